@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+)
+
+var encodedAuthorizationMessageRegexp = regexp.MustCompile(`Encoded authorization failure message: ([A-Za-z0-9_-]+)`)
+
+// DecodeAuthorizationFailureMessage decodes an encoded authorization failure message contained in an error
+// returned by the AWS SDK for Go v2 or v1, using the STS client configured by c.
+// If the message is decoded, the error is wrapped in an AuthorizationFailureError.
+// Otherwise, including when the caller does not have permission to call sts:DecodeAuthorizationMessage, err is returned unchanged.
+func DecodeAuthorizationFailureMessage(ctx context.Context, awsConfig aws.Config, c *Config, err error) error {
+	if err == nil || IsAuthorizationFailureError(err) {
+		return err
+	}
+
+	match := encodedAuthorizationMessageRegexp.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+
+	logger := logging.RetrieveLogger(ctx)
+
+	output, decodeErr := stsClient(ctx, awsConfig, c).DecodeAuthorizationMessage(ctx, &sts.DecodeAuthorizationMessageInput{
+		EncodedMessage: aws.String(match[1]),
+	})
+	if decodeErr != nil {
+		logger.Warn(ctx, "Unable to decode authorization failure message", map[string]any{
+			"error": decodeErr,
+		})
+		return err
+	}
+
+	return AuthorizationFailureError{
+		Err:            err,
+		DecodedMessage: aws.ToString(output.DecodedMessage),
+	}
+}
+
+// authorizationMessageDecoder decodes encoded authorization failure messages in operation errors.
+// awsConfig must not itself include the decoder.
+type authorizationMessageDecoder struct {
+	awsConfig aws.Config
+	config    *Config
+}
+
+// ID is the middleware identifier.
+func (d *authorizationMessageDecoder) ID() string {
+	return "TF_AWS_AuthorizationMessageDecoder"
+}
+
+func (d *authorizationMessageDecoder) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+) (
+	out middleware.InitializeOutput, metadata middleware.Metadata, err error,
+) {
+	out, metadata, err = next.HandleInitialize(ctx, in)
+
+	if err != nil {
+		err = DecodeAuthorizationFailureMessage(ctx, d.awsConfig, d.config, err)
+	}
+
+	return out, metadata, err
+}
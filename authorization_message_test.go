@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestDecodeAuthorizationMessages(t *testing.T) {
+	testCases := map[string]struct {
+		DecodeAuthorizationMessages bool
+		MockStsEndpoints            []*servicemocks.MockEndpoint
+		ExpectDecoded               bool
+	}{
+		"disabled": {
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityInvalidEndpointEncodedAccessDenied,
+				servicemocks.MockStsDecodeAuthorizationMessageValidEndpoint,
+			},
+		},
+		"enabled": {
+			DecodeAuthorizationMessages: true,
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityInvalidEndpointEncodedAccessDenied,
+				servicemocks.MockStsDecodeAuthorizationMessageValidEndpoint,
+			},
+			ExpectDecoded: true,
+		},
+		"enabled no encoded message": {
+			DecodeAuthorizationMessages: true,
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityInvalidEndpointAccessDenied,
+				servicemocks.MockStsDecodeAuthorizationMessageValidEndpoint,
+			},
+		},
+		"enabled decode access denied": {
+			DecodeAuthorizationMessages: true,
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityInvalidEndpointEncodedAccessDenied,
+				servicemocks.MockStsDecodeAuthorizationMessageInvalidEndpointAccessDenied,
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			ts := servicemocks.MockAwsApiServer("STS", testCase.MockStsEndpoints)
+			defer ts.Close()
+
+			config := &Config{
+				AccessKey:                   servicemocks.MockStaticAccessKey,
+				DecodeAuthorizationMessages: testCase.DecodeAuthorizationMessages,
+				Region:                      "us-east-1",
+				SecretKey:                   servicemocks.MockStaticSecretKey,
+				StsEndpoint:                 ts.URL,
+			}
+
+			_, _, err := GetAwsConfig(context.Background(), config)
+			if err == nil {
+				t.Fatal("expected error, got none")
+			}
+
+			var authErr AuthorizationFailureError
+			if a, e := errors.As(err, &authErr), testCase.ExpectDecoded; a != e {
+				t.Fatalf("expected decoded error to be %t, got %t: %s", e, a, err)
+			}
+			if testCase.ExpectDecoded {
+				if a, e := authErr.DecodedMessage, servicemocks.MockStsDecodedAuthorizationMessage; a != e {
+					t.Errorf("expected decoded message %q, got %q", e, a)
+				}
+			}
+		})
+	}
+}
@@ -5,12 +5,10 @@ package awsbase
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log"
-	"net"
 	"os"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -22,8 +20,13 @@ import (
 	"github.com/aws/smithy-go/middleware"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/awsconfig"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/constants"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/detach"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/endpoints"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/invalidate"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/logfilter"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/metrics"
 	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+	"github.com/hashicorp/aws-sdk-go-base/v2/tfawserr"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
@@ -54,8 +57,19 @@ func GetAwsConfig(ctx context.Context, c *Config) (context.Context, aws.Config,
 
 	credentialsProvider, initialSource, err := getCredentialsProvider(baseCtx, c)
 	if err != nil {
+		credentialsError(c, err)
 		return ctx, aws.Config{}, err
 	}
+	var reloadingProvider *reloadingCredentialsProvider
+	if c.WatchCredentialFiles != nil {
+		reloadingProvider = &reloadingCredentialsProvider{
+			config:   c,
+			provider: credentialsProvider,
+		}
+		// Cached here rather than by LoadDefaultConfig so that invalidating the cache also invalidates the reloading provider
+		credentialsProvider = invalidate.NewCredentialsCache(reloadingProvider, c.CredentialsCacheOptions)
+	}
+	credentialsProvider = withCredentialsHooks(credentialsProvider, c)
 	creds, _ := credentialsProvider.Retrieve(baseCtx)
 	logger.Info(baseCtx, "Retrieved credentials", map[string]any{
 		"tf_aws.credentials_source": creds.Source,
@@ -94,18 +108,74 @@ func GetAwsConfig(ctx context.Context, c *Config) (context.Context, aws.Config,
 
 	resolveRetryer(baseCtx, &awsConfig)
 
-	if !c.SkipCredsValidation {
-		if _, _, err := getAccountIDAndPartitionFromSTSGetCallerIdentity(baseCtx, stsClient(baseCtx, awsConfig, c)); err != nil {
+	// Background work outlives ctx and is stopped by CloseAwsConfig
+	backgroundCtx, stopBackground := context.WithCancel(detach.Context(baseCtx))
+	var background bool
+
+	if reloadingProvider != nil {
+		if err := watchCredentialFiles(backgroundCtx, awsConfig, reloadingProvider, c); err != nil {
+			stopBackground()
+			return ctx, aws.Config{}, fmt.Errorf("watching credential files: %w", err)
+		}
+		background = true
+	}
+
+	if c.CredentialsRefresh != nil && c.CredentialsRefresh.BackgroundRefresh {
+		refreshCredentialsInBackground(backgroundCtx, awsConfig.Credentials)
+		background = true
+	}
+
+	addExpiredCredentialsInvalidator(&awsConfig)
+
+	if c.DecodeAuthorizationMessages {
+		decoder := &authorizationMessageDecoder{
+			awsConfig: awsConfig.Copy(),
+			config:    c,
+		}
+		awsConfig.APIOptions = append(awsConfig.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(decoder, middleware.After)
+		})
+	}
+
+	// Offline mode implies SkipCredsValidation
+	if !c.SkipCredsValidation && !c.Offline {
+		err := withPhaseTimeout(baseCtx, c, PhaseCredentialsValidation, func(ctx context.Context) error {
+			_, _, err := getAccountIDAndPartitionFromSTSGetCallerIdentity(ctx, stsClient(ctx, awsConfig, c))
+			return err
+		})
+		if err != nil {
+			stopBackground()
 			return ctx, awsConfig, fmt.Errorf("validating provider credentials: %w", err)
 		}
 	}
 
+	if background {
+		backgroundTasks.Store(awsConfig.Credentials, stopBackground)
+	} else {
+		stopBackground()
+	}
+
 	return ctx, awsConfig, nil
 }
 
+// backgroundTasks holds the function stopping the background work started by GetAwsConfig, keyed by the credentials provider
+// of the returned aws.Config.
+var backgroundTasks sync.Map
+
+// CloseAwsConfig stops the background work started by GetAwsConfig or Deriver.Derive for awsConfig, i.e. watching
+// the credential files and refreshing credentials in the background.
+// ConfigCache and Deriver close the aws.Configs that they evict.
+// The background work runs until CloseAwsConfig is called, independently of the context passed to GetAwsConfig.
+// awsConfig remains usable after it is closed. Closing an aws.Config without background work has no effect.
+func CloseAwsConfig(awsConfig aws.Config) {
+	if stop, ok := backgroundTasks.LoadAndDelete(awsConfig.Credentials); ok {
+		stop.(context.CancelFunc)()
+	}
+}
+
 // Adapted from the per-service-client `resolveRetryer()` functions in the AWS SDK for Go v2
 // e.g. https://github.com/aws/aws-sdk-go-v2/blob/main/service/accessanalyzer/api_client.go
-// Currently only supports "standard" retry mode
+// Supports the "standard" and "adaptive" retry modes, defaulting to "standard"
 func resolveRetryer(ctx context.Context, awsConfig *aws.Config) {
 	var standardOptions []func(*retry.StandardOptions)
 
@@ -115,9 +185,25 @@ func resolveRetryer(ctx context.Context, awsConfig *aws.Config) {
 		})
 	}
 
+	retryMode := aws.RetryModeStandard
+	if v, found, _ := awsconfig.GetRetryMode(ctx, awsConfig.ConfigSources); found && v != "" {
+		retryMode = v
+	}
+	awsConfig.RetryMode = retryMode
+
 	awsConfig.Retryer = func() aws.Retryer {
+		var retryer aws.RetryerV2
+		switch retryMode {
+		case aws.RetryModeAdaptive:
+			retryer = retry.NewAdaptiveMode(func(ao *retry.AdaptiveModeOptions) {
+				ao.StandardOptions = append(ao.StandardOptions, standardOptions...)
+			})
+		default:
+			retryer = retry.NewStandard(standardOptions...)
+		}
+
 		return &networkErrorShortcutter{
-			RetryerV2: retry.NewStandard(standardOptions...),
+			RetryerV2: retryer,
 		}
 	}
 }
@@ -129,18 +215,12 @@ type networkErrorShortcutter struct {
 
 // We're misusing RetryDelay here, since this is the only function that takes the attempt count
 func (r *networkErrorShortcutter) RetryDelay(attempt int, err error) (time.Duration, error) {
-	if attempt >= constants.MaxNetworkRetryCount {
-		var netOpErr *net.OpError
-		if errors.As(err, &netOpErr) {
-			// It's disappointing that we have to do string matching here, rather than being able to using `errors.Is()` or even strings exported by the Go `net` package
-			if strings.Contains(netOpErr.Error(), "no such host") || strings.Contains(netOpErr.Error(), "connection refused") {
-				// TODO: figure out how to get correct logger here
-				log.Printf("[WARN] Disabling retries after next request due to networking error: %s", err)
-				return 0, &retry.MaxAttemptsError{
-					Attempt: attempt,
-					Err:     err,
-				}
-			}
+	if attempt >= constants.MaxNetworkRetryCount && tfawserr.IsEndpointUnreachable(err) {
+		// TODO: figure out how to get correct logger here
+		log.Printf("[WARN] Disabling retries after next request due to networking error: %s", err)
+		return 0, &retry.MaxAttemptsError{
+			Attempt: attempt,
+			Err:     err,
 		}
 	}
 
@@ -151,9 +231,13 @@ func GetAwsAccountIDAndPartition(ctx context.Context, awsConfig aws.Config, c *C
 	ctx, logger := logging.New(ctx, loggerName)
 	ctx = logging.RegisterLogger(ctx, logger)
 
-	if !c.SkipCredsValidation {
-		stsClient := stsClient(ctx, awsConfig, c)
-		accountID, partition, err := getAccountIDAndPartitionFromSTSGetCallerIdentity(ctx, stsClient)
+	// Offline mode implies SkipCredsValidation and SkipRequestingAccountId
+	if !c.SkipCredsValidation && !c.Offline {
+		var accountID, partition string
+		err := withPhaseTimeout(ctx, c, PhaseAccountIDLookup, func(ctx context.Context) (err error) {
+			accountID, partition, err = getAccountIDAndPartitionFromSTSGetCallerIdentity(ctx, stsClient(ctx, awsConfig, c))
+			return err
+		})
 		if err != nil {
 			return "", "", fmt.Errorf("validating provider credentials: %w", err)
 		}
@@ -161,15 +245,17 @@ func GetAwsAccountIDAndPartition(ctx context.Context, awsConfig aws.Config, c *C
 		return accountID, partition, nil
 	}
 
-	if !c.SkipRequestingAccountId {
+	if !c.SkipRequestingAccountId && !c.Offline {
 		credentialsProviderName := ""
 		if credentialsValue, err := awsConfig.Credentials.Retrieve(context.Background()); err == nil {
 			credentialsProviderName = credentialsValue.Source
 		}
 
-		iamClient := iamClient(ctx, awsConfig, c)
-		stsClient := stsClient(ctx, awsConfig, c)
-		accountID, partition, err := getAccountIDAndPartition(ctx, iamClient, stsClient, credentialsProviderName)
+		var accountID, partition string
+		err := withPhaseTimeout(ctx, c, PhaseAccountIDLookup, func(ctx context.Context) (err error) {
+			accountID, partition, err = getAccountIDAndPartition(ctx, iamClient(ctx, awsConfig, c), stsClient(ctx, awsConfig, c), credentialsProviderName)
+			return err
+		})
 
 		if err == nil {
 			return accountID, partition, nil
@@ -224,9 +310,56 @@ func commonLoadOptions(ctx context.Context, c *Config) ([]func(*config.LoadOptio
 		apiOptions = append(apiOptions, awsmiddleware.AddUserAgentKey(v))
 	}
 
+	recorder, err := metrics.New(c.MeterProvider)
+	if err != nil {
+		return nil, fmt.Errorf("configuring metrics: %w", err)
+	}
+
+	if recorder != nil {
+		apiOptions = append(apiOptions, func(stack *middleware.Stack) error {
+			// Added at the end of the Initialize step, after the service metadata has been registered, so that the whole call is measured
+			if err := stack.Initialize.Add(&callMetricsRecorder{metrics: recorder}, middleware.After); err != nil {
+				return err
+			}
+			// Added at the front of the Deserialize step so that API errors have already been deserialized
+			return stack.Deserialize.Add(&attemptMetricsRecorder{metrics: recorder}, middleware.Before)
+		})
+	}
+
+	trafficLog, err := c.HTTPTrafficLog()
+	if err != nil {
+		return nil, err
+	}
+
+	logFilter, err := logfilter.New(c.DebugLogFilter)
+	if err != nil {
+		return nil, fmt.Errorf("configuring debug log filter: %w", err)
+	}
+
+	// The request-response logger also measures the attempt duration used by attemptMetricsRecorder
+	if !c.SuppressDebugLog || recorder != nil || trafficLog != nil {
+		apiOptions = append(apiOptions, func(stack *middleware.Stack) error {
+			if err := stack.Initialize.Add(&logDecider{filter: logFilter}, middleware.After); err != nil {
+				return err
+			}
+			return stack.Deserialize.Add(&requestResponseLogger{
+				suppressLog: c.SuppressDebugLog,
+				filter:      logFilter,
+				trafficLog:  trafficLog,
+			}, middleware.After)
+		})
+	}
+
 	if !c.SuppressDebugLog {
 		apiOptions = append(apiOptions, func(stack *middleware.Stack) error {
-			return stack.Deserialize.Add(&requestResponseLogger{}, middleware.After)
+			// Added at the front of the Deserialize step so that API errors have already been deserialized
+			return stack.Deserialize.Add(&responseLogger{}, middleware.Before)
+		})
+	}
+
+	if c.Timeouts != nil {
+		apiOptions = append(apiOptions, func(stack *middleware.Stack) error {
+			return stack.Finalize.Add(&endpointRecorderMiddleware{}, middleware.After)
 		})
 	}
 
@@ -237,6 +370,13 @@ func commonLoadOptions(ctx context.Context, c *Config) ([]func(*config.LoadOptio
 		config.WithEC2IMDSClientEnableState(c.EC2MetadataServiceEnableState),
 	}
 
+	if c.CredentialsRefresh != nil {
+		loadOptions = append(
+			loadOptions,
+			config.WithCredentialsCacheOptions(c.CredentialsCacheOptions),
+		)
+	}
+
 	if !c.SuppressDebugLog {
 		loadOptions = append(
 			loadOptions,
@@ -6,14 +6,18 @@ package awsbase
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -30,6 +34,7 @@ import (
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/awsconfig"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/constants"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/test"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
 	"github.com/hashicorp/aws-sdk-go-base/v2/mockdata"
 	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
 	"github.com/hashicorp/aws-sdk-go-base/v2/useragent"
@@ -1428,6 +1433,7 @@ func TestMaxAttempts(t *testing.T) {
 		EnvironmentVariables    map[string]string
 		SharedConfigurationFile string
 		ExpectedMaxAttempts     int
+		ExpectedRetryMode       aws.RetryMode
 	}{
 		"no configuration": {
 			Config: &Config{
@@ -1495,6 +1501,19 @@ max_attempts = 10
 `,
 			ExpectedMaxAttempts: 5,
 		},
+
+		"adaptive retry mode": {
+			Config: &Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				SecretKey: servicemocks.MockStaticSecretKey,
+			},
+			EnvironmentVariables: map[string]string{
+				"AWS_MAX_ATTEMPTS": "5",
+				"AWS_RETRY_MODE":   "adaptive",
+			},
+			ExpectedMaxAttempts: 5,
+			ExpectedRetryMode:   aws.RetryModeAdaptive,
+		},
 	}
 
 	for testName, testCase := range testCases {
@@ -1540,6 +1559,21 @@ max_attempts = 10
 			if a, e := retryer.MaxAttempts(), testCase.ExpectedMaxAttempts; a != e {
 				t.Errorf(`expected MaxAttempts "%d", got: "%d"`, e, a)
 			}
+
+			expectedRetryMode := testCase.ExpectedRetryMode
+			if expectedRetryMode == "" {
+				expectedRetryMode = aws.RetryModeStandard
+			}
+			if a, e := awsConfig.RetryMode, expectedRetryMode; a != e {
+				t.Errorf("expected RetryMode %q, got %q", e, a)
+			}
+			shortcutter, ok := retryer.(*networkErrorShortcutter)
+			if !ok {
+				t.Fatalf("expected *networkErrorShortcutter, got %T", retryer)
+			}
+			if _, adaptive := shortcutter.RetryerV2.(*retry.AdaptiveMode); adaptive != (expectedRetryMode == aws.RetryModeAdaptive) {
+				t.Errorf("expected %s retryer, got %T", expectedRetryMode, shortcutter.RetryerV2)
+			}
 		})
 	}
 }
@@ -2752,7 +2786,7 @@ web_identity_token_file = no-such-file
 			},
 			ExpectedCredentialsValue: mockdata.MockStsAssumeRoleWithWebIdentityCredentials,
 			ExpectedError: func(err error) bool {
-				return strings.Contains(err.Error(), "one of WebIdentityToken, WebIdentityTokenFile must be set")
+				return strings.Contains(err.Error(), "one of WebIdentityToken, WebIdentityTokenFile, WebIdentityTokenRetriever must be set")
 			},
 		},
 	}
@@ -3320,3 +3354,601 @@ func TestLogger(t *testing.T) {
 		}
 	}
 }
+
+func TestDebugLogFilter(t *testing.T) {
+	testCases := map[string]struct {
+		Filter                *DebugLogFilter
+		MockStsEndpoints      []*servicemocks.MockEndpoint
+		ExpectError           bool
+		ExpectRequestLog      bool
+		ExpectRequestBody     bool
+		ExpectResponseLog     bool
+		ExpectResponseBody    bool
+		ExpectResponseReqBody bool
+	}{
+		"no filter": {
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityValidEndpoint,
+			},
+			ExpectRequestLog:   true,
+			ExpectRequestBody:  true,
+			ExpectResponseLog:  true,
+			ExpectResponseBody: true,
+		},
+		"service excluded": {
+			Filter: &DebugLogFilter{
+				Rules: []DebugLogRule{
+					{Service: "STS", Exclude: true},
+				},
+			},
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityValidEndpoint,
+			},
+		},
+		"unmatched excluded": {
+			Filter: &DebugLogFilter{
+				Rules: []DebugLogRule{
+					{Service: "EC2"},
+				},
+				ExcludeUnmatched: true,
+			},
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityValidEndpoint,
+			},
+		},
+		"bodies on error only success": {
+			Filter: &DebugLogFilter{
+				Rules: []DebugLogRule{
+					{Operation: "GetCallerIdentity", BodiesOnErrorOnly: true},
+				},
+			},
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityValidEndpoint,
+			},
+			ExpectRequestLog:  true,
+			ExpectResponseLog: true,
+		},
+		"bodies on error only error": {
+			Filter: &DebugLogFilter{
+				BodiesOnErrorOnly: true,
+			},
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityInvalidEndpointAccessDenied,
+			},
+			ExpectError:           true,
+			ExpectRequestLog:      true,
+			ExpectResponseLog:     true,
+			ExpectResponseBody:    true,
+			ExpectResponseReqBody: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			ctx := tflogtest.RootLogger(context.Background(), &buf)
+
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			ts := servicemocks.MockAwsApiServer("STS", testCase.MockStsEndpoints)
+			defer ts.Close()
+
+			config := &Config{
+				AccessKey:      servicemocks.MockStaticAccessKey,
+				DebugLogFilter: testCase.Filter,
+				Region:         "us-east-1",
+				SecretKey:      servicemocks.MockStaticSecretKey,
+				StsEndpoint:    ts.URL,
+			}
+
+			_, _, err := GetAwsConfig(ctx, config)
+			if err != nil && !testCase.ExpectError {
+				t.Fatalf("expected no error, got '%[1]T' error: %[1]s", err)
+			}
+			if err == nil && testCase.ExpectError {
+				t.Fatal("expected error, got none")
+			}
+
+			lines, err := tflogtest.MultilineJSONDecode(&buf)
+			if err != nil {
+				t.Fatalf("decoding log lines: %s", err)
+			}
+
+			var requestLine, responseLine map[string]any
+			for _, line := range lines {
+				switch line["@message"] {
+				case "HTTP Request Sent":
+					requestLine = line
+				case "HTTP Response Received":
+					responseLine = line
+				}
+			}
+
+			if a, e := requestLine != nil, testCase.ExpectRequestLog; a != e {
+				t.Fatalf("expected request logged to be %t, got %t", e, a)
+			}
+			if requestLine != nil {
+				if _, a := requestLine["http.request.body"]; a != testCase.ExpectRequestBody {
+					t.Errorf("expected request body in request log to be %t, got %t", testCase.ExpectRequestBody, a)
+				}
+			}
+
+			if a, e := responseLine != nil, testCase.ExpectResponseLog; a != e {
+				t.Fatalf("expected response logged to be %t, got %t", e, a)
+			}
+			if responseLine != nil {
+				if _, a := responseLine["http.response.body"]; a != testCase.ExpectResponseBody {
+					t.Errorf("expected response body in response log to be %t, got %t", testCase.ExpectResponseBody, a)
+				}
+				if _, a := responseLine["http.request.body"]; a != testCase.ExpectResponseReqBody {
+					t.Errorf("expected request body in response log to be %t, got %t", testCase.ExpectResponseReqBody, a)
+				}
+			}
+		})
+	}
+}
+
+func TestResponseLogEnrichment(t *testing.T) {
+	testCases := map[string]struct {
+		MockStsEndpoints []*servicemocks.MockEndpoint
+		CloseServer      bool
+		ExpectedMessage  string
+		ExpectedFields   map[string]any
+	}{
+		"success": {
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityValidEndpoint,
+			},
+			ExpectedMessage: "HTTP Response Received",
+			ExpectedFields: map[string]any{
+				"aws.request_id": servicemocks.MockRequestID,
+				"aws.attempt":    float64(1),
+			},
+		},
+		"API error": {
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityInvalidEndpointAccessDenied,
+			},
+			ExpectedMessage: "HTTP Response Received",
+			ExpectedFields: map[string]any{
+				"aws.request_id":    servicemocks.MockRequestID,
+				"aws.attempt":       float64(1),
+				"aws.error_code":    "AccessDenied",
+				"aws.error_message": "User: arn:aws:iam::123456789012:user/Bob is not authorized to perform: sts:GetCallerIdentity",
+			},
+		},
+		"send error": {
+			CloseServer:     true,
+			ExpectedMessage: "HTTP Request Failed",
+			ExpectedFields: map[string]any{
+				"aws.attempt": float64(1),
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			ctx := tflogtest.RootLogger(context.Background(), &buf)
+
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			ts := servicemocks.MockAwsApiServer("STS", testCase.MockStsEndpoints)
+			defer ts.Close()
+			if testCase.CloseServer {
+				ts.Close()
+			}
+
+			config := &Config{
+				AccessKey:   servicemocks.MockStaticAccessKey,
+				MaxRetries:  1,
+				Region:      "us-east-1",
+				SecretKey:   servicemocks.MockStaticSecretKey,
+				StsEndpoint: ts.URL,
+			}
+
+			_, _, err := GetAwsConfig(ctx, config)
+			if err == nil && testCase.ExpectedFields["aws.error_code"] != nil {
+				t.Fatal("expected error, got none")
+			}
+
+			lines, err := tflogtest.MultilineJSONDecode(&buf)
+			if err != nil {
+				t.Fatalf("decoding log lines: %s", err)
+			}
+
+			var line map[string]any
+			for _, l := range lines {
+				if l["@message"] == testCase.ExpectedMessage {
+					line = l
+					break
+				}
+			}
+			if line == nil {
+				t.Fatalf("expected log line %q, got none", testCase.ExpectedMessage)
+			}
+
+			for k, e := range testCase.ExpectedFields {
+				if a := line[k]; a != e {
+					t.Errorf("expected field %q to be %v, got %v", k, e, a)
+				}
+			}
+
+			if testCase.CloseServer {
+				if _, ok := line["aws.error_message"]; !ok {
+					t.Error("expected field \"aws.error_message\", got none")
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPTrafficLog(t *testing.T) {
+	testCases := map[string]struct {
+		Filename string
+		Format   string
+		Decode   func(t *testing.T, b []byte) []map[string]any
+	}{
+		"HAR by extension": {
+			Filename: "traffic.har",
+			Decode:   decodeHAREntries,
+		},
+		"HAR by format": {
+			Filename: "traffic.log",
+			Format:   HTTPTrafficLogFormatHAR,
+			Decode:   decodeHAREntries,
+		},
+		"JSON lines": {
+			Filename: "traffic.jsonl",
+			Decode:   decodeJSONLinesEntries,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			ts := servicemocks.MockAwsApiServer("STS", []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityValidEndpoint,
+			})
+			defer ts.Close()
+
+			filename := filepath.Join(t.TempDir(), testCase.Filename)
+			defer logging.CloseTrafficLogs()
+
+			config := &Config{
+				AccessKey:            servicemocks.MockStaticAccessKey,
+				HTTPTrafficLogFile:   filename,
+				HTTPTrafficLogFormat: testCase.Format,
+				Region:               "us-east-1",
+				SecretKey:            servicemocks.MockStaticSecretKey,
+				StsEndpoint:          ts.URL,
+				SuppressDebugLog:     true,
+			}
+
+			_, _, err := GetAwsConfig(context.Background(), config)
+			if err != nil {
+				t.Fatalf("GetAwsConfig: unexpected '%[1]T': %[1]s", err)
+			}
+
+			b, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("reading HTTP traffic log: %s", err)
+			}
+
+			entries := testCase.Decode(t, b)
+			if a, e := len(entries), 1; a != e {
+				t.Fatalf("expected %d entries, got %d", e, a)
+			}
+			entry := entries[0]
+
+			if a, e := entry["_awsOperation"], "GetCallerIdentity"; a != e {
+				t.Errorf("expected operation %q, got %q", e, a)
+			}
+
+			response := entry["response"].(map[string]any)
+			if a, e := response["status"], float64(http.StatusOK); a != e {
+				t.Errorf("expected status %v, got %v", e, a)
+			}
+
+			request := entry["request"].(map[string]any)
+			for _, h := range request["headers"].([]any) {
+				header := h.(map[string]any)
+				if header["name"] != "Authorization" {
+					continue
+				}
+				value := header["value"].(string)
+				if !strings.Contains(value, "Signature=*****") {
+					t.Errorf("expected Authorization header to be masked, got %q", value)
+				}
+			}
+		})
+	}
+}
+
+// TestHTTPTrafficLogRedaction checks that credentials and tokens in request and response bodies are not written to the traffic log.
+func TestHTTPTrafficLogRedaction(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", []*servicemocks.MockEndpoint{
+		servicemocks.MockStsAssumeRoleWithWebIdentityValidEndpoint,
+	})
+	defer closeSts()
+
+	filename := filepath.Join(t.TempDir(), "traffic.har")
+	defer logging.CloseTrafficLogs()
+
+	config := &Config{
+		AssumeRoleWithWebIdentity: &AssumeRoleWithWebIdentity{
+			RoleARN:          servicemocks.MockStsAssumeRoleWithWebIdentityArn,
+			SessionName:      servicemocks.MockStsAssumeRoleWithWebIdentitySessionName,
+			WebIdentityToken: servicemocks.MockWebIdentityToken,
+		},
+		HTTPTrafficLogFile:  filename,
+		Region:              "us-east-1",
+		SkipCredsValidation: true,
+		StsEndpoint:         stsEndpoint,
+		SuppressDebugLog:    true,
+	}
+
+	if _, _, err := GetAwsConfig(context.Background(), config); err != nil {
+		t.Fatalf("GetAwsConfig: unexpected '%[1]T': %[1]s", err)
+	}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("reading HTTP traffic log: %s", err)
+	}
+	if len(decodeHAREntries(t, b)) == 0 {
+		t.Fatal("expected entries, got none")
+	}
+	for _, secret := range []string{
+		servicemocks.MockStsAssumeRoleWithWebIdentitySecretKey,
+		servicemocks.MockStsAssumeRoleWithWebIdentitySessionToken,
+		"WebIdentityToken=" + servicemocks.MockWebIdentityToken,
+	} {
+		if bytes.Contains(b, []byte(secret)) {
+			t.Errorf("expected %q to be redacted", secret)
+		}
+	}
+}
+
+// TestHTTPTrafficLogTruncation checks that long request bodies are truncated in the traffic log and sent in full.
+func TestHTTPTrafficLogTruncation(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	policy := fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Sid":"%s","Effect":"Allow","Action":"s3:GetObject","Resource":"*"}],"End":"Last"}`, strings.Repeat("A", 1024))
+
+	closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", []*servicemocks.MockEndpoint{
+		{
+			Request: &servicemocks.MockRequest{
+				Body: url.Values{
+					"Action":          []string{"AssumeRole"},
+					"DurationSeconds": []string{"900"},
+					"Policy":          []string{policy},
+					"RoleArn":         []string{servicemocks.MockStsAssumeRoleArn},
+					"RoleSessionName": []string{servicemocks.MockStsAssumeRoleSessionName},
+					"Version":         []string{"2011-06-15"},
+				}.Encode(),
+				Method: http.MethodPost,
+				Uri:    "/",
+			},
+			Response: servicemocks.MockStsAssumeRoleValidEndpoint.Response,
+		},
+	})
+	defer closeSts()
+
+	filename := filepath.Join(t.TempDir(), "traffic.jsonl")
+	defer logging.CloseTrafficLogs()
+
+	config := &Config{
+		AccessKey: servicemocks.MockStaticAccessKey,
+		AssumeRole: &AssumeRole{
+			Policy:      policy,
+			RoleARN:     servicemocks.MockStsAssumeRoleArn,
+			SessionName: servicemocks.MockStsAssumeRoleSessionName,
+		},
+		HTTPTrafficLogFile:  filename,
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+		StsEndpoint:         stsEndpoint,
+		SuppressDebugLog:    true,
+	}
+
+	if _, _, err := GetAwsConfig(context.Background(), config); err != nil {
+		t.Fatalf("GetAwsConfig: unexpected '%[1]T': %[1]s", err)
+	}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("reading HTTP traffic log: %s", err)
+	}
+
+	entries := decodeJSONLinesEntries(t, b)
+	if a, e := len(entries), 1; a != e {
+		t.Fatalf("expected %d entries, got %d", e, a)
+	}
+	request := entries[0]["request"].(map[string]any)
+	postData, ok := request["postData"].(map[string]any)
+	if !ok {
+		t.Fatal("expected request postData, got none")
+	}
+	if _, ok := postData["comment"]; !ok {
+		t.Error("expected truncated request body to be marked with a comment")
+	}
+	if text := postData["text"].(string); strings.Contains(text, "Last") {
+		t.Errorf("expected request body to be truncated, got %q", text)
+	}
+	if a := request["bodySize"].(float64); a <= 1024 {
+		t.Errorf("expected size of full request body, got %v", a)
+	}
+}
+
+func TestCloseTrafficLogs(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "traffic.jsonl")
+
+	l, err := logging.OpenTrafficLog(filename, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := logging.CloseTrafficLogs(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := l.Write(&logging.TrafficEntry{}); err != nil {
+		t.Errorf("expected writes after Close to be discarded, got error: %s", err)
+	}
+
+	reopened, err := logging.OpenTrafficLog(filename, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer reopened.Close()
+	if reopened == l {
+		t.Error("expected closed traffic log to be reopened")
+	}
+}
+
+// TestDebugLogFilterPerCall checks that the filter is evaluated once per API call, so that retries of a sampled call
+// are logged, and that the request body withheld by BodiesOnErrorOnly is logged when a request fails.
+func TestDebugLogFilterPerCall(t *testing.T) {
+	testCases := map[string]struct {
+		Filter              *DebugLogFilter
+		Handler             func(n int32, w http.ResponseWriter)
+		CloseServer         bool
+		ExpectedRequestLogs int
+		ExpectFailedReqBody bool
+	}{
+		"sampled call with retry": {
+			Filter: &DebugLogFilter{
+				Rules: []DebugLogRule{
+					{Operation: "GetCallerIdentity", SampleRate: 2},
+				},
+			},
+			Handler: func(n int32, w http.ResponseWriter) {
+				if n == 1 {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Content-Type", "text/xml")
+				fmt.Fprintln(w, servicemocks.MockStsGetCallerIdentityValidResponseBody)
+			},
+			ExpectedRequestLogs: 2,
+		},
+		"bodies on error only request failed": {
+			Filter: &DebugLogFilter{
+				BodiesOnErrorOnly: true,
+			},
+			CloseServer:         true,
+			ExpectFailedReqBody: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			ctx := tflogtest.RootLogger(context.Background(), &buf)
+
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			var requests int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				testCase.Handler(atomic.AddInt32(&requests, 1), w)
+			}))
+			defer ts.Close()
+			if testCase.CloseServer {
+				ts.Close()
+			}
+
+			config := &Config{
+				AccessKey:      servicemocks.MockStaticAccessKey,
+				DebugLogFilter: testCase.Filter,
+				MaxRetries:     2,
+				Region:         "us-east-1",
+				SecretKey:      servicemocks.MockStaticSecretKey,
+				StsEndpoint:    ts.URL,
+			}
+
+			_, _, err := GetAwsConfig(ctx, config)
+			if err != nil && !testCase.CloseServer {
+				t.Fatalf("expected no error, got '%[1]T' error: %[1]s", err)
+			}
+
+			lines, err := tflogtest.MultilineJSONDecode(&buf)
+			if err != nil {
+				t.Fatalf("decoding log lines: %s", err)
+			}
+
+			var requestLogs int
+			var failedLine map[string]any
+			for _, line := range lines {
+				switch line["@message"] {
+				case "HTTP Request Sent":
+					requestLogs++
+				case "HTTP Request Failed":
+					failedLine = line
+				}
+			}
+
+			if testCase.ExpectedRequestLogs > 0 {
+				if a, e := requestLogs, testCase.ExpectedRequestLogs; a != e {
+					t.Errorf("expected %d request logs, got %d", e, a)
+				}
+			}
+			if testCase.ExpectFailedReqBody {
+				if failedLine == nil {
+					t.Fatal("expected failed request log, got none")
+				}
+				if _, ok := failedLine["http.request.body"]; !ok {
+					t.Error("expected request body in failed request log")
+				}
+			}
+		})
+	}
+}
+
+func decodeHAREntries(t *testing.T, b []byte) []map[string]any {
+	t.Helper()
+
+	var har struct {
+		Log struct {
+			Version string           `json:"version"`
+			Entries []map[string]any `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(b, &har); err != nil {
+		t.Fatalf("decoding HAR file: %s", err)
+	}
+	if a, e := har.Log.Version, "1.2"; a != e {
+		t.Errorf("expected HAR version %q, got %q", e, a)
+	}
+	return har.Log.Entries
+}
+
+func decodeJSONLinesEntries(t *testing.T, b []byte) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("decoding JSON line: %s", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
@@ -0,0 +1,128 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+)
+
+// VaultAWSIAMServerIDHeader is the header containing the server ID expected by HashiCorp Vault's AWS auth method.
+const VaultAWSIAMServerIDHeader = "X-Vault-AWS-IAM-Server-ID"
+
+// CallerIdentityProofOptions configures GetCallerIdentityProof.
+type CallerIdentityProofOptions struct {
+	// ServerID is sent in the VaultAWSIAMServerIDHeader header, which is signed.
+	// It must match the iam_server_id_header_value configured in Vault, if any.
+	ServerID string
+}
+
+// CallerIdentityProof is a signed sts:GetCallerIdentity request that proves the caller's AWS identity
+// to a third party, such as HashiCorp Vault, which sends the request to STS.
+type CallerIdentityProof struct {
+	Method  string
+	URL     string
+	Headers http.Header
+	Body    string
+}
+
+// VaultLoginData returns the proof in the format expected by the login endpoint of HashiCorp Vault's AWS auth method
+// using the iam auth type. The role must be added by the caller.
+func (p CallerIdentityProof) VaultLoginData() (map[string]any, error) {
+	headers, err := json.Marshal(p.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("encoding headers: %w", err)
+	}
+
+	return map[string]any{
+		"iam_http_request_method": p.Method,
+		"iam_request_url":         base64.StdEncoding.EncodeToString([]byte(p.URL)),
+		"iam_request_headers":     base64.StdEncoding.EncodeToString(headers),
+		"iam_request_body":        base64.StdEncoding.EncodeToString([]byte(p.Body)),
+	}, nil
+}
+
+// GetCallerIdentityProof signs an sts:GetCallerIdentity request with the credentials of awsConfig, using the
+// STS client configured by c, and returns it without sending it.
+// The request is sent to the STS endpoint for the partition of the STS region, unless StsEndpoint is set.
+func GetCallerIdentityProof(ctx context.Context, awsConfig aws.Config, c *Config, opts CallerIdentityProofOptions) (CallerIdentityProof, error) {
+	ctx, logger := logging.New(ctx, loggerName)
+	ctx = logging.RegisterLogger(ctx, logger)
+
+	var proof CallerIdentityProof
+	_, err := stsClient(ctx, awsConfig, c).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}, func(o *sts.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			if opts.ServerID != "" {
+				if err := stack.Build.Add(serverIDHeaderMiddleware(opts.ServerID), middleware.After); err != nil {
+					return err
+				}
+			}
+			return stack.Finalize.Add(captureCallerIdentityProofMiddleware(&proof), middleware.After)
+		})
+	})
+	if err != nil {
+		return CallerIdentityProof{}, fmt.Errorf("signing STS GetCallerIdentity request: %w", err)
+	}
+
+	logger.Debug(ctx, "Signed STS GetCallerIdentity request", map[string]any{
+		"tf_aws.caller_identity_proof.url": proof.URL,
+	})
+
+	return proof, nil
+}
+
+func serverIDHeaderMiddleware(serverID string) middleware.BuildMiddleware {
+	return middleware.BuildMiddlewareFunc("TF_AWS_VaultServerIDHeader", func(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+		req, ok := in.Request.(*smithyhttp.Request)
+		if !ok {
+			return middleware.BuildOutput{}, middleware.Metadata{}, fmt.Errorf("unexpected request type %T", in.Request)
+		}
+		req.Header.Set(VaultAWSIAMServerIDHeader, serverID)
+
+		return next.HandleBuild(ctx, in)
+	})
+}
+
+// captureCallerIdentityProofMiddleware records the signed request and stops it from being sent.
+func captureCallerIdentityProofMiddleware(proof *CallerIdentityProof) middleware.FinalizeMiddleware {
+	return middleware.FinalizeMiddlewareFunc("TF_AWS_CaptureCallerIdentityProof", func(ctx context.Context, in middleware.FinalizeInput, _ middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		req, ok := in.Request.(*smithyhttp.Request)
+		if !ok {
+			return middleware.FinalizeOutput{}, middleware.Metadata{}, fmt.Errorf("unexpected request type %T", in.Request)
+		}
+		if req.Header.Get("Authorization") == "" {
+			return middleware.FinalizeOutput{}, middleware.Metadata{}, errors.New("request was not signed")
+		}
+
+		r := req.Build(ctx)
+		var body []byte
+		if r.Body != nil {
+			var err error
+			body, err = io.ReadAll(r.Body)
+			if err != nil {
+				return middleware.FinalizeOutput{}, middleware.Metadata{}, fmt.Errorf("reading request body: %w", err)
+			}
+		}
+
+		*proof = CallerIdentityProof{
+			Method:  r.Method,
+			URL:     r.URL.String(),
+			Headers: r.Header.Clone(),
+			Body:    string(body),
+		}
+
+		return middleware.FinalizeOutput{Result: &sts.GetCallerIdentityOutput{}}, middleware.Metadata{}, nil
+	})
+}
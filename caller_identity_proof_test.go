@@ -0,0 +1,197 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestGetCallerIdentityProof(t *testing.T) {
+	testCases := map[string]struct {
+		Region      string
+		StsRegion   string
+		ServerID    string
+		ExpectedURL string
+	}{
+		"aws partition": {
+			Region:      "us-west-2",
+			ExpectedURL: "https://sts.us-west-2.amazonaws.com/",
+		},
+		"aws-cn partition": {
+			Region:      "cn-north-1",
+			ExpectedURL: "https://sts.cn-north-1.amazonaws.com.cn/",
+		},
+		"aws-us-gov partition": {
+			Region:      "us-gov-west-1",
+			ExpectedURL: "https://sts.us-gov-west-1.amazonaws.com/",
+		},
+		"STS region": {
+			Region:      "us-west-2",
+			StsRegion:   "cn-northwest-1",
+			ExpectedURL: "https://sts.cn-northwest-1.amazonaws.com.cn/",
+		},
+		"server ID": {
+			Region:      "us-west-2",
+			ServerID:    "vault.example.com",
+			ExpectedURL: "https://sts.us-west-2.amazonaws.com/",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			config := &Config{
+				AccessKey:               servicemocks.MockStaticAccessKey,
+				Region:                  testCase.Region,
+				SecretKey:               servicemocks.MockStaticSecretKey,
+				SkipCredsValidation:     true,
+				SkipRequestingAccountId: true,
+				StsRegion:               testCase.StsRegion,
+			}
+
+			ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+			if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+
+			proof, err := GetCallerIdentityProof(ctx, awsConfig, config, CallerIdentityProofOptions{
+				ServerID: testCase.ServerID,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if a, e := proof.Method, http.MethodPost; a != e {
+				t.Errorf("expected method %q, got %q", e, a)
+			}
+			if a, e := proof.URL, testCase.ExpectedURL; a != e {
+				t.Errorf("expected URL %q, got %q", e, a)
+			}
+			body, err := url.ParseQuery(proof.Body)
+			if err != nil {
+				t.Fatalf("parsing body: %s", err)
+			}
+			if a, e := body.Get("Action"), "GetCallerIdentity"; a != e {
+				t.Errorf("expected Action %q, got %q", e, a)
+			}
+
+			authorization := proof.Headers.Get("Authorization")
+			if !strings.Contains(authorization, fmt.Sprintf("Credential=%s/", servicemocks.MockStaticAccessKey)) {
+				t.Errorf("expected request signed with %q, got Authorization %q", servicemocks.MockStaticAccessKey, authorization)
+			}
+			signedServerID := strings.Contains(authorization, strings.ToLower(VaultAWSIAMServerIDHeader))
+			if testCase.ServerID != "" {
+				if a, e := proof.Headers.Get(VaultAWSIAMServerIDHeader), testCase.ServerID; a != e {
+					t.Errorf("expected server ID %q, got %q", e, a)
+				}
+				if !signedServerID {
+					t.Errorf("expected %s to be signed, got Authorization %q", VaultAWSIAMServerIDHeader, authorization)
+				}
+			} else if signedServerID {
+				t.Errorf("expected no %s, got Authorization %q", VaultAWSIAMServerIDHeader, authorization)
+			}
+		})
+	}
+}
+
+// TestGetCallerIdentityProofStsEndpoint checks that the request is signed for the configured STS endpoint and is not sent,
+// and that it can be replayed from the Vault login data.
+func TestGetCallerIdentityProofStsEndpoint(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get(VaultAWSIAMServerIDHeader) != "vault.example.com" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintln(w, servicemocks.MockStsGetCallerIdentityValidResponseBody)
+	}))
+	defer ts.Close()
+
+	config := &Config{
+		AccessKey:               servicemocks.MockStaticAccessKey,
+		Region:                  "us-east-1",
+		SecretKey:               servicemocks.MockStaticSecretKey,
+		SkipCredsValidation:     true,
+		SkipRequestingAccountId: true,
+		StsEndpoint:             ts.URL,
+	}
+
+	ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	proof, err := GetCallerIdentityProof(ctx, awsConfig, config, CallerIdentityProofOptions{
+		ServerID: "vault.example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a := atomic.LoadInt32(&requests); a != 0 {
+		t.Fatalf("expected no requests to STS, got %d", a)
+	}
+	if !strings.HasPrefix(proof.URL, ts.URL) {
+		t.Errorf("expected URL with prefix %q, got %q", ts.URL, proof.URL)
+	}
+
+	data, err := proof.VaultLoginData()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	decode := func(key string) []byte {
+		t.Helper()
+		s, ok := data[key].(string)
+		if !ok {
+			t.Fatalf("expected string %s, got %T", key, data[key])
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("decoding %s: %s", key, err)
+		}
+		return b
+	}
+	var headers http.Header
+	if err := json.Unmarshal(decode("iam_request_headers"), &headers); err != nil {
+		t.Fatalf("decoding headers: %s", err)
+	}
+
+	method, ok := data["iam_http_request_method"].(string)
+	if !ok {
+		t.Fatalf("expected string iam_http_request_method, got %T", data["iam_http_request_method"])
+	}
+	req, err := http.NewRequest(method, string(decode("iam_request_url")), strings.NewReader(string(decode("iam_request_body"))))
+	if err != nil {
+		t.Fatalf("creating request: %s", err)
+	}
+	req.Header = headers
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("sending request: %s", err)
+	}
+	defer resp.Body.Close()
+	if a, e := resp.StatusCode, http.StatusOK; a != e {
+		t.Errorf("expected status %d, got %d", e, a)
+	}
+}
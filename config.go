@@ -5,6 +5,7 @@ package awsbase
 
 import (
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/config"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
 )
 
 // Config, APNInfo, APNProduct, and AssumeRole are aliased to an internal package to break a dependency cycle
@@ -18,8 +19,65 @@ type AssumeRole = config.AssumeRole
 
 type AssumeRoleWithWebIdentity = config.AssumeRoleWithWebIdentity
 
+type AssumeRoleHook = config.AssumeRoleHook
+
+type AssumeRoleResult = config.AssumeRoleResult
+
+type ContainerCredentials = config.ContainerCredentials
+
+type CredentialSourcePolicy = config.CredentialSourcePolicy
+
+type CredentialsErrorHook = config.CredentialsErrorHook
+
+type CredentialsFileCache = config.CredentialsFileCache
+
+type CredentialsHook = config.CredentialsHook
+
+type CredentialsRefresh = config.CredentialsRefresh
+
+const (
+	CredentialSourceStatic            = config.CredentialSourceStatic
+	CredentialSourceEnvironment       = config.CredentialSourceEnvironment
+	CredentialSourceSharedCredentials = config.CredentialSourceSharedCredentials
+	CredentialSourceProcess           = config.CredentialSourceProcess
+	CredentialSourceSSO               = config.CredentialSourceSSO
+	CredentialSourceWebIdentity       = config.CredentialSourceWebIdentity
+	CredentialSourceAssumeRole        = config.CredentialSourceAssumeRole
+	CredentialSourceSessionToken      = config.CredentialSourceSessionToken
+	CredentialSourceContainer         = config.CredentialSourceContainer
+	CredentialSourceIMDS              = config.CredentialSourceIMDS
+)
+
+func CredentialSource_Values() []string {
+	return config.CredentialSource_Values()
+}
+
+type DebugLogFilter = config.DebugLogFilter
+
+type DebugLogRule = config.DebugLogRule
+
+type GetSessionToken = config.GetSessionToken
+
+type SessionNameData = config.SessionNameData
+
+// DefaultSessionNameTemplate is the session name template used when a role session name is not set.
+const DefaultSessionNameTemplate = config.DefaultSessionNameTemplate
+
+type Timeouts = config.Timeouts
+
+type Phase = config.Phase
+
+const (
+	PhaseCredentialsRetrieval  = config.PhaseCredentialsRetrieval
+	PhaseAssumeRole            = config.PhaseAssumeRole
+	PhaseCredentialsValidation = config.PhaseCredentialsValidation
+	PhaseAccountIDLookup       = config.PhaseAccountIDLookup
+)
+
 type UserAgentProducts = config.UserAgentProducts
 
+type WatchCredentialFiles = config.WatchCredentialFiles
+
 type UserAgentProduct = config.UserAgentProduct
 
 const (
@@ -33,3 +91,15 @@ func EC2MetadataEndpointMode_Values() []string {
 		EC2MetadataEndpointModeIPv6,
 	}
 }
+
+const (
+	HTTPTrafficLogFormatHAR       = logging.TrafficLogFormatHAR
+	HTTPTrafficLogFormatJSONLines = logging.TrafficLogFormatJSONLines
+)
+
+func HTTPTrafficLogFormat_Values() []string {
+	return []string{
+		HTTPTrafficLogFormatHAR,
+		HTTPTrafficLogFormatJSONLines,
+	}
+}
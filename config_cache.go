@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/cache"
+)
+
+// ConfigCache memoizes the results of GetAwsConfig, keyed by the fingerprint of the Config.
+// Concurrent calls for the same Config share a single call of GetAwsConfig, so that, for example,
+// roles are only assumed once. GetAwsConfig is not canceled when the caller that called it stops waiting,
+// so that it completes for the other callers.
+// Cached values are evicted after the TTL or when their credentials expire, whichever is first.
+// Evicting a value, including by Invalidate and Purge, stops its background work as CloseAwsConfig does.
+// The environment and shared configuration files are assumed not to change while values are cached.
+// A ConfigCache is safe for concurrent use.
+type ConfigCache struct {
+	cache *cache.Cache[aws.Config]
+	ttl   time.Duration
+}
+
+// NewConfigCache returns a ConfigCache. A zero ttl caches values until their credentials expire.
+func NewConfigCache(ttl time.Duration) *ConfigCache {
+	return &ConfigCache{
+		cache: cache.NewWithEvict(CloseAwsConfig),
+		ttl:   ttl,
+	}
+}
+
+// GetAwsConfig returns the cached result of GetAwsConfig for c, calling GetAwsConfig if there is none.
+func (cc *ConfigCache) GetAwsConfig(ctx context.Context, c *Config) (context.Context, aws.Config, error) {
+	key, err := c.Fingerprint()
+	if err != nil {
+		return ctx, aws.Config{}, fmt.Errorf("fingerprinting configuration: %w", err)
+	}
+
+	awsConfig, err := cc.cache.Get(ctx, key, func(ctx context.Context) (aws.Config, time.Time, error) {
+		ctx, awsConfig, err := GetAwsConfig(ctx, c)
+		if err != nil {
+			return aws.Config{}, time.Time{}, err
+		}
+		return awsConfig, CacheExpiry(ctx, awsConfig.Credentials, cc.ttl), nil
+	})
+	if err != nil {
+		return configCommonLogging(ctx), aws.Config{}, err
+	}
+
+	return configCommonLogging(ctx), awsConfig.Copy(), nil
+}
+
+// Invalidate removes the cached result for c.
+func (cc *ConfigCache) Invalidate(c *Config) error {
+	key, err := c.Fingerprint()
+	if err != nil {
+		return fmt.Errorf("fingerprinting configuration: %w", err)
+	}
+	cc.cache.Delete(key)
+	return nil
+}
+
+// Purge removes all cached results.
+func (cc *ConfigCache) Purge() {
+	cc.cache.Purge()
+}
+
+// CacheExpiry returns the time at which a value using the credentials should be evicted from a cache with the given TTL.
+// It is the earlier of the TTL and the expiry of the credentials. A zero time never expires.
+func CacheExpiry(ctx context.Context, provider aws.CredentialsProvider, ttl time.Duration) time.Time {
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	if provider == nil {
+		return expires
+	}
+	creds, err := provider.Retrieve(ctx)
+	if err != nil || !creds.CanExpire {
+		return expires
+	}
+	if expires.IsZero() || creds.Expires.Before(expires) {
+		return creds.Expires
+	}
+	return expires
+}
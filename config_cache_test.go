@@ -0,0 +1,279 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/hashicorp/aws-sdk-go-base/v2/mockdata"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestConfigFingerprint(t *testing.T) {
+	httpClient := &http.Client{}
+	onCredentialsResolved := func(string, time.Time) {}
+
+	base := func() *Config {
+		return &Config{
+			AccessKey:             servicemocks.MockStaticAccessKey,
+			HTTPClient:            httpClient,
+			OnCredentialsResolved: onCredentialsResolved,
+			Region:                "us-east-1",
+			SecretKey:             servicemocks.MockStaticSecretKey,
+			AssumeRole: &AssumeRole{
+				RoleARN: servicemocks.MockStsAssumeRoleArn,
+				Tags: map[string]string{
+					"a": "1",
+					"b": "2",
+					"c": "3",
+				},
+			},
+		}
+	}
+
+	testCases := map[string]struct {
+		Config        func() *Config
+		ExpectedEqual bool
+	}{
+		"same": {
+			Config:        base,
+			ExpectedEqual: true,
+		},
+		"secret key": {
+			Config: func() *Config {
+				c := base()
+				c.SecretKey = "other"
+				return c
+			},
+		},
+		"region": {
+			Config: func() *Config {
+				c := base()
+				c.Region = "us-west-2"
+				return c
+			},
+		},
+		"assume role tag": {
+			Config: func() *Config {
+				c := base()
+				c.AssumeRole.Tags["a"] = "0"
+				return c
+			},
+		},
+		"HTTP client": {
+			Config: func() *Config {
+				c := base()
+				c.HTTPClient = &http.Client{}
+				return c
+			},
+		},
+		"credentials hook": {
+			Config: func() *Config {
+				c := base()
+				c.OnCredentialsResolved = func(string, time.Time) {}
+				return c
+			},
+		},
+	}
+
+	expected, err := base().Fingerprint()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if strings.Contains(expected, servicemocks.MockStaticSecretKey) {
+		t.Errorf("fingerprint contains secret key")
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			fingerprint, err := testCase.Config().Fingerprint()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if a, e := fingerprint == expected, testCase.ExpectedEqual; a != e {
+				t.Errorf("expected fingerprints equal to be %t, got %t", e, a)
+			}
+		})
+	}
+}
+
+func TestConfigCache(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", []*servicemocks.MockEndpoint{
+		servicemocks.MockStsAssumeRoleValidEndpoint,
+	})
+
+	config := &Config{
+		AccessKey: servicemocks.MockStaticAccessKey,
+		AssumeRole: &AssumeRole{
+			RoleARN:     servicemocks.MockStsAssumeRoleArn,
+			SessionName: servicemocks.MockStsAssumeRoleSessionName,
+		},
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+		StsEndpoint:         stsEndpoint,
+	}
+
+	cache := NewConfigCache(time.Hour)
+
+	const callers = 5
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := cache.GetAwsConfig(context.Background(), config); err != nil {
+				t.Errorf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Cached results don't call STS
+	closeSts()
+
+	ctx, awsConfig, err := cache.GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in cached GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+	}
+	if a, e := credentialsValue.AccessKeyID, mockdata.MockStsAssumeRoleCredentials.AccessKeyID; a != e {
+		t.Errorf("expected access key %q, got %q", e, a)
+	}
+
+	if err := cache.Invalidate(config); err != nil {
+		t.Fatalf("unexpected Invalidate() error: %s", err)
+	}
+
+	if _, _, err := cache.GetAwsConfig(context.Background(), config); err == nil {
+		t.Fatal("expected error after invalidation, got none")
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	now := time.Now()
+
+	testCases := map[string]struct {
+		Provider aws.CredentialsProvider
+		TTL      time.Duration
+		Expected func(time.Time) bool
+	}{
+		"no TTL, static credentials": {
+			Provider: credentials.NewStaticCredentialsProvider("a", "b", ""),
+			Expected: func(v time.Time) bool { return v.IsZero() },
+		},
+		"TTL, static credentials": {
+			Provider: credentials.NewStaticCredentialsProvider("a", "b", ""),
+			TTL:      time.Hour,
+			Expected: func(v time.Time) bool { return v.After(now.Add(59 * time.Minute)) },
+		},
+		"TTL, credentials expire first": {
+			Provider: expiringCredentialsProvider(now.Add(time.Minute)),
+			TTL:      time.Hour,
+			Expected: func(v time.Time) bool { return v.Equal(now.Add(time.Minute)) },
+		},
+		"TTL first": {
+			Provider: expiringCredentialsProvider(now.Add(2 * time.Hour)),
+			TTL:      time.Hour,
+			Expected: func(v time.Time) bool { return v.Before(now.Add(2 * time.Hour)) },
+		},
+		"no TTL, expiring credentials": {
+			Provider: expiringCredentialsProvider(now.Add(time.Minute)),
+			Expected: func(v time.Time) bool { return v.Equal(now.Add(time.Minute)) },
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			if v := CacheExpiry(context.Background(), testCase.Provider, testCase.TTL); !testCase.Expected(v) {
+				t.Errorf("unexpected expiry %s", v)
+			}
+		})
+	}
+}
+
+func expiringCredentialsProvider(expires time.Time) aws.CredentialsProvider {
+	return aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{
+			AccessKeyID:     "a",
+			SecretAccessKey: "b",
+			CanExpire:       true,
+			Expires:         expires,
+		}, nil
+	})
+}
+
+// TestConfigCachePurge checks that the background work of cached aws.Configs stops when they are purged.
+func TestConfigCachePurge(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"AccessKeyId":     servicemocks.MockEcsCredentialsAccessKey,
+			"Expiration":      time.Now().UTC().Format(time.RFC3339),
+			"SecretAccessKey": servicemocks.MockEcsCredentialsSecretKey,
+			"Token":           servicemocks.MockEcsCredentialsSessionToken,
+		})
+	}))
+	defer ts.Close()
+
+	config := &Config{
+		ContainerCredentials: &ContainerCredentials{
+			FullURI: ts.URL,
+		},
+		CredentialsRefresh: &CredentialsRefresh{
+			BackgroundRefresh: true,
+		},
+		Region:              "us-east-1",
+		SkipCredsValidation: true,
+	}
+
+	cache := NewConfigCache(time.Hour)
+	if _, _, err := cache.GetAwsConfig(context.Background(), config); err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	n := atomic.LoadInt32(&calls)
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&calls) <= n+1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected credentials to be refreshed in background")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cache.Purge()
+	// Allow a refresh in progress to complete
+	time.Sleep(100 * time.Millisecond)
+	n = atomic.LoadInt32(&calls)
+	time.Sleep(backgroundRefreshMinInterval + 100*time.Millisecond)
+	if a := atomic.LoadInt32(&calls); a != n {
+		t.Errorf("expected no retrievals after Purge, got %d", a-n)
+	}
+}
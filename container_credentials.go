@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/endpointcreds"
+)
+
+// containerCredentialsProvider retrieves credentials from the endpoint configured in ContainerCredentials.
+// The authorization token is resolved on each retrieval, so that rotated token files are used.
+type containerCredentialsProvider struct {
+	config   *ContainerCredentials
+	endpoint string
+
+	// httpClient is the HTTP client resolved for the Config, so that its proxy and TLS settings apply.
+	// It is set once the configuration is loaded. If nil, the default HTTP client is used.
+	httpClient endpointcreds.HTTPClient
+}
+
+func newContainerCredentialsProvider(c *Config) (*containerCredentialsProvider, error) {
+	endpoint, err := c.ContainerCredentials.ResolveEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	return &containerCredentialsProvider{
+		config:   c.ContainerCredentials,
+		endpoint: endpoint,
+	}, nil
+}
+
+func (p *containerCredentialsProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	token, err := p.config.ResolveAuthorizationToken()
+	if err != nil {
+		return aws.Credentials{Source: endpointcreds.ProviderName}, err
+	}
+
+	if p.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.Timeout)
+		defer cancel()
+	}
+
+	provider := endpointcreds.New(p.endpoint, func(opts *endpointcreds.Options) {
+		opts.AuthorizationToken = token
+		if p.httpClient != nil {
+			opts.HTTPClient = p.httpClient
+		}
+	})
+
+	return provider.Retrieve(ctx)
+}
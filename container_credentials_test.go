@@ -0,0 +1,286 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials/endpointcreds"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestContainerCredentials(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte(servicemocks.MockEcsCredentialsAuthorizationToken+"\n"), 0600); err != nil {
+		t.Fatalf("writing token file: %s", err)
+	}
+
+	testCases := map[string]struct {
+		ContainerCredentials func(endpoint string) *ContainerCredentials
+		EnvironmentVariables func(endpoint string) map[string]string
+		ExpectedError        bool
+	}{
+		"authorization token": {
+			ContainerCredentials: func(endpoint string) *ContainerCredentials {
+				return &ContainerCredentials{
+					AuthorizationToken: servicemocks.MockEcsCredentialsAuthorizationToken,
+					FullURI:            endpoint,
+				}
+			},
+		},
+
+		"authorization token file": {
+			ContainerCredentials: func(endpoint string) *ContainerCredentials {
+				return &ContainerCredentials{
+					AuthorizationTokenFile: tokenFile,
+					FullURI:                endpoint,
+				}
+			},
+		},
+
+		"environment variables": {
+			ContainerCredentials: func(endpoint string) *ContainerCredentials {
+				return &ContainerCredentials{}
+			},
+			EnvironmentVariables: func(endpoint string) map[string]string {
+				return map[string]string{
+					"AWS_CONTAINER_CREDENTIALS_FULL_URI":     endpoint,
+					"AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE": tokenFile,
+				}
+			},
+		},
+
+		"config overrides environment variables": {
+			ContainerCredentials: func(endpoint string) *ContainerCredentials {
+				return &ContainerCredentials{
+					AuthorizationToken: servicemocks.MockEcsCredentialsAuthorizationToken,
+					FullURI:            endpoint,
+				}
+			},
+			EnvironmentVariables: func(endpoint string) map[string]string {
+				return map[string]string{
+					"AWS_CONTAINER_CREDENTIALS_FULL_URI": "http://invalid.example.com/creds",
+					"AWS_CONTAINER_AUTHORIZATION_TOKEN":  "invalid",
+				}
+			},
+		},
+
+		"invalid authorization token": {
+			ContainerCredentials: func(endpoint string) *ContainerCredentials {
+				return &ContainerCredentials{
+					AuthorizationToken: "invalid",
+					FullURI:            endpoint,
+				}
+			},
+			ExpectedError: true,
+		},
+
+		"host not allowed": {
+			ContainerCredentials: func(endpoint string) *ContainerCredentials {
+				return &ContainerCredentials{
+					AllowedHosts:       []string{"169.254.170.2"},
+					AuthorizationToken: servicemocks.MockEcsCredentialsAuthorizationToken,
+					FullURI:            endpoint,
+				}
+			},
+			ExpectedError: true,
+		},
+
+		"no endpoint": {
+			ContainerCredentials: func(endpoint string) *ContainerCredentials {
+				return &ContainerCredentials{}
+			},
+			ExpectedError: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			endpoint, closeEcs := servicemocks.ContainerCredentialsApiMock(servicemocks.MockEcsCredentialsAuthorizationToken)
+			defer closeEcs()
+
+			if testCase.EnvironmentVariables != nil {
+				for k, v := range testCase.EnvironmentVariables(endpoint) {
+					os.Setenv(k, v)
+				}
+			}
+
+			config := &Config{
+				ContainerCredentials: testCase.ContainerCredentials(endpoint),
+				Region:               "us-east-1",
+				SkipCredsValidation:  true,
+			}
+
+			ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+			if testCase.ExpectedError {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+
+			credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+			if err != nil {
+				t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+			}
+			if a, e := credentialsValue.AccessKeyID, servicemocks.MockEcsCredentialsAccessKey; a != e {
+				t.Errorf("expected access key %q, got %q", e, a)
+			}
+			if a, e := credentialsValue.Source, endpointcreds.ProviderName; a != e {
+				t.Errorf("expected source %q, got %q", e, a)
+			}
+		})
+	}
+}
+
+// TestContainerCredentialsTokenFileRotation checks that the authorization token file is read on each retrieval.
+func TestContainerCredentialsTokenFileRotation(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	endpoint, closeEcs := servicemocks.ContainerCredentialsApiMock(servicemocks.MockEcsCredentialsAuthorizationToken)
+	defer closeEcs()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte(servicemocks.MockEcsCredentialsAuthorizationToken), 0600); err != nil {
+		t.Fatalf("writing token file: %s", err)
+	}
+
+	provider, err := newContainerCredentialsProvider(&Config{
+		ContainerCredentials: &ContainerCredentials{
+			AuthorizationTokenFile: tokenFile,
+			FullURI:                endpoint,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := provider.Retrieve(context.Background()); err != nil {
+		t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+	}
+
+	if err := os.WriteFile(tokenFile, []byte("rotated"), 0600); err != nil {
+		t.Fatalf("writing token file: %s", err)
+	}
+
+	if _, err := provider.Retrieve(context.Background()); err == nil {
+		t.Fatal("expected error with rotated token, got none")
+	}
+}
+
+func TestContainerCredentialsTimeout(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	provider, err := newContainerCredentialsProvider(&Config{
+		ContainerCredentials: &ContainerCredentials{
+			FullURI: ts.URL + "/creds",
+			Timeout: 100 * time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	start := time.Now()
+	if _, err := provider.Retrieve(context.Background()); err == nil {
+		t.Fatal("expected error, got none")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected request to time out, took %s", elapsed)
+	}
+}
+
+// TestContainerCredentialsCustomCABundle checks that requests to the endpoint use the HTTP client of the Config.
+func TestContainerCredentialsCustomCABundle(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"AccessKeyId":     servicemocks.MockEcsCredentialsAccessKey,
+			"Expiration":      time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+			"SecretAccessKey": servicemocks.MockEcsCredentialsSecretKey,
+			"Token":           servicemocks.MockEcsCredentialsSessionToken,
+		})
+	}))
+	defer ts.Close()
+
+	pemFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(pemFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0600); err != nil {
+		t.Fatalf("writing CA bundle: %s", err)
+	}
+
+	testCases := map[string]struct {
+		CustomCABundle string
+		ExpectedError  bool
+	}{
+		"custom CA bundle": {
+			CustomCABundle: pemFile,
+		},
+		"no custom CA bundle": {
+			ExpectedError: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			config := &Config{
+				ContainerCredentials: &ContainerCredentials{
+					FullURI: ts.URL,
+				},
+				CustomCABundle:      testCase.CustomCABundle,
+				Region:              "us-east-1",
+				SkipCredsValidation: true,
+			}
+
+			ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+			if testCase.ExpectedError {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+
+			credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+			if err != nil {
+				t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+			}
+			if a, e := credentialsValue.AccessKeyID, servicemocks.MockEcsCredentialsAccessKey; a != e {
+				t.Errorf("expected access key %q, got %q", e, a)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/filewatch"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/invalidate"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+)
+
+const (
+	defaultWatchCredentialFilesInterval = 5 * time.Second
+	defaultWatchCredentialFilesDebounce = 1 * time.Second
+)
+
+// reloadingCredentialsProvider resolves the credentials provider again after reload is called,
+// so that changes to the shared credentials and configuration files are picked up.
+// The credentials provider is resolved without holding the lock, as resolving it can make network calls.
+type reloadingCredentialsProvider struct {
+	config *Config
+
+	mu         sync.Mutex
+	provider   aws.CredentialsProvider
+	stale      bool
+	generation int
+}
+
+func (p *reloadingCredentialsProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	p.mu.Lock()
+	provider, stale, generation := p.provider, p.stale, p.generation
+	p.mu.Unlock()
+
+	if stale {
+		var err error
+		provider, _, err = getCredentialsProvider(ctx, p.config)
+		if err != nil {
+			return aws.Credentials{}, err
+		}
+
+		p.mu.Lock()
+		// Keep the provider stale if the files changed again while it was being resolved
+		if p.generation == generation {
+			p.provider = provider
+			p.stale = false
+		}
+		p.mu.Unlock()
+	}
+
+	return provider.Retrieve(ctx)
+}
+
+// Invalidate invalidates the current credentials provider.
+func (p *reloadingCredentialsProvider) Invalidate() {
+	p.mu.Lock()
+	provider := p.provider
+	p.mu.Unlock()
+
+	invalidate.Credentials(provider)
+}
+
+func (p *reloadingCredentialsProvider) reload() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stale = true
+	p.generation++
+}
+
+// watchCredentialFiles invalidates the credentials in awsConfig when the files from which they are resolved change.
+// Watching stops when ctx is done, see CloseAwsConfig.
+func watchCredentialFiles(ctx context.Context, awsConfig aws.Config, provider *reloadingCredentialsProvider, c *Config) error {
+	logger := logging.RetrieveLogger(ctx)
+
+	files, err := c.ResolveCredentialFiles()
+	if err != nil {
+		return err
+	}
+
+	interval := c.WatchCredentialFiles.Interval
+	if interval <= 0 {
+		interval = defaultWatchCredentialFilesInterval
+	}
+	debounce := c.WatchCredentialFiles.Debounce
+	if debounce <= 0 {
+		debounce = defaultWatchCredentialFilesDebounce
+	}
+
+	logger.Debug(ctx, "Watching credential files", map[string]any{
+		"tf_aws.watched_files": files,
+	})
+
+	filewatch.Watch(ctx, files, interval, debounce, func(changed []string) {
+		logger.Info(ctx, "Credential files changed, invalidating cached credentials", map[string]any{
+			"tf_aws.changed_files": changed,
+		})
+
+		provider.reload()
+		if cache, ok := awsConfig.Credentials.(*aws.CredentialsCache); ok {
+			cache.Invalidate()
+		}
+	})
+
+	return nil
+}
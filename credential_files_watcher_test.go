@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestWatchCredentialFiles(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	filename := filepath.Join(t.TempDir(), "credentials")
+	writeCredentials := func(accessKey string) {
+		t.Helper()
+
+		content := fmt.Sprintf("[default]\naws_access_key_id = %s\naws_secret_access_key = secret\n", accessKey)
+		if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatalf("writing shared credentials file: %s", err)
+		}
+	}
+	writeCredentials("AKID1")
+
+	config := &Config{
+		Region:                 "us-east-1",
+		SharedCredentialsFiles: []string{filename},
+		SkipCredsValidation:    true,
+		WatchCredentialFiles: &WatchCredentialFiles{
+			Interval: 10 * time.Millisecond,
+			Debounce: 20 * time.Millisecond,
+		},
+	}
+
+	// Watching is not tied to the context passed to GetAwsConfig
+	requestCtx, cancel := context.WithCancel(context.Background())
+	_, awsConfig, err := GetAwsConfig(requestCtx, config)
+	cancel()
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+	defer CloseAwsConfig(awsConfig)
+
+	ctx := context.Background()
+
+	credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+	}
+	if a, e := credentialsValue.AccessKeyID, "AKID1"; a != e {
+		t.Fatalf("expected access key %q, got %q", e, a)
+	}
+
+	// Ensure the modification time changes on file systems with coarse timestamps
+	time.Sleep(20 * time.Millisecond)
+	writeCredentials("AKID2-updated")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		credentialsValue, err = awsConfig.Credentials.Retrieve(ctx)
+		if err != nil {
+			t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+		}
+		if credentialsValue.AccessKeyID == "AKID2-updated" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected access key %q after file change, got %q", "AKID2-updated", credentialsValue.AccessKeyID)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCloseAwsConfig(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	filename := filepath.Join(t.TempDir(), "credentials")
+	writeCredentials := func(accessKey string) {
+		t.Helper()
+
+		content := fmt.Sprintf("[default]\naws_access_key_id = %s\naws_secret_access_key = secret\n", accessKey)
+		if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatalf("writing shared credentials file: %s", err)
+		}
+	}
+	writeCredentials("AKID1")
+
+	config := &Config{
+		Region:                 "us-east-1",
+		SharedCredentialsFiles: []string{filename},
+		SkipCredsValidation:    true,
+		WatchCredentialFiles: &WatchCredentialFiles{
+			Interval: 10 * time.Millisecond,
+			Debounce: 20 * time.Millisecond,
+		},
+	}
+
+	ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+	CloseAwsConfig(awsConfig)
+
+	if _, err := awsConfig.Credentials.Retrieve(ctx); err != nil {
+		t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	writeCredentials("AKID2-updated")
+	time.Sleep(200 * time.Millisecond)
+
+	credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+	}
+	if a, e := credentialsValue.AccessKeyID, "AKID1"; a != e {
+		t.Errorf("expected access key %q after CloseAwsConfig, got %q", e, a)
+	}
+}
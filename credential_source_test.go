@@ -0,0 +1,207 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/aws-sdk-go-base/v2/mockdata"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestCredentialSourcePolicy(t *testing.T) {
+	assumeRole := &AssumeRole{
+		RoleARN:     servicemocks.MockStsAssumeRoleArn,
+		SessionName: servicemocks.MockStsAssumeRoleSessionName,
+	}
+
+	testCases := map[string]struct {
+		Config               *Config
+		EnvironmentVariables map[string]string
+		IMDS                 bool
+		ExpectedSource       string
+	}{
+		"static denied": {
+			Config: &Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				SecretKey: servicemocks.MockStaticSecretKey,
+				CredentialSourcePolicy: &CredentialSourcePolicy{
+					Denied: []string{CredentialSourceStatic},
+				},
+			},
+			ExpectedSource: CredentialSourceStatic,
+		},
+
+		"environment allowed": {
+			Config: &Config{
+				CredentialSourcePolicy: &CredentialSourcePolicy{
+					Allowed: []string{CredentialSourceEnvironment},
+				},
+			},
+			EnvironmentVariables: map[string]string{
+				"AWS_ACCESS_KEY_ID":     servicemocks.MockEnvAccessKey,
+				"AWS_SECRET_ACCESS_KEY": servicemocks.MockEnvSecretKey,
+			},
+		},
+
+		"IMDS denied": {
+			Config: &Config{
+				CredentialSourcePolicy: &CredentialSourcePolicy{
+					Denied: []string{CredentialSourceIMDS},
+				},
+			},
+			IMDS:           true,
+			ExpectedSource: CredentialSourceIMDS,
+		},
+
+		"IMDS not allowed": {
+			Config: &Config{
+				CredentialSourcePolicy: &CredentialSourcePolicy{
+					Allowed: []string{CredentialSourceWebIdentity, CredentialSourceSSO},
+				},
+			},
+			IMDS:           true,
+			ExpectedSource: CredentialSourceIMDS,
+		},
+
+		"assumed role not allowed": {
+			Config: &Config{
+				AccessKey:  servicemocks.MockStaticAccessKey,
+				AssumeRole: assumeRole,
+				SecretKey:  servicemocks.MockStaticSecretKey,
+				CredentialSourcePolicy: &CredentialSourcePolicy{
+					Allowed: []string{CredentialSourceStatic},
+				},
+			},
+			ExpectedSource: CredentialSourceAssumeRole,
+		},
+
+		"assumed role allowed": {
+			Config: &Config{
+				AccessKey:  servicemocks.MockStaticAccessKey,
+				AssumeRole: assumeRole,
+				SecretKey:  servicemocks.MockStaticSecretKey,
+				CredentialSourcePolicy: &CredentialSourcePolicy{
+					Allowed: []string{CredentialSourceStatic, CredentialSourceAssumeRole},
+				},
+			},
+		},
+
+		"session token not allowed": {
+			Config: &Config{
+				AccessKey:       servicemocks.MockStaticAccessKey,
+				GetSessionToken: &GetSessionToken{},
+				SecretKey:       servicemocks.MockStaticSecretKey,
+				CredentialSourcePolicy: &CredentialSourcePolicy{
+					Allowed: []string{CredentialSourceStatic},
+				},
+			},
+			ExpectedSource: CredentialSourceSessionToken,
+		},
+
+		"session token allowed": {
+			Config: &Config{
+				AccessKey:       servicemocks.MockStaticAccessKey,
+				GetSessionToken: &GetSessionToken{},
+				SecretKey:       servicemocks.MockStaticSecretKey,
+				CredentialSourcePolicy: &CredentialSourcePolicy{
+					Allowed: []string{CredentialSourceStatic, CredentialSourceSessionToken},
+				},
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			for k, v := range testCase.EnvironmentVariables {
+				os.Setenv(k, v)
+			}
+
+			if testCase.IMDS {
+				closeEc2Metadata := servicemocks.AwsMetadataApiMock(append(
+					servicemocks.Ec2metadata_securityCredentialsEndpoints,
+					servicemocks.Ec2metadata_instanceIdEndpoint,
+					servicemocks.Ec2metadata_iamInfoEndpoint,
+				))
+				defer closeEc2Metadata()
+			}
+
+			closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", []*servicemocks.MockEndpoint{
+				servicemocks.MockStsAssumeRoleValidEndpoint,
+				servicemocks.MockStsGetSessionTokenValidEndpoint,
+			})
+			defer closeSts()
+
+			testCase.Config.Region = "us-east-1"
+			testCase.Config.SkipCredsValidation = true
+			testCase.Config.StsEndpoint = stsEndpoint
+
+			_, _, err := GetAwsConfig(context.Background(), testCase.Config)
+
+			if testCase.ExpectedSource == "" {
+				if err != nil {
+					t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+				}
+				return
+			}
+
+			var e CredentialSourceNotAllowedError
+			if !errors.As(err, &e) {
+				t.Fatalf("expected CredentialSourceNotAllowedError, got '%[1]T': %[1]v", err)
+			}
+			if a, e := e.Source, testCase.ExpectedSource; a != e {
+				t.Errorf("expected source %q, got %q", e, a)
+			}
+		})
+	}
+}
+
+func TestCredentialSourcePolicyDerive(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", []*servicemocks.MockEndpoint{
+		servicemocks.MockStsAssumeRoleValidEndpoint,
+	})
+	defer closeSts()
+
+	config := &Config{
+		AccessKey: servicemocks.MockStaticAccessKey,
+		CredentialSourcePolicy: &CredentialSourcePolicy{
+			Allowed: []string{CredentialSourceStatic},
+		},
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+		StsEndpoint:         stsEndpoint,
+	}
+
+	ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	_, err = NewDeriver(awsConfig, config).Derive(ctx, DeriveOverrides{
+		AssumeRole: &AssumeRole{
+			RoleARN:     servicemocks.MockStsAssumeRoleArn,
+			SessionName: servicemocks.MockStsAssumeRoleSessionName,
+		},
+	})
+
+	var e CredentialSourceNotAllowedError
+	if !errors.As(err, &e) {
+		t.Fatalf("expected CredentialSourceNotAllowedError, got '%[1]T': %[1]v", err)
+	}
+	if a, e := e.Source, CredentialSourceAssumeRole; a != e {
+		t.Errorf("expected source %q, got %q", e, a)
+	}
+}
@@ -8,18 +8,27 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/endpointcreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/filecache"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/invalidate"
 	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
 )
 
 func getCredentialsProvider(ctx context.Context, c *Config) (aws.CredentialsProvider, string, error) {
 	logger := logging.RetrieveLogger(ctx)
 
+	if err := c.CheckOffline(); err != nil {
+		return nil, "", err
+	}
+
 	loadOptions, err := commonLoadOptions(ctx, c)
 	if err != nil {
 		return nil, "", err
@@ -31,6 +40,18 @@ func getCredentialsProvider(ctx context.Context, c *Config) (aws.CredentialsProv
 		config.WithEndpointResolverWithOptions(credentialsEndpointResolver(ctx, c)),
 	)
 
+	if c.Offline {
+		logger.Debug(ctx, "Offline mode: refusing network access while resolving credentials")
+		loadOptions = append(
+			loadOptions,
+			config.WithHTTPClient(offlineHTTPClient{}),
+			// The container credentials provider does not use the configured HTTP client
+			config.WithEndpointCredentialOptions(func(opts *endpointcreds.Options) {
+				opts.HTTPClient = offlineHTTPClient{}
+			}),
+		)
+	}
+
 	envConfig, err := config.NewEnvConfig()
 	if err != nil {
 		return nil, "", err
@@ -115,6 +136,21 @@ func getCredentialsProvider(ctx context.Context, c *Config) (aws.CredentialsProv
 		)
 	}
 
+	var containerProvider *containerCredentialsProvider
+	if c.ContainerCredentials != nil {
+		containerProvider, err = newContainerCredentialsProvider(c)
+		if err != nil {
+			return nil, "", err
+		}
+		logger.Debug(ctx, "Using container credentials from configuration", map[string]any{
+			"tf_aws.container_credentials.endpoint": containerProvider.endpoint,
+		})
+		loadOptions = append(
+			loadOptions,
+			config.WithCredentialsProvider(containerProvider),
+		)
+	}
+
 	if c.AccessKey != "" || c.SecretKey != "" || c.Token != "" {
 		params := make([]string, 0, 3) //nolint:gomnd
 		if c.AccessKey != "" {
@@ -145,6 +181,20 @@ func getCredentialsProvider(ctx context.Context, c *Config) (aws.CredentialsProv
 	if err != nil {
 		return nil, "", fmt.Errorf("loading configuration: %w", err)
 	}
+	if containerProvider != nil {
+		// The HTTP client, including the custom CA bundle and the offline client, is only resolved by LoadDefaultConfig
+		containerProvider.httpClient = cfg.HTTPClient
+	}
+
+	fileCache, err := credentialsFileCache(c)
+	if err != nil {
+		return nil, "", err
+	}
+	if fileCache != nil {
+		if key, ok := ssoCredentialsFileCacheKey(cfg, envConfig, c); ok {
+			cfg.Credentials = invalidate.NewCredentialsCache(fileCache.Provider(key, filecache.ProviderTypeSSO, ssocreds.ProviderName, cfg.Credentials), c.CredentialsCacheOptions)
+		}
+	}
 
 	// This can probably be configured directly in commonLoadOptions() once
 	// https://github.com/aws/aws-sdk-go-v2/pull/1682 is merged
@@ -152,8 +202,8 @@ func getCredentialsProvider(ctx context.Context, c *Config) (aws.CredentialsProv
 		if c.AssumeRoleWithWebIdentity.RoleARN == "" {
 			return nil, "", errors.New("Assume Role With Web Identity: role ARN not set")
 		}
-		if c.AssumeRoleWithWebIdentity.WebIdentityToken == "" && c.AssumeRoleWithWebIdentity.WebIdentityTokenFile == "" {
-			return nil, "", errors.New("Assume Role With Web Identity: one of WebIdentityToken, WebIdentityTokenFile must be set")
+		if !c.AssumeRoleWithWebIdentity.HasValidTokenSource() {
+			return nil, "", errors.New("Assume Role With Web Identity: one of WebIdentityToken, WebIdentityTokenFile, WebIdentityTokenRetriever must be set")
 		}
 		provider, err := webIdentityCredentialsProvider(ctx, cfg, c)
 		if err != nil {
@@ -162,7 +212,14 @@ func getCredentialsProvider(ctx context.Context, c *Config) (aws.CredentialsProv
 		cfg.Credentials = provider
 	}
 
-	creds, err := cfg.Credentials.Retrieve(ctx)
+	var creds aws.Credentials
+	err = withPhaseTimeout(ctx, c, PhaseCredentialsRetrieval, func(ctx context.Context) (err error) {
+		creds, err = cfg.Credentials.Retrieve(ctx)
+		return err
+	})
+	if IsPhaseTimeoutError(err) || IsOfflineError(err) {
+		return nil, "", err
+	}
 	if err != nil {
 		if c.Profile != "" && os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != "" {
 			err = fmt.Errorf(`A Profile was specified along with the environment variables "AWS_ACCESS_KEY_ID" and "AWS_SECRET_ACCESS_KEY". The Profile is now used instead of the environment variable credentials.
@@ -172,24 +229,68 @@ Error: %w`, err)
 		return nil, "", c.NewNoValidCredentialSourcesError(err)
 	}
 
+	if err := c.CredentialSourcePolicy.Check(creds.Source); err != nil {
+		return nil, "", err
+	}
+
+	resolvedCreds := creds
+	if c.GetSessionToken != nil {
+		provider, sessionCreds, err := getSessionTokenCredentialsProvider(ctx, cfg, c, creds)
+		if err != nil {
+			return nil, "", err
+		}
+		cfg.Credentials = provider
+		resolvedCreds = sessionCreds
+
+		if err := c.CredentialSourcePolicy.Check(resolvedCreds.Source); err != nil {
+			return nil, "", err
+		}
+	}
+
 	if c.AssumeRole == nil {
-		return cfg.Credentials, creds.Source, nil
+		credentialsResolved(c, resolvedCreds)
+		return cfg.Credentials, resolvedCreds.Source, nil
 	}
 
 	logger.Info(ctx, "Retrieved initial credentials", map[string]any{
-		"tf_aws.credentials_source": creds.Source,
+		"tf_aws.credentials_source": resolvedCreds.Source,
 	})
-	provider, err := assumeRoleCredentialsProvider(ctx, cfg, c)
+	provider, finalCreds, err := assumeRoleCredentialsProvider(ctx, cfg, c)
+	if err != nil {
+		return nil, "", err
+	}
 
-	return provider, creds.Source, err
+	if err := c.CredentialSourcePolicy.Check(finalCreds.Source); err != nil {
+		return nil, "", err
+	}
+
+	credentialsResolved(c, finalCreds)
+	return provider, creds.Source, nil
 }
 
 func webIdentityCredentialsProvider(ctx context.Context, awsConfig aws.Config, c *Config) (aws.CredentialsProvider, error) {
+	logger := logging.RetrieveLogger(ctx)
+
 	ar := c.AssumeRoleWithWebIdentity
+
+	if err := ar.Validate(); err != nil {
+		return nil, err
+	}
+	if size := ar.PackedPolicySizeEstimate(); size > 100 { //nolint:gomnd
+		logger.Warn(ctx, "Session policy may exceed the size limit of AssumeRoleWithWebIdentity", map[string]any{
+			"tf_aws.assume_role_with_web_identity.packed_policy_size_estimate": size,
+		})
+	}
+
+	sessionName, err := ar.ResolveSessionName()
+	if err != nil {
+		return nil, fmt.Errorf("Assume Role With Web Identity: session name: %w", err)
+	}
+
 	client := stsClient(ctx, awsConfig, c)
 
-	appCreds := stscreds.NewWebIdentityRoleProvider(client, ar.RoleARN, ar, func(opts *stscreds.WebIdentityRoleOptions) {
-		opts.RoleSessionName = ar.SessionName
+	var appCreds aws.CredentialsProvider = stscreds.NewWebIdentityRoleProvider(client, ar.RoleARN, ar, func(opts *stscreds.WebIdentityRoleOptions) {
+		opts.RoleSessionName = sessionName
 		opts.Duration = ar.Duration
 
 		if ar.Policy != "" {
@@ -201,34 +302,72 @@ func webIdentityCredentialsProvider(ctx context.Context, awsConfig aws.Config, c
 		}
 	})
 
-	_, err := appCreds.Retrieve(ctx)
+	appCreds = &assumeRoleHooksProvider{
+		provider:    appCreds,
+		config:      c,
+		roleARN:     ar.RoleARN,
+		sessionName: sessionName,
+	}
+
+	fileCache, err := credentialsFileCache(c)
+	if err != nil {
+		return nil, err
+	}
+	if fileCache != nil {
+		key := roleCredentialsFileCacheKey(ar.RoleARN, sessionName, "")
+		appCreds = fileCache.Provider(key, "", stscreds.WebIdentityProviderName, appCreds)
+	}
+
+	var creds aws.Credentials
+	err = withPhaseTimeout(ctx, c, PhaseAssumeRole, func(ctx context.Context) (err error) {
+		creds, err = appCreds.Retrieve(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, c.NewCannotAssumeRoleWithWebIdentityError(err)
 	}
-	return aws.NewCredentialsCache(appCreds), nil
+	return invalidate.NewCredentialsCache(newResolvedCredentialsProvider(appCreds, creds), c.CredentialsCacheOptions), nil
 }
 
-func assumeRoleCredentialsProvider(ctx context.Context, awsConfig aws.Config, c *Config) (aws.CredentialsProvider, error) {
+func assumeRoleCredentialsProvider(ctx context.Context, awsConfig aws.Config, c *Config) (aws.CredentialsProvider, aws.Credentials, error) {
 	logger := logging.RetrieveLogger(ctx)
 
 	ar := c.AssumeRole
 
 	if ar.RoleARN == "" {
-		return nil, errors.New("Assume Role: role ARN not set")
+		return nil, aws.Credentials{}, errors.New("Assume Role: role ARN not set")
+	}
+
+	if err := ar.Validate(); err != nil {
+		return nil, aws.Credentials{}, err
+	}
+	if size := ar.PackedPolicySizeEstimate(); size > 100 { //nolint:gomnd
+		logger.Warn(ctx, "Session policy and tags may exceed the size limit of AssumeRole", map[string]any{
+			"tf_aws.assume_role.packed_policy_size_estimate": size,
+		})
+	}
+
+	sessionName, err := ar.ResolveSessionName()
+	if err != nil {
+		return nil, aws.Credentials{}, fmt.Errorf("Assume Role: session name: %w", err)
+	}
+	sourceIdentity, err := ar.ResolveSourceIdentity()
+	if err != nil {
+		return nil, aws.Credentials{}, fmt.Errorf("Assume Role: source identity: %w", err)
 	}
 
 	// When assuming a role, we need to first authenticate the base credentials above, then assume the desired role
 	logger.Info(ctx, "Assuming IAM Role", map[string]any{
 		"tf_aws.assume_role.role_arn":        ar.RoleARN,
-		"tf_aws.assume_role.session_name":    ar.SessionName,
+		"tf_aws.assume_role.session_name":    sessionName,
 		"tf_aws.assume_role.external_id":     ar.ExternalID,
-		"tf_aws.assume_role.source_identity": ar.SourceIdentity,
+		"tf_aws.assume_role.source_identity": sourceIdentity,
 	})
 
 	client := stsClient(ctx, awsConfig, c)
 
-	appCreds := stscreds.NewAssumeRoleProvider(client, ar.RoleARN, func(opts *stscreds.AssumeRoleOptions) {
-		opts.RoleSessionName = ar.SessionName
+	var appCreds aws.CredentialsProvider = stscreds.NewAssumeRoleProvider(client, ar.RoleARN, func(opts *stscreds.AssumeRoleOptions) {
+		opts.RoleSessionName = sessionName
 		opts.Duration = ar.Duration
 
 		if ar.ExternalID != "" {
@@ -260,15 +399,36 @@ func assumeRoleCredentialsProvider(ctx context.Context, awsConfig aws.Config, c
 			opts.TransitiveTagKeys = ar.TransitiveTagKeys
 		}
 
-		if ar.SourceIdentity != "" {
-			opts.SourceIdentity = aws.String(ar.SourceIdentity)
+		if sourceIdentity != "" {
+			opts.SourceIdentity = aws.String(sourceIdentity)
 		}
 	})
-	_, err := appCreds.Retrieve(ctx)
+
+	appCreds = &assumeRoleHooksProvider{
+		provider:    appCreds,
+		config:      c,
+		roleARN:     ar.RoleARN,
+		sessionName: sessionName,
+	}
+
+	fileCache, err := credentialsFileCache(c)
+	if err != nil {
+		return nil, aws.Credentials{}, err
+	}
+	if fileCache != nil {
+		key := roleCredentialsFileCacheKey(ar.RoleARN, sessionName, sourceIdentity)
+		appCreds = fileCache.Provider(key, "", stscreds.ProviderName, appCreds)
+	}
+
+	var creds aws.Credentials
+	err = withPhaseTimeout(ctx, c, PhaseAssumeRole, func(ctx context.Context) (err error) {
+		creds, err = appCreds.Retrieve(ctx)
+		return err
+	})
 	if err != nil {
-		return nil, c.NewCannotAssumeRoleError(err)
+		return nil, aws.Credentials{}, c.NewCannotAssumeRoleError(err)
 	}
-	return aws.NewCredentialsCache(appCreds), nil
+	return invalidate.NewCredentialsCache(newResolvedCredentialsProvider(appCreds, creds), c.CredentialsCacheOptions), creds, nil
 }
 
 func getPolicyDescriptorTypes(policyARNs []string) []types.PolicyDescriptorType {
@@ -282,3 +442,41 @@ func getPolicyDescriptorTypes(policyARNs []string) []types.PolicyDescriptorType
 	}
 	return policyDescriptorTypes
 }
+
+// resolvedCredentialsProvider returns the credentials retrieved when resolving the configuration on its first retrieval,
+// so that they are not retrieved again on first use, and retrieves credentials from the underlying provider thereafter.
+type resolvedCredentialsProvider struct {
+	provider aws.CredentialsProvider
+
+	mu       sync.Mutex
+	resolved *aws.Credentials
+}
+
+func newResolvedCredentialsProvider(provider aws.CredentialsProvider, creds aws.Credentials) *resolvedCredentialsProvider {
+	return &resolvedCredentialsProvider{
+		provider: provider,
+		resolved: &creds,
+	}
+}
+
+func (p *resolvedCredentialsProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	p.mu.Lock()
+	resolved := p.resolved
+	p.resolved = nil
+	p.mu.Unlock()
+
+	if resolved != nil && !resolved.Expired() {
+		return *resolved, nil
+	}
+
+	return p.provider.Retrieve(ctx)
+}
+
+// Invalidate discards the resolved credentials and invalidates the underlying provider.
+func (p *resolvedCredentialsProvider) Invalidate() {
+	p.mu.Lock()
+	p.resolved = nil
+	p.mu.Unlock()
+
+	invalidate.Credentials(p.provider)
+}
@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/filecache"
+)
+
+// credentialsFileCache returns the credentials file cache, or nil if it is not enabled.
+func credentialsFileCache(c *Config) (*filecache.Cache, error) {
+	if c.CredentialsFileCache == nil {
+		return nil, nil
+	}
+
+	dir, err := c.ResolveCredentialsFileCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return filecache.New(dir), nil
+}
+
+// roleCredentialsFileCacheKey returns the cache key for credentials from assuming a role.
+func roleCredentialsFileCacheKey(roleARN, sessionName, sourceIdentity string) string {
+	return filecache.Key(map[string]string{
+		"RoleArn":         roleARN,
+		"RoleSessionName": sessionName,
+		"SourceIdentity":  sourceIdentity,
+	})
+}
+
+// ssoCredentialsFileCacheKey returns the AWS CLI's cache key for SSO credentials
+// if credentials are resolved from SSO settings in the shared configuration.
+func ssoCredentialsFileCacheKey(awsConfig aws.Config, envConfig config.EnvConfig, c *Config) (string, bool) {
+	// Credentials from the configuration, and from the environment unless a profile is configured, take precedence over the profile
+	if c.AccessKey != "" || c.SecretKey != "" || c.Token != "" || c.AssumeRoleWithWebIdentity != nil {
+		return "", false
+	}
+	if c.Profile == "" && (envConfig.Credentials.HasKeys() || envConfig.WebIdentityTokenFilePath != "") {
+		return "", false
+	}
+
+	var sharedConfig *config.SharedConfig
+	for _, source := range awsConfig.ConfigSources {
+		if v, ok := source.(config.SharedConfig); ok {
+			sharedConfig = &v
+			break
+		}
+	}
+	if sharedConfig == nil {
+		return "", false
+	}
+
+	// Settings that take precedence over SSO, or assume a role using the SSO credentials
+	if sharedConfig.Source != nil || sharedConfig.Credentials.HasKeys() || sharedConfig.CredentialSource != "" ||
+		sharedConfig.WebIdentityTokenFile != "" || sharedConfig.RoleARN != "" {
+		return "", false
+	}
+	if sharedConfig.SSOAccountID == "" || sharedConfig.SSORoleName == "" {
+		return "", false
+	}
+
+	args := map[string]string{
+		"accountId": sharedConfig.SSOAccountID,
+		"roleName":  sharedConfig.SSORoleName,
+		"startUrl":  sharedConfig.SSOStartURL,
+	}
+	if sharedConfig.SSOSession != nil {
+		args["sessionName"] = sharedConfig.SSOSessionName
+		args["startUrl"] = sharedConfig.SSOSession.SSOStartURL
+	}
+	if args["startUrl"] == "" {
+		return "", false
+	}
+
+	return filecache.Key(args), true
+}
@@ -0,0 +1,270 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/filecache"
+	"github.com/hashicorp/aws-sdk-go-base/v2/mockdata"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestCredentialsFileCache(t *testing.T) {
+	testCases := map[string]struct {
+		Config                   *Config
+		MockStsEndpoints         []*servicemocks.MockEndpoint
+		ExpectedCredentialsValue aws.Credentials
+	}{
+		"assume role": {
+			Config: &Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				AssumeRole: &AssumeRole{
+					RoleARN:     servicemocks.MockStsAssumeRoleArn,
+					SessionName: servicemocks.MockStsAssumeRoleSessionName,
+				},
+				SecretKey: servicemocks.MockStaticSecretKey,
+			},
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsAssumeRoleValidEndpoint,
+			},
+			ExpectedCredentialsValue: mockdata.MockStsAssumeRoleCredentials,
+		},
+		"assume role with web identity": {
+			Config: &Config{
+				AssumeRoleWithWebIdentity: &AssumeRoleWithWebIdentity{
+					RoleARN:          servicemocks.MockStsAssumeRoleWithWebIdentityArn,
+					SessionName:      servicemocks.MockStsAssumeRoleWithWebIdentitySessionName,
+					WebIdentityToken: servicemocks.MockWebIdentityToken,
+				},
+			},
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsAssumeRoleWithWebIdentityValidEndpoint,
+			},
+			ExpectedCredentialsValue: mockdata.MockStsAssumeRoleWithWebIdentityCredentials,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			ts := servicemocks.MockAwsApiServer("STS", testCase.MockStsEndpoints)
+			defer ts.Close()
+
+			dir := t.TempDir()
+
+			testCase.Config.CredentialsFileCache = &CredentialsFileCache{
+				Dir: dir,
+			}
+			testCase.Config.Region = "us-east-1"
+			testCase.Config.SkipCredsValidation = true
+			testCase.Config.StsEndpoint = ts.URL
+
+			ctx, awsConfig, err := GetAwsConfig(context.Background(), testCase.Config)
+			if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+			if _, err := awsConfig.Credentials.Retrieve(ctx); err != nil {
+				t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+			}
+
+			files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(files) != 1 {
+				t.Fatalf("expected 1 cache file, got %d", len(files))
+			}
+			fi, err := os.Stat(files[0])
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if a, e := fi.Mode().Perm(), os.FileMode(0600); a != e {
+				t.Errorf("expected file mode %s, got %s", e, a)
+			}
+
+			// Cached credentials don't call STS
+			ts.Close()
+
+			ctx, awsConfig, err = GetAwsConfig(context.Background(), testCase.Config)
+			if err != nil {
+				t.Fatalf("error in cached GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+
+			credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+			if err != nil {
+				t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+			}
+
+			if diff := cmp.Diff(credentialsValue, testCase.ExpectedCredentialsValue, cmpopts.IgnoreFields(aws.Credentials{}, "Expires")); diff != "" {
+				t.Fatalf("unexpected credentials: (- got, + expected)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCredentialsFileCacheSSO(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	dir := t.TempDir()
+
+	configFile := filepath.Join(dir, "config")
+	err := os.WriteFile(configFile, []byte(`
+[profile sso]
+sso_start_url = https://example.awsapps.com/start
+sso_region = us-east-1
+sso_account_id = 123456789012
+sso_role_name = Role
+`), 0600)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The SSO token cache is empty, so credentials can only come from the credentials file cache
+	cacheDir := filepath.Join(dir, "cache")
+	cache := filecache.New(cacheDir)
+	key := filecache.Key(map[string]string{
+		"accountId": "123456789012",
+		"roleName":  "Role",
+		"startUrl":  "https://example.awsapps.com/start",
+	})
+	creds := aws.Credentials{
+		AccessKeyID:     "SSOAccessKey",
+		SecretAccessKey: "SSOSecretKey",
+		SessionToken:    "SSOSessionToken",
+		CanExpire:       true,
+		Expires:         time.Now().Add(time.Hour),
+	}
+	if err := cache.Store(key, filecache.ProviderTypeSSO, creds); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	config := &Config{
+		CredentialsFileCache: &CredentialsFileCache{
+			Dir: cacheDir,
+		},
+		Profile:             "sso",
+		Region:              "us-east-1",
+		SharedConfigFiles:   []string{configFile},
+		SkipCredsValidation: true,
+	}
+
+	ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+	}
+
+	if a, e := credentialsValue.AccessKeyID, creds.AccessKeyID; a != e {
+		t.Errorf("expected access key %q, got %q", e, a)
+	}
+	if a, e := credentialsValue.Source, ssocreds.ProviderName; a != e {
+		t.Errorf("expected source %q, got %q", e, a)
+	}
+}
+
+func TestRoleCredentialsFileCacheKey(t *testing.T) {
+	testCases := map[string]struct {
+		SourceIdentity string
+		ExpectedKey    string
+	}{
+		// SHA-1 of {"RoleArn":"arn:aws:iam::555555555555:role/AssumeRole","RoleSessionName":"AssumeRoleSessionName"}
+		"no source identity": {
+			ExpectedKey: "21d434fcc7bc74a1d1b6242766da724e9d145f66",
+		},
+		// SHA-1 of {"RoleArn":"arn:aws:iam::555555555555:role/AssumeRole","RoleSessionName":"AssumeRoleSessionName","SourceIdentity":"Identity"}
+		"source identity": {
+			SourceIdentity: "Identity",
+			ExpectedKey:    "fca95350e47554af8a6349202a30a631d90178c5",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			key := roleCredentialsFileCacheKey(servicemocks.MockStsAssumeRoleArn, servicemocks.MockStsAssumeRoleSessionName, testCase.SourceIdentity)
+			if key != testCase.ExpectedKey {
+				t.Errorf("expected key %q, got %q", testCase.ExpectedKey, key)
+			}
+		})
+	}
+}
+
+// TestCredentialsFileCacheAWSCLIEntry checks that credentials from assuming a role are read from an entry written by the AWS CLI.
+func TestCredentialsFileCacheAWSCLIEntry(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	dir := t.TempDir()
+	key := roleCredentialsFileCacheKey(servicemocks.MockStsAssumeRoleArn, servicemocks.MockStsAssumeRoleSessionName, "")
+	content := `{
+  "Credentials": {
+    "AccessKeyId": "` + mockdata.MockStsAssumeRoleCredentials.AccessKeyID + `",
+    "SecretAccessKey": "` + mockdata.MockStsAssumeRoleCredentials.SecretAccessKey + `",
+    "SessionToken": "` + mockdata.MockStsAssumeRoleCredentials.SessionToken + `",
+    "Expiration": "2099-12-31T23:59:59+00:00"
+  },
+  "AssumedRoleUser": {
+    "AssumedRoleId": "AROA:` + servicemocks.MockStsAssumeRoleSessionName + `",
+    "Arn": "arn:aws:sts::555555555555:assumed-role/AssumeRole/` + servicemocks.MockStsAssumeRoleSessionName + `"
+  },
+  "ResponseMetadata": {
+    "HTTPStatusCode": 200
+  }
+}`
+	if err := os.WriteFile(filepath.Join(dir, key+".json"), []byte(content), 0600); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// STS is not called
+	ts := servicemocks.MockAwsApiServer("STS", []*servicemocks.MockEndpoint{})
+	defer ts.Close()
+
+	config := &Config{
+		AccessKey: servicemocks.MockStaticAccessKey,
+		AssumeRole: &AssumeRole{
+			RoleARN:     servicemocks.MockStsAssumeRoleArn,
+			SessionName: servicemocks.MockStsAssumeRoleSessionName,
+		},
+		CredentialsFileCache: &CredentialsFileCache{
+			Dir: dir,
+		},
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+		StsEndpoint:         ts.URL,
+	}
+
+	ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+	}
+
+	if diff := cmp.Diff(credentialsValue, mockdata.MockStsAssumeRoleCredentials, cmpopts.IgnoreFields(aws.Credentials{}, "Expires")); diff != "" {
+		t.Fatalf("unexpected credentials: (- got, + expected)\n%s", diff)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+)
+
+const (
+	// backgroundRefreshMinInterval limits how often credentials are refreshed in the background,
+	// for example when the expiry window is longer than the lifetime of the credentials.
+	backgroundRefreshMinInterval = 1 * time.Second
+
+	// backgroundRefreshRetryInterval is the time to wait after a failed background refresh.
+	backgroundRefreshRetryInterval = 30 * time.Second
+)
+
+// refreshCredentialsInBackground retrieves credentials from provider each time the previously retrieved credentials expire,
+// so that the cached credentials are refreshed before they are needed by a request.
+// Refreshing stops when ctx is done or the credentials cannot expire.
+func refreshCredentialsInBackground(ctx context.Context, provider aws.CredentialsProvider) {
+	logger := logging.RetrieveLogger(ctx)
+
+	go func() {
+		for {
+			var wait time.Duration
+
+			creds, err := provider.Retrieve(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				logger.Warn(ctx, "Unable to refresh credentials in background", map[string]any{
+					"error": err,
+				})
+				wait = backgroundRefreshRetryInterval
+			} else {
+				if !creds.CanExpire {
+					return
+				}
+				wait = time.Until(creds.Expires)
+			}
+			if wait < backgroundRefreshMinInterval {
+				wait = backgroundRefreshMinInterval
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			logger.Debug(ctx, "Refreshing credentials in background")
+		}
+	}()
+}
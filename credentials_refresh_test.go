@@ -0,0 +1,236 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/hashicorp/aws-sdk-go-base/v2/mockdata"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestCredentialsRefreshExpiryWindow(t *testing.T) {
+	// Matches the Expiration in the mock STS responses
+	mockExpires := time.Date(2099, time.December, 31, 23, 59, 59, 0, time.UTC)
+
+	testCases := map[string]struct {
+		CredentialsRefresh *CredentialsRefresh
+		ExpectedEarliest   time.Time
+		ExpectedLatest     time.Time
+	}{
+		"no refresh settings": {
+			ExpectedEarliest: mockExpires,
+			ExpectedLatest:   mockExpires,
+		},
+		"expiry window": {
+			CredentialsRefresh: &CredentialsRefresh{
+				ExpiryWindow: 15 * time.Minute,
+			},
+			ExpectedEarliest: mockExpires.Add(-15 * time.Minute),
+			ExpectedLatest:   mockExpires.Add(-15 * time.Minute),
+		},
+		"expiry window with jitter": {
+			CredentialsRefresh: &CredentialsRefresh{
+				ExpiryWindow:           15 * time.Minute,
+				ExpiryWindowJitterFrac: 0.5,
+			},
+			ExpectedEarliest: mockExpires.Add(-15 * time.Minute),
+			ExpectedLatest:   mockExpires.Add(-7*time.Minute - 30*time.Second),
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", []*servicemocks.MockEndpoint{
+				servicemocks.MockStsAssumeRoleValidEndpoint,
+			})
+			defer closeSts()
+
+			config := &Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				AssumeRole: &AssumeRole{
+					RoleARN:     servicemocks.MockStsAssumeRoleArn,
+					SessionName: servicemocks.MockStsAssumeRoleSessionName,
+				},
+				CredentialsRefresh:  testCase.CredentialsRefresh,
+				Region:              "us-east-1",
+				SecretKey:           servicemocks.MockStaticSecretKey,
+				SkipCredsValidation: true,
+				StsEndpoint:         stsEndpoint,
+			}
+
+			ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+			if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+
+			credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+			if err != nil {
+				t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+			}
+
+			if credentialsValue.Expires.Before(testCase.ExpectedEarliest) || credentialsValue.Expires.After(testCase.ExpectedLatest) {
+				t.Errorf("expected expiry between %s and %s, got %s", testCase.ExpectedEarliest, testCase.ExpectedLatest, credentialsValue.Expires)
+			}
+		})
+	}
+}
+
+// TestCredentialsRefreshExpiryWindowContainerCredentials checks that the expiry window applies to credentials cached by the
+// AWS SDK's default credentials chain.
+func TestCredentialsRefreshExpiryWindowContainerCredentials(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	endpoint, closeEcs := servicemocks.ContainerCredentialsApiMock(servicemocks.MockEcsCredentialsAuthorizationToken)
+	defer closeEcs()
+
+	config := &Config{
+		ContainerCredentials: &ContainerCredentials{
+			AuthorizationToken: servicemocks.MockEcsCredentialsAuthorizationToken,
+			FullURI:            endpoint,
+		},
+		CredentialsRefresh: &CredentialsRefresh{
+			ExpiryWindow: 15 * time.Minute,
+		},
+		Region:              "us-east-1",
+		SkipCredsValidation: true,
+	}
+
+	ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+	}
+
+	// The mock container credentials expire when they are returned
+	if latest := time.Now().Add(-14 * time.Minute); credentialsValue.Expires.After(latest) {
+		t.Errorf("expected expiry before %s, got %s", latest, credentialsValue.Expires)
+	}
+}
+
+func TestRefreshCredentialsInBackground(t *testing.T) {
+	var calls int32
+	provider := aws.NewCredentialsCache(aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		atomic.AddInt32(&calls, 1)
+		return aws.Credentials{
+			AccessKeyID:     "a",
+			SecretAccessKey: "b",
+			CanExpire:       true,
+			Expires:         time.Now().Add(100 * time.Millisecond),
+		}, nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	refreshCredentialsInBackground(ctx, provider)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&calls) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected credentials to be refreshed in background, got %d retrievals", atomic.LoadInt32(&calls))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	n := atomic.LoadInt32(&calls)
+	time.Sleep(backgroundRefreshMinInterval + 100*time.Millisecond)
+	if a := atomic.LoadInt32(&calls); a != n {
+		t.Errorf("expected no retrievals after cancellation, got %d", a-n)
+	}
+}
+
+// TestCredentialsRefreshBackgroundRefreshLifecycle checks that background refresh is not tied to the context passed to
+// GetAwsConfig and stops when CloseAwsConfig is called.
+func TestCredentialsRefreshBackgroundRefreshLifecycle(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"AccessKeyId":     servicemocks.MockEcsCredentialsAccessKey,
+			"Expiration":      time.Now().UTC().Format(time.RFC3339),
+			"SecretAccessKey": servicemocks.MockEcsCredentialsSecretKey,
+			"Token":           servicemocks.MockEcsCredentialsSessionToken,
+		})
+	}))
+	defer ts.Close()
+
+	config := &Config{
+		ContainerCredentials: &ContainerCredentials{
+			FullURI: ts.URL,
+		},
+		CredentialsRefresh: &CredentialsRefresh{
+			BackgroundRefresh: true,
+		},
+		Region:              "us-east-1",
+		SkipCredsValidation: true,
+	}
+
+	requestCtx, cancel := context.WithCancel(context.Background())
+	_, awsConfig, err := GetAwsConfig(requestCtx, config)
+	cancel()
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	n := atomic.LoadInt32(&calls)
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&calls) <= n+1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected credentials to be refreshed in background after the context was canceled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	CloseAwsConfig(awsConfig)
+	// Allow a refresh in progress to complete
+	time.Sleep(100 * time.Millisecond)
+	n = atomic.LoadInt32(&calls)
+	time.Sleep(backgroundRefreshMinInterval + 100*time.Millisecond)
+	if a := atomic.LoadInt32(&calls); a != n {
+		t.Errorf("expected no retrievals after CloseAwsConfig, got %d", a-n)
+	}
+}
+
+func TestRefreshCredentialsInBackgroundNoExpiry(t *testing.T) {
+	var calls int32
+	provider := aws.NewCredentialsCache(aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		atomic.AddInt32(&calls, 1)
+		return aws.Credentials{
+			AccessKeyID:     "a",
+			SecretAccessKey: "b",
+		}, nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	refreshCredentialsInBackground(ctx, provider)
+
+	time.Sleep(backgroundRefreshMinInterval + 100*time.Millisecond)
+	if a, e := atomic.LoadInt32(&calls), int32(1); a != e {
+		t.Errorf("expected %d retrievals, got %d", e, a)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/cache"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/detach"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+)
+
+// DeriveOverrides are the settings that differ between a derived aws.Config and its base.
+type DeriveOverrides struct {
+	// Region overrides the region of the base aws.Config.
+	Region string
+
+	// AssumeRole is assumed using the credentials of the base aws.Config.
+	AssumeRole *AssumeRole
+}
+
+// Deriver derives aws.Configs that differ in region or in an additional assumed role
+// from a base aws.Config returned by GetAwsConfig.
+// Derived aws.Configs share the HTTP client, logger, and API options of the base aws.Config.
+// Shared configuration files are not re-read and credentials are not re-validated.
+// Derived aws.Configs are cached by the fingerprint of the derived Config, concurrent calls with the same overrides
+// share a single derivation, and a Deriver is safe for concurrent use.
+// When CredentialsRefresh.BackgroundRefresh is set, assumed role credentials are refreshed in the background
+// until the derived aws.Config is removed by Purge.
+type Deriver struct {
+	awsConfig aws.Config
+	config    *Config
+
+	cache *cache.Cache[aws.Config]
+}
+
+// NewDeriver returns a Deriver for the aws.Config returned by GetAwsConfig for c.
+func NewDeriver(awsConfig aws.Config, c *Config) *Deriver {
+	return &Deriver{
+		awsConfig: awsConfig,
+		config:    c,
+		cache: cache.NewWithEvict(func(derived aws.Config) {
+			// Derived aws.Configs without an assumed role share the credentials, and background work, of the base aws.Config
+			if derived.Credentials != awsConfig.Credentials {
+				CloseAwsConfig(derived)
+			}
+		}),
+	}
+}
+
+// Derive returns a copy of the base aws.Config with the overrides applied.
+// When AssumeRole is set, the role is assumed using the base credentials in the overridden region,
+// and the assumed role credentials are checked against the CredentialSourcePolicy of the base Config.
+func (d *Deriver) Derive(ctx context.Context, overrides DeriveOverrides) (aws.Config, error) {
+	ctx, logger := logging.New(ctx, loggerName)
+	ctx = logging.RegisterLogger(ctx, logger)
+
+	c := d.derivedConfig(overrides)
+	key, err := c.Fingerprint()
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("fingerprinting derived configuration: %w", err)
+	}
+
+	awsConfig, err := d.cache.Get(ctx, key, func(ctx context.Context) (aws.Config, time.Time, error) {
+		awsConfig := d.awsConfig.Copy()
+		awsConfig.Region = c.Region
+
+		if overrides.AssumeRole != nil {
+			provider, creds, err := assumeRoleCredentialsProvider(ctx, awsConfig, c)
+			if err != nil {
+				credentialsError(c, err)
+				return aws.Config{}, time.Time{}, err
+			}
+			if err := c.CredentialSourcePolicy.Check(creds.Source); err != nil {
+				credentialsError(c, err)
+				return aws.Config{}, time.Time{}, err
+			}
+			provider = withCredentialsHooks(provider, c)
+			awsConfig.Credentials = provider
+			addExpiredCredentialsInvalidator(&awsConfig)
+
+			if c.CredentialsRefresh != nil && c.CredentialsRefresh.BackgroundRefresh {
+				backgroundCtx, stopBackground := context.WithCancel(detach.Context(ctx))
+				refreshCredentialsInBackground(backgroundCtx, provider)
+				backgroundTasks.Store(provider, stopBackground)
+			}
+		}
+
+		logger.Debug(ctx, "Derived AWS configuration", map[string]any{
+			"tf_aws.derive.region":      awsConfig.Region,
+			"tf_aws.derive.assume_role": overrides.AssumeRole != nil,
+		})
+
+		return awsConfig, time.Time{}, nil
+	})
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	return awsConfig.Copy(), nil
+}
+
+// Purge removes all derived aws.Configs, stopping their background work.
+// Derived aws.Configs already returned remain usable.
+func (d *Deriver) Purge() {
+	d.cache.Purge()
+}
+
+// derivedConfig returns a copy of the base Config with the overrides applied.
+func (d *Deriver) derivedConfig(overrides DeriveOverrides) *Config {
+	c := *d.config
+	c.Region = d.awsConfig.Region
+	if overrides.Region != "" {
+		c.Region = overrides.Region
+	}
+	if overrides.AssumeRole != nil {
+		c.AssumeRole = overrides.AssumeRole
+	}
+	return &c
+}
@@ -0,0 +1,241 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hashicorp/aws-sdk-go-base/v2/mockdata"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestDeriver(t *testing.T) {
+	staticCredentials := aws.Credentials{
+		AccessKeyID:     servicemocks.MockStaticAccessKey,
+		SecretAccessKey: servicemocks.MockStaticSecretKey,
+		Source:          "StaticCredentials",
+	}
+
+	testCases := map[string]struct {
+		Overrides                DeriveOverrides
+		ExpectedRegion           string
+		ExpectedCredentialsValue aws.Credentials
+	}{
+		"no overrides": {
+			ExpectedRegion:           "us-east-1",
+			ExpectedCredentialsValue: staticCredentials,
+		},
+
+		"region": {
+			Overrides: DeriveOverrides{
+				Region: "eu-west-1",
+			},
+			ExpectedRegion:           "eu-west-1",
+			ExpectedCredentialsValue: staticCredentials,
+		},
+
+		"assume role": {
+			Overrides: DeriveOverrides{
+				AssumeRole: &AssumeRole{
+					RoleARN:     servicemocks.MockStsAssumeRoleArn,
+					SessionName: servicemocks.MockStsAssumeRoleSessionName,
+				},
+			},
+			ExpectedRegion:           "us-east-1",
+			ExpectedCredentialsValue: mockdata.MockStsAssumeRoleCredentials,
+		},
+
+		"region and assume role": {
+			Overrides: DeriveOverrides{
+				Region: "eu-west-1",
+				AssumeRole: &AssumeRole{
+					RoleARN:     servicemocks.MockStsAssumeRoleArn,
+					SessionName: servicemocks.MockStsAssumeRoleSessionName,
+				},
+			},
+			ExpectedRegion:           "eu-west-1",
+			ExpectedCredentialsValue: mockdata.MockStsAssumeRoleCredentials,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", []*servicemocks.MockEndpoint{
+				servicemocks.MockStsAssumeRoleValidEndpoint,
+			})
+
+			config := &Config{
+				AccessKey:           servicemocks.MockStaticAccessKey,
+				Region:              "us-east-1",
+				SecretKey:           servicemocks.MockStaticSecretKey,
+				SkipCredsValidation: true,
+				StsEndpoint:         stsEndpoint,
+			}
+
+			ctx, baseConfig, err := GetAwsConfig(context.Background(), config)
+			if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+
+			deriver := NewDeriver(baseConfig, config)
+
+			awsConfig, err := deriver.Derive(ctx, testCase.Overrides)
+			if err != nil {
+				t.Fatalf("error in Derive() '%[1]T': %[1]s", err)
+			}
+
+			if a, e := awsConfig.Region, testCase.ExpectedRegion; a != e {
+				t.Errorf("expected Region %q, got: %q", e, a)
+			}
+
+			if awsConfig.HTTPClient != baseConfig.HTTPClient {
+				t.Error("expected derived HTTP client to be shared with base")
+			}
+
+			credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+			if err != nil {
+				t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+			}
+
+			if diff := cmp.Diff(credentialsValue, testCase.ExpectedCredentialsValue, cmpopts.IgnoreFields(aws.Credentials{}, "Expires")); diff != "" {
+				t.Fatalf("unexpected credentials: (- got, + expected)\n%s", diff)
+			}
+
+			if a, e := baseConfig.Region, "us-east-1"; a != e {
+				t.Errorf("expected base Region %q to be unchanged, got: %q", e, a)
+			}
+
+			// Cached results don't call STS
+			closeSts()
+
+			awsConfig, err = deriver.Derive(ctx, testCase.Overrides)
+			if err != nil {
+				t.Fatalf("error in cached Derive() '%[1]T': %[1]s", err)
+			}
+
+			credentialsValue, err = awsConfig.Credentials.Retrieve(ctx)
+			if err != nil {
+				t.Fatalf("unexpected cached credentials Retrieve() error: %s", err)
+			}
+
+			if diff := cmp.Diff(credentialsValue, testCase.ExpectedCredentialsValue, cmpopts.IgnoreFields(aws.Credentials{}, "Expires")); diff != "" {
+				t.Fatalf("unexpected cached credentials: (- got, + expected)\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestDeriverSingleFlight checks that concurrent calls with the same overrides assume the role once.
+func TestDeriverSingleFlight(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	ts, _, assumeRoleCount := servicemocks.MockStsRevokedCredentialsServer()
+	defer ts.Close()
+
+	config := &Config{
+		AccessKey:           servicemocks.MockStaticAccessKey,
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+		StsEndpoint:         ts.URL,
+	}
+
+	ctx, baseConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	deriver := NewDeriver(baseConfig, config)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := deriver.Derive(ctx, DeriveOverrides{
+				AssumeRole: &AssumeRole{
+					RoleARN:     servicemocks.MockStsAssumeRoleArn,
+					SessionName: servicemocks.MockStsAssumeRoleSessionName,
+				},
+			})
+			if err != nil {
+				t.Errorf("error in Derive() '%[1]T': %[1]s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if a, e := assumeRoleCount(), 1; a != e {
+		t.Errorf("expected %d AssumeRole calls, got %d", e, a)
+	}
+}
+
+// TestDeriverPurge checks that background refresh of derived assumed role credentials stops when the Deriver is purged,
+// and that the background work of the base aws.Config is not stopped.
+func TestDeriverPurge(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	ts, _, _ := servicemocks.MockStsRevokedCredentialsServer()
+	defer ts.Close()
+
+	config := &Config{
+		AccessKey: servicemocks.MockStaticAccessKey,
+		CredentialsRefresh: &CredentialsRefresh{
+			BackgroundRefresh: true,
+		},
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+		StsEndpoint:         ts.URL,
+	}
+
+	ctx, baseConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+	defer CloseAwsConfig(baseConfig)
+
+	deriver := NewDeriver(baseConfig, config)
+
+	regional, err := deriver.Derive(ctx, DeriveOverrides{Region: "us-west-2"})
+	if err != nil {
+		t.Fatalf("error in Derive() '%[1]T': %[1]s", err)
+	}
+	assumed, err := deriver.Derive(ctx, DeriveOverrides{
+		AssumeRole: &AssumeRole{
+			RoleARN:     servicemocks.MockStsAssumeRoleArn,
+			SessionName: servicemocks.MockStsAssumeRoleSessionName,
+		},
+	})
+	if err != nil {
+		t.Fatalf("error in Derive() '%[1]T': %[1]s", err)
+	}
+
+	if _, ok := backgroundTasks.Load(assumed.Credentials); !ok {
+		t.Fatal("expected derived assumed role credentials to be refreshed in background")
+	}
+
+	deriver.Purge()
+
+	if _, ok := backgroundTasks.Load(assumed.Credentials); ok {
+		t.Error("expected background refresh of derived credentials to stop after Purge")
+	}
+	if _, ok := backgroundTasks.Load(regional.Credentials); !ok {
+		t.Error("expected background refresh of base credentials to continue after Purge")
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/endpointcreds"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/awsconfig"
+)
+
+// Sources of the settings in an EffectiveConfig.
+const (
+	SettingSourceConfig       = "config"
+	SettingSourceEnvVar       = "envvar"
+	SettingSourceSharedConfig = "shared config"
+	SettingSourceIMDS         = "imds"
+	SettingSourceDefault      = "default"
+)
+
+// Names of the settings in an EffectiveConfig.
+const (
+	SettingRegion                         = "region"
+	SettingProfile                        = "profile"
+	SettingCredentialSource               = "credential_source"
+	SettingRetryMode                      = "retry_mode"
+	SettingRetryMaxAttempts               = "retry_max_attempts"
+	SettingUseFIPSEndpoint                = "use_fips_endpoint"
+	SettingUseDualStackEndpoint           = "use_dualstack_endpoint"
+	SettingCustomCABundle                 = "custom_ca_bundle"
+	SettingIAMEndpoint                    = "iam_endpoint"
+	SettingSTSEndpoint                    = "sts_endpoint"
+	SettingEC2MetadataServiceEnableState  = "ec2_metadata_service_enable_state"
+	SettingEC2MetadataServiceEndpoint     = "ec2_metadata_service_endpoint"
+	SettingEC2MetadataServiceEndpointMode = "ec2_metadata_service_endpoint_mode"
+)
+
+// EffectiveSetting is a resolved setting and where its value came from.
+type EffectiveSetting struct {
+	Name   string
+	Value  string
+	Source string
+	// Detail qualifies Source, e.g. the name of the environment variable or of the shared config profile.
+	Detail string
+}
+
+func (s EffectiveSetting) String() string {
+	source := s.Source
+	if s.Detail != "" {
+		source = fmt.Sprintf("%s(%q)", s.Source, s.Detail)
+	}
+	return fmt.Sprintf("%s = %q (%s)", s.Name, s.Value, source)
+}
+
+// EffectiveConfig reports the settings resolved by GetAwsConfig.
+type EffectiveConfig struct {
+	Settings []EffectiveSetting
+}
+
+// Get returns the named setting.
+func (e EffectiveConfig) Get(name string) (EffectiveSetting, bool) {
+	for _, s := range e.Settings {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return EffectiveSetting{}, false
+}
+
+// String returns the settings one per line, suitable for debug output.
+func (e EffectiveConfig) String() string {
+	var b strings.Builder
+	for _, s := range e.Settings {
+		b.WriteString(s.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// LogFields returns the settings as structured log fields.
+func (e EffectiveConfig) LogFields() map[string]any {
+	fields := make(map[string]any, len(e.Settings))
+	for _, s := range e.Settings {
+		fields["tf_aws.effective_config."+s.Name] = s.String()
+	}
+	return fields
+}
+
+// GetEffectiveConfig returns the settings in the aws.Config returned by GetAwsConfig, and where each came from.
+// The credentials are retrieved to determine their source.
+func GetEffectiveConfig(ctx context.Context, awsConfig aws.Config, c *Config) (EffectiveConfig, error) {
+	var result EffectiveConfig
+	sources := awsConfig.ConfigSources
+
+	add := func(name, value string, source interface{}, envVar string) {
+		s := EffectiveSetting{
+			Name:  name,
+			Value: value,
+		}
+		s.Source, s.Detail = settingSource(source, envVar)
+		result.Settings = append(result.Settings, s)
+	}
+
+	// Region
+	source, found, _ := awsconfig.ResolveSource(sources, func(sources []interface{}) (bool, error) {
+		switch v := sources[0].(type) {
+		case config.LoadOptions:
+			return v.Region != "", nil
+		case config.EnvConfig:
+			return v.Region != "", nil
+		case config.SharedConfig:
+			return v.Region != "", nil
+		}
+		return false, nil
+	})
+	if !found && awsConfig.Region != "" {
+		// Only set when the initial credentials come from the EC2 Instance Metadata Service
+		source = SettingSourceIMDS
+	}
+	add(SettingRegion, awsConfig.Region, source, firstEnvVar("AWS_REGION", "AWS_DEFAULT_REGION"))
+
+	// Profile
+	// The configured profile is only used to resolve credentials, so it is not in the configuration sources
+	profile := "default"
+	if c.Profile != "" {
+		profile = c.Profile
+		source = config.LoadOptions{}
+	} else {
+		source, _, _ = awsconfig.ResolveSource(sources, func(sources []interface{}) (bool, error) {
+			if v, ok := sources[0].(config.EnvConfig); ok && v.SharedConfigProfile != "" {
+				profile = v.SharedConfigProfile
+				return true, nil
+			}
+			return false, nil
+		})
+	}
+	add(SettingProfile, profile, source, firstEnvVar("AWS_PROFILE", "AWS_DEFAULT_PROFILE"))
+
+	// Credential source
+	creds, err := awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		return EffectiveConfig{}, fmt.Errorf("retrieving credentials: %w", err)
+	}
+	source, envVar := credentialsSettingSource(c, creds.Source, sources)
+	add(SettingCredentialSource, creds.Source, source, envVar)
+
+	// Retry mode and max attempts
+	retryMode := awsConfig.RetryMode
+	if retryMode == "" {
+		retryMode = aws.RetryModeStandard
+	}
+	source, _, err = awsconfig.ResolveSource(sources, func(sources []interface{}) (bool, error) {
+		v, found, err := awsconfig.GetRetryMode(ctx, sources)
+		return found && v != "", err
+	})
+	if err != nil {
+		return EffectiveConfig{}, fmt.Errorf("resolving retry mode: %w", err)
+	}
+	add(SettingRetryMode, string(retryMode), source, "AWS_RETRY_MODE")
+
+	var maxAttempts string
+	if awsConfig.Retryer != nil {
+		maxAttempts = strconv.Itoa(awsConfig.Retryer().MaxAttempts())
+	}
+	source, _, err = awsconfig.ResolveSource(sources, func(sources []interface{}) (bool, error) {
+		v, found, err := awsconfig.GetRetryMaxAttempts(ctx, sources)
+		return found && v != 0, err
+	})
+	if err != nil {
+		return EffectiveConfig{}, fmt.Errorf("resolving retry max attempts: %w", err)
+	}
+	add(SettingRetryMaxAttempts, maxAttempts, source, "AWS_MAX_ATTEMPTS")
+
+	// FIPS and dual-stack endpoints
+	useFIPSEndpoint, _, err := awsconfig.ResolveUseFIPSEndpoint(ctx, sources)
+	if err != nil {
+		return EffectiveConfig{}, fmt.Errorf("resolving FIPS endpoint configuration: %w", err)
+	}
+	source, _, _ = awsconfig.ResolveSource(sources, func(sources []interface{}) (bool, error) {
+		_, found, err := awsconfig.ResolveUseFIPSEndpoint(ctx, sources)
+		return found, err
+	})
+	add(SettingUseFIPSEndpoint, awsconfig.FIPSEndpointStateString(useFIPSEndpoint), source, "AWS_USE_FIPS_ENDPOINT")
+
+	useDualStackEndpoint, _, err := awsconfig.ResolveUseDualStackEndpoint(ctx, sources)
+	if err != nil {
+		return EffectiveConfig{}, fmt.Errorf("resolving dual-stack endpoint configuration: %w", err)
+	}
+	source, _, _ = awsconfig.ResolveSource(sources, func(sources []interface{}) (bool, error) {
+		_, found, err := awsconfig.ResolveUseDualStackEndpoint(ctx, sources)
+		return found, err
+	})
+	add(SettingUseDualStackEndpoint, awsconfig.DualStackEndpointStateString(useDualStackEndpoint), source, "AWS_USE_DUALSTACK_ENDPOINT")
+
+	// Custom CA bundle
+	// The aws.Config only contains the contents of the bundle, so report the configured file name
+	caBundle := c.CustomCABundle
+	source, _, _ = awsconfig.ResolveSource(sources, func(sources []interface{}) (bool, error) {
+		switch v := sources[0].(type) {
+		case config.LoadOptions:
+			return c.CustomCABundle != "", nil
+		case config.EnvConfig:
+			caBundle = v.CustomCABundle
+		case config.SharedConfig:
+			caBundle = v.CustomCABundle
+		}
+		return caBundle != "", nil
+	})
+	add(SettingCustomCABundle, caBundle, source, "AWS_CA_BUNDLE")
+
+	// Service endpoints
+	if c.IamEndpoint != "" {
+		source = config.LoadOptions{}
+	} else {
+		source = nil
+	}
+	add(SettingIAMEndpoint, c.IamEndpoint, source, "")
+
+	if c.StsEndpoint != "" {
+		source = config.LoadOptions{}
+	} else {
+		source = nil
+	}
+	add(SettingSTSEndpoint, c.StsEndpoint, source, "")
+
+	// EC2 Instance Metadata Service
+	imdsEnableState, _, err := awsconfig.ResolveEC2IMDSClientEnableState(sources)
+	if err != nil {
+		return EffectiveConfig{}, fmt.Errorf("resolving EC2 Instance Metadata Service state: %w", err)
+	}
+	source, _, _ = awsconfig.ResolveSource(sources, func(sources []interface{}) (bool, error) {
+		v, found, err := awsconfig.ResolveEC2IMDSClientEnableState(sources)
+		return found && v != imds.ClientDefaultEnableState, err
+	})
+	add(SettingEC2MetadataServiceEnableState, awsconfig.EC2IMDSClientEnableStateString(imdsEnableState), source, "AWS_EC2_METADATA_DISABLED")
+
+	imdsEndpoint, _, err := awsconfig.ResolveEC2IMDSEndpointConfig(sources)
+	if err != nil {
+		return EffectiveConfig{}, fmt.Errorf("resolving EC2 Instance Metadata Service endpoint: %w", err)
+	}
+	source, _, _ = awsconfig.ResolveSource(sources, func(sources []interface{}) (bool, error) {
+		_, found, err := awsconfig.ResolveEC2IMDSEndpointConfig(sources)
+		return found, err
+	})
+	add(SettingEC2MetadataServiceEndpoint, imdsEndpoint, source, "AWS_EC2_METADATA_SERVICE_ENDPOINT")
+
+	imdsEndpointMode, _, err := awsconfig.ResolveEC2IMDSEndpointModeConfig(sources)
+	if err != nil {
+		return EffectiveConfig{}, fmt.Errorf("resolving EC2 Instance Metadata Service endpoint mode: %w", err)
+	}
+	source, _, _ = awsconfig.ResolveSource(sources, func(sources []interface{}) (bool, error) {
+		_, found, err := awsconfig.ResolveEC2IMDSEndpointModeConfig(sources)
+		return found, err
+	})
+	add(SettingEC2MetadataServiceEndpointMode, awsconfig.EC2IMDSEndpointModeString(imdsEndpointMode), source, "AWS_EC2_METADATA_SERVICE_ENDPOINT_MODE")
+
+	return result, nil
+}
+
+// settingSource returns the setting source and its detail for a configuration source
+// returned by awsconfig.ResolveSource.
+func settingSource(source interface{}, envVar string) (string, string) {
+	switch v := source.(type) {
+	case config.LoadOptions:
+		return SettingSourceConfig, ""
+	case config.EnvConfig:
+		return SettingSourceEnvVar, envVar
+	case config.SharedConfig:
+		return SettingSourceSharedConfig, v.Profile
+	case string:
+		return v, ""
+	}
+	return SettingSourceDefault, ""
+}
+
+// credentialsSettingSource returns the configuration source of the credentials with the given provider source.
+func credentialsSettingSource(c *Config, providerSource string, sources []interface{}) (interface{}, string) {
+	if c.AssumeRole != nil || c.AssumeRoleWithWebIdentity != nil || c.GetSessionToken != nil || c.AccessKey != "" {
+		return config.LoadOptions{}, ""
+	}
+
+	switch {
+	case providerSource == config.CredentialsSourceName:
+		return config.EnvConfig{}, "AWS_ACCESS_KEY_ID"
+	case providerSource == ec2rolecreds.ProviderName:
+		return SettingSourceIMDS, ""
+	case providerSource == endpointcreds.ProviderName:
+		if cc := c.ContainerCredentials; cc != nil && (cc.FullURI != "" || cc.RelativeURI != "") {
+			return config.LoadOptions{}, ""
+		}
+		// The relative URI takes precedence
+		return config.EnvConfig{}, firstEnvVar("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	}
+
+	// Remaining providers, such as shared credentials, SSO, credential processes and
+	// roles assumed from a profile, are configured in the shared configuration files
+	for _, source := range sources {
+		if v, ok := source.(config.SharedConfig); ok {
+			return v, ""
+		}
+	}
+	return nil, ""
+}
+
+// firstEnvVar returns the first of the named environment variables that is set, or the first name if none are set.
+func firstEnvVar(names ...string) string {
+	for _, name := range names {
+		if os.Getenv(name) != "" {
+			return name
+		}
+	}
+	return names[0]
+}
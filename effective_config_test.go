@@ -0,0 +1,332 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/aws-sdk-go-base/v2/mockdata"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestGetEffectiveConfig(t *testing.T) {
+	testCases := map[string]struct {
+		Config                  *Config
+		EnvironmentVariables    map[string]string
+		IMDSRegion              string
+		SharedConfigurationFile string
+		ExpectedSettings        []EffectiveSetting
+	}{
+		"config": {
+			Config: &Config{
+				AccessKey:                      servicemocks.MockStaticAccessKey,
+				EC2MetadataServiceEndpoint:     "http://198.51.100.1:1234/",
+				EC2MetadataServiceEndpointMode: "IPv4",
+				IamEndpoint:                    "https://iam.example.com/",
+				MaxRetries:                     5,
+				Region:                         "us-east-1",
+				SecretKey:                      servicemocks.MockStaticSecretKey,
+				StsEndpoint:                    "https://sts.example.com/",
+			},
+			ExpectedSettings: []EffectiveSetting{
+				{Name: SettingRegion, Value: "us-east-1", Source: SettingSourceConfig},
+				{Name: SettingProfile, Value: "default", Source: SettingSourceDefault},
+				{Name: SettingCredentialSource, Value: "StaticCredentials", Source: SettingSourceConfig},
+				{Name: SettingRetryMode, Value: "standard", Source: SettingSourceDefault},
+				{Name: SettingRetryMaxAttempts, Value: "5", Source: SettingSourceConfig},
+				{Name: SettingUseFIPSEndpoint, Value: "FIPSEndpointStateUnset", Source: SettingSourceDefault},
+				{Name: SettingUseDualStackEndpoint, Value: "DualStackEndpointStateUnset", Source: SettingSourceDefault},
+				{Name: SettingCustomCABundle, Value: "", Source: SettingSourceDefault},
+				{Name: SettingIAMEndpoint, Value: "https://iam.example.com/", Source: SettingSourceConfig},
+				{Name: SettingSTSEndpoint, Value: "https://sts.example.com/", Source: SettingSourceConfig},
+				{Name: SettingEC2MetadataServiceEnableState, Value: "ClientDefaultEnableState", Source: SettingSourceDefault},
+				{Name: SettingEC2MetadataServiceEndpoint, Value: "http://198.51.100.1:1234/", Source: SettingSourceConfig},
+				{Name: SettingEC2MetadataServiceEndpointMode, Value: "EndpointModeStateIPv4", Source: SettingSourceConfig},
+			},
+		},
+
+		"environment variables": {
+			Config: &Config{},
+			EnvironmentVariables: map[string]string{
+				"AWS_ACCESS_KEY_ID":                      servicemocks.MockEnvAccessKey,
+				"AWS_DEFAULT_REGION":                     "us-west-2",
+				"AWS_EC2_METADATA_DISABLED":              "true",
+				"AWS_EC2_METADATA_SERVICE_ENDPOINT":      "http://[fd00:ec2::254]/",
+				"AWS_EC2_METADATA_SERVICE_ENDPOINT_MODE": "IPv6",
+				"AWS_MAX_ATTEMPTS":                       "4",
+				"AWS_RETRY_MODE":                         "standard",
+				"AWS_SECRET_ACCESS_KEY":                  servicemocks.MockEnvSecretKey,
+				"AWS_USE_DUALSTACK_ENDPOINT":             "true",
+				"AWS_USE_FIPS_ENDPOINT":                  "true",
+			},
+			ExpectedSettings: []EffectiveSetting{
+				{Name: SettingRegion, Value: "us-west-2", Source: SettingSourceEnvVar, Detail: "AWS_DEFAULT_REGION"},
+				{Name: SettingProfile, Value: "default", Source: SettingSourceDefault},
+				{Name: SettingCredentialSource, Value: "EnvConfigCredentials", Source: SettingSourceEnvVar, Detail: "AWS_ACCESS_KEY_ID"},
+				{Name: SettingRetryMode, Value: "standard", Source: SettingSourceEnvVar, Detail: "AWS_RETRY_MODE"},
+				{Name: SettingRetryMaxAttempts, Value: "4", Source: SettingSourceEnvVar, Detail: "AWS_MAX_ATTEMPTS"},
+				{Name: SettingUseFIPSEndpoint, Value: "FIPSEndpointStateEnabled", Source: SettingSourceEnvVar, Detail: "AWS_USE_FIPS_ENDPOINT"},
+				{Name: SettingUseDualStackEndpoint, Value: "DualStackEndpointStateEnabled", Source: SettingSourceEnvVar, Detail: "AWS_USE_DUALSTACK_ENDPOINT"},
+				{Name: SettingCustomCABundle, Value: "", Source: SettingSourceDefault},
+				{Name: SettingIAMEndpoint, Value: "", Source: SettingSourceDefault},
+				{Name: SettingSTSEndpoint, Value: "", Source: SettingSourceDefault},
+				{Name: SettingEC2MetadataServiceEnableState, Value: "ClientDisabled", Source: SettingSourceEnvVar, Detail: "AWS_EC2_METADATA_DISABLED"},
+				{Name: SettingEC2MetadataServiceEndpoint, Value: "http://[fd00:ec2::254]/", Source: SettingSourceEnvVar, Detail: "AWS_EC2_METADATA_SERVICE_ENDPOINT"},
+				{Name: SettingEC2MetadataServiceEndpointMode, Value: "EndpointModeStateIPv6", Source: SettingSourceEnvVar, Detail: "AWS_EC2_METADATA_SERVICE_ENDPOINT_MODE"},
+			},
+		},
+
+		"shared configuration file": {
+			Config: &Config{},
+			EnvironmentVariables: map[string]string{
+				"AWS_PROFILE": "SharedConfigurationProfile",
+			},
+			SharedConfigurationFile: `
+[profile SharedConfigurationProfile]
+aws_access_key_id = SharedConfigurationAccessKey
+aws_secret_access_key = SharedConfigurationSecretKey
+max_attempts = 3
+region = eu-west-1
+retry_mode = adaptive
+use_fips_endpoint = true
+`,
+			ExpectedSettings: []EffectiveSetting{
+				{Name: SettingRegion, Value: "eu-west-1", Source: SettingSourceSharedConfig, Detail: "SharedConfigurationProfile"},
+				{Name: SettingProfile, Value: "SharedConfigurationProfile", Source: SettingSourceEnvVar, Detail: "AWS_PROFILE"},
+				{Name: SettingRetryMode, Value: "adaptive", Source: SettingSourceSharedConfig, Detail: "SharedConfigurationProfile"},
+				{Name: SettingRetryMaxAttempts, Value: "3", Source: SettingSourceSharedConfig, Detail: "SharedConfigurationProfile"},
+				{Name: SettingUseFIPSEndpoint, Value: "FIPSEndpointStateEnabled", Source: SettingSourceSharedConfig, Detail: "SharedConfigurationProfile"},
+			},
+		},
+
+		"IMDS": {
+			Config:     &Config{},
+			IMDSRegion: "us-east-1",
+			ExpectedSettings: []EffectiveSetting{
+				{Name: SettingRegion, Value: "us-east-1", Source: SettingSourceIMDS},
+				{Name: SettingCredentialSource, Value: "EC2RoleProvider", Source: SettingSourceIMDS},
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			for k, v := range testCase.EnvironmentVariables {
+				os.Setenv(k, v)
+			}
+
+			if testCase.IMDSRegion != "" {
+				closeEc2Metadata := servicemocks.AwsMetadataApiMock(append(
+					servicemocks.Ec2metadata_securityCredentialsEndpoints,
+					servicemocks.Ec2metadata_instanceIdEndpoint,
+					servicemocks.Ec2metadata_iamInfoEndpoint,
+					servicemocks.Ec2metadata_instanceIdentityEndpoint(testCase.IMDSRegion),
+				))
+				defer closeEc2Metadata()
+			}
+
+			if testCase.SharedConfigurationFile != "" {
+				file, err := os.CreateTemp("", "aws-sdk-go-base-shared-configuration-file")
+
+				if err != nil {
+					t.Fatalf("unexpected error creating temporary shared configuration file: %s", err)
+				}
+
+				defer os.Remove(file.Name())
+
+				err = os.WriteFile(file.Name(), []byte(testCase.SharedConfigurationFile), 0600)
+
+				if err != nil {
+					t.Fatalf("unexpected error writing shared configuration file: %s", err)
+				}
+
+				testCase.Config.SharedConfigFiles = []string{file.Name()}
+			}
+
+			testCase.Config.SkipCredsValidation = true
+
+			ctx, awsConfig, err := GetAwsConfig(context.Background(), testCase.Config)
+			if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+
+			effectiveConfig, err := GetEffectiveConfig(ctx, awsConfig, testCase.Config)
+			if err != nil {
+				t.Fatalf("error in GetEffectiveConfig() '%[1]T': %[1]s", err)
+			}
+
+			for _, expected := range testCase.ExpectedSettings {
+				actual, ok := effectiveConfig.Get(expected.Name)
+				if !ok {
+					t.Errorf("expected setting %q, not found", expected.Name)
+					continue
+				}
+				if diff := cmp.Diff(expected, actual); diff != "" {
+					t.Errorf("unexpected setting %q difference: %s", expected.Name, diff)
+				}
+			}
+		})
+	}
+}
+
+// TestGetEffectiveConfigGetSessionToken checks that credentials from GetSessionToken are reported as configured in Config,
+// even when the long-term credentials come from the environment.
+func TestGetEffectiveConfigGetSessionToken(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	os.Setenv("AWS_ACCESS_KEY_ID", servicemocks.MockEnvAccessKey)
+	os.Setenv("AWS_SECRET_ACCESS_KEY", servicemocks.MockEnvSecretKey)
+
+	closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", []*servicemocks.MockEndpoint{
+		servicemocks.MockStsGetSessionTokenValidEndpoint,
+	})
+	defer closeSts()
+
+	config := &Config{
+		GetSessionToken:     &GetSessionToken{},
+		Region:              "us-east-1",
+		SkipCredsValidation: true,
+		StsEndpoint:         stsEndpoint,
+	}
+
+	ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	effectiveConfig, err := GetEffectiveConfig(ctx, awsConfig, config)
+	if err != nil {
+		t.Fatalf("error in GetEffectiveConfig() '%[1]T': %[1]s", err)
+	}
+
+	actual, ok := effectiveConfig.Get(SettingCredentialSource)
+	if !ok {
+		t.Fatalf("expected setting %q, not found", SettingCredentialSource)
+	}
+	expected := EffectiveSetting{Name: SettingCredentialSource, Value: getSessionTokenProviderName, Source: SettingSourceConfig}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected setting %q difference: %s", expected.Name, diff)
+	}
+}
+
+func TestGetEffectiveConfigContainerCredentials(t *testing.T) {
+	testCases := map[string]struct {
+		ContainerCredentials func(endpoint string) *ContainerCredentials
+		EnvironmentVariables func(endpoint string) map[string]string
+		ExpectedSetting      EffectiveSetting
+	}{
+		"config": {
+			ContainerCredentials: func(endpoint string) *ContainerCredentials {
+				return &ContainerCredentials{
+					AuthorizationToken: servicemocks.MockEcsCredentialsAuthorizationToken,
+					FullURI:            endpoint,
+				}
+			},
+			EnvironmentVariables: func(endpoint string) map[string]string {
+				return map[string]string{
+					"AWS_CONTAINER_CREDENTIALS_FULL_URI": "http://invalid.example.com/creds",
+				}
+			},
+			ExpectedSetting: EffectiveSetting{Name: SettingCredentialSource, Value: "CredentialsEndpointProvider", Source: SettingSourceConfig},
+		},
+
+		"config environment variables": {
+			ContainerCredentials: func(endpoint string) *ContainerCredentials {
+				return &ContainerCredentials{
+					AuthorizationToken: servicemocks.MockEcsCredentialsAuthorizationToken,
+				}
+			},
+			EnvironmentVariables: func(endpoint string) map[string]string {
+				return map[string]string{
+					"AWS_CONTAINER_CREDENTIALS_FULL_URI": endpoint,
+				}
+			},
+			ExpectedSetting: EffectiveSetting{Name: SettingCredentialSource, Value: "CredentialsEndpointProvider", Source: SettingSourceEnvVar, Detail: "AWS_CONTAINER_CREDENTIALS_FULL_URI"},
+		},
+
+		"environment variables": {
+			EnvironmentVariables: func(endpoint string) map[string]string {
+				return map[string]string{
+					"AWS_CONTAINER_CREDENTIALS_FULL_URI": endpoint,
+					"AWS_CONTAINER_AUTHORIZATION_TOKEN":  servicemocks.MockEcsCredentialsAuthorizationToken,
+				}
+			},
+			ExpectedSetting: EffectiveSetting{Name: SettingCredentialSource, Value: "CredentialsEndpointProvider", Source: SettingSourceEnvVar, Detail: "AWS_CONTAINER_CREDENTIALS_FULL_URI"},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			endpoint, closeEcs := servicemocks.ContainerCredentialsApiMock(servicemocks.MockEcsCredentialsAuthorizationToken)
+			defer closeEcs()
+
+			for k, v := range testCase.EnvironmentVariables(endpoint) {
+				os.Setenv(k, v)
+			}
+
+			config := &Config{
+				Region:              "us-east-1",
+				SkipCredsValidation: true,
+			}
+			if testCase.ContainerCredentials != nil {
+				config.ContainerCredentials = testCase.ContainerCredentials(endpoint)
+			}
+
+			ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+			if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+
+			effectiveConfig, err := GetEffectiveConfig(ctx, awsConfig, config)
+			if err != nil {
+				t.Fatalf("error in GetEffectiveConfig() '%[1]T': %[1]s", err)
+			}
+
+			actual, ok := effectiveConfig.Get(SettingCredentialSource)
+			if !ok {
+				t.Fatalf("expected setting %q, not found", SettingCredentialSource)
+			}
+			if diff := cmp.Diff(testCase.ExpectedSetting, actual); diff != "" {
+				t.Errorf("unexpected setting %q difference: %s", SettingCredentialSource, diff)
+			}
+		})
+	}
+}
+
+func TestEffectiveConfigString(t *testing.T) {
+	effectiveConfig := EffectiveConfig{
+		Settings: []EffectiveSetting{
+			{Name: SettingRegion, Value: "us-east-1", Source: SettingSourceEnvVar, Detail: "AWS_REGION"},
+			{Name: SettingProfile, Value: "default", Source: SettingSourceDefault},
+		},
+	}
+
+	expected := strings.Join([]string{
+		`region = "us-east-1" (envvar("AWS_REGION"))`,
+		`profile = "default" (default)`,
+		"",
+	}, "\n")
+	if a, e := effectiveConfig.String(), expected; a != e {
+		t.Errorf("expected %q, got %q", e, a)
+	}
+
+	if a, e := effectiveConfig.LogFields()["tf_aws.effective_config.region"], `region = "us-east-1" (envvar("AWS_REGION"))`; a != e {
+		t.Errorf("expected log field %q, got %q", e, a)
+	}
+}
@@ -9,6 +9,15 @@ import (
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/config"
 )
 
+// AuthorizationFailureError wraps an error containing an encoded authorization failure message with the decoded message.
+type AuthorizationFailureError = config.AuthorizationFailureError
+
+// IsAuthorizationFailureError returns true if the error contains the AuthorizationFailureError type.
+func IsAuthorizationFailureError(err error) bool {
+	var e AuthorizationFailureError
+	return errors.As(err, &e)
+}
+
 // CannotAssumeRoleError occurs when AssumeRole cannot complete.
 type CannotAssumeRoleError = config.CannotAssumeRoleError
 
@@ -18,6 +27,24 @@ func IsCannotAssumeRoleError(err error) bool {
 	return errors.As(err, &e)
 }
 
+// CannotGetSessionTokenError occurs when GetSessionToken cannot complete.
+type CannotGetSessionTokenError = config.CannotGetSessionTokenError
+
+// IsCannotGetSessionTokenError returns true if the error contains the CannotGetSessionTokenError type.
+func IsCannotGetSessionTokenError(err error) bool {
+	var e CannotGetSessionTokenError
+	return errors.As(err, &e)
+}
+
+// CredentialSourceNotAllowedError occurs when credentials are obtained from a source not allowed by the CredentialSourcePolicy.
+type CredentialSourceNotAllowedError = config.CredentialSourceNotAllowedError
+
+// IsCredentialSourceNotAllowedError returns true if the error contains the CredentialSourceNotAllowedError type.
+func IsCredentialSourceNotAllowedError(err error) bool {
+	var e CredentialSourceNotAllowedError
+	return errors.As(err, &e)
+}
+
 // NoValidCredentialSourcesError occurs when all credential lookup methods have been exhausted without results.
 type NoValidCredentialSourcesError = config.NoValidCredentialSourcesError
 
@@ -26,3 +53,31 @@ func IsNoValidCredentialSourcesError(err error) bool {
 	var e NoValidCredentialSourcesError
 	return errors.As(err, &e)
 }
+
+// OfflineError occurs when resolving the configuration in offline mode would require a network call.
+type OfflineError = config.OfflineError
+
+// IsOfflineError returns true if the error contains the OfflineError type.
+func IsOfflineError(err error) bool {
+	var e OfflineError
+	return errors.As(err, &e)
+}
+
+// PhaseTimeoutError occurs when a phase of configuration exceeds its limit in Config.Timeouts.
+type PhaseTimeoutError = config.PhaseTimeoutError
+
+// IsPhaseTimeoutError returns true if the error contains the PhaseTimeoutError type.
+func IsPhaseTimeoutError(err error) bool {
+	var e PhaseTimeoutError
+	return errors.As(err, &e)
+}
+
+// ValidationError occurs when a configuration field is invalid.
+// Config.Validate returns all ValidationErrors found, aggregated in a multierror.
+type ValidationError = config.ValidationError
+
+// IsValidationError returns true if the error contains the ValidationError type.
+func IsValidationError(err error) bool {
+	var e ValidationError
+	return errors.As(err, &e)
+}
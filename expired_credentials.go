@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/invalidate"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+	"github.com/hashicorp/aws-sdk-go-base/v2/tfawserr"
+)
+
+// addExpiredCredentialsInvalidator adds an expiredCredentialsInvalidator for the credentials of awsConfig to its API options
+// if they are cached. It replaces any invalidator already added, such as that for the base credentials of a derived aws.Config.
+func addExpiredCredentialsInvalidator(awsConfig *aws.Config) {
+	if _, ok := awsConfig.Credentials.(invalidate.Invalidator); !ok {
+		return
+	}
+
+	invalidator := &expiredCredentialsInvalidator{
+		provider: awsConfig.Credentials,
+	}
+	// Do not append to a backing array shared with the aws.Config that this one was copied from
+	apiOptions := awsConfig.APIOptions[:len(awsConfig.APIOptions):len(awsConfig.APIOptions)]
+	awsConfig.APIOptions = append(apiOptions, func(stack *middleware.Stack) error {
+		if _, ok := stack.Finalize.Get(invalidator.ID()); ok {
+			_, err := stack.Finalize.Swap(invalidator.ID(), invalidator)
+			return err
+		}
+		return stack.Finalize.Add(invalidator, middleware.After)
+	})
+}
+
+// expiredCredentialsInvalidator invalidates cached credentials when a request fails because they have expired,
+// for example because a session token was revoked before its reported expiry.
+// Every layer of caching is invalidated, e.g. the credentials file cache and the cache of the credentials provider
+// reloaded when credential files change.
+// The failed request is retried if credentials retrieved again differ from those that expired.
+type expiredCredentialsInvalidator struct {
+	provider aws.CredentialsProvider
+}
+
+// ID is the middleware identifier.
+func (i *expiredCredentialsInvalidator) ID() string {
+	return "TF_AWS_ExpiredCredentialsInvalidator"
+}
+
+func (i *expiredCredentialsInvalidator) HandleFinalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler,
+) (
+	out middleware.FinalizeOutput, metadata middleware.Metadata, err error,
+) {
+	// The cached credentials are those used to sign the request.
+	expired, _ := i.provider.Retrieve(ctx)
+
+	out, metadata, err = next.HandleFinalize(ctx, in)
+
+	if err != nil && tfawserr.IsExpiredCredentials(err) {
+		logging.RetrieveLogger(ctx).Info(ctx, "Credentials expired, invalidating cached credentials", map[string]any{
+			"error": err,
+		})
+		invalidate.Credentials(i.provider)
+
+		if creds, retrieveErr := i.provider.Retrieve(ctx); retrieveErr == nil && !sameCredentials(creds, expired) {
+			err = &expiredCredentialsError{err: err}
+		}
+	}
+
+	return out, metadata, err
+}
+
+func sameCredentials(a, b aws.Credentials) bool {
+	return a.AccessKeyID == b.AccessKeyID && a.SessionToken == b.SessionToken
+}
+
+// expiredCredentialsError marks an expired credentials error as retryable once the cached credentials have been invalidated.
+type expiredCredentialsError struct {
+	err error
+}
+
+func (e *expiredCredentialsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *expiredCredentialsError) Unwrap() error {
+	return e.err
+}
+
+func (e *expiredCredentialsError) RetryableError() bool {
+	return true
+}
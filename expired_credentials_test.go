@@ -0,0 +1,172 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+	"github.com/hashicorp/aws-sdk-go-base/v2/tfawserr"
+)
+
+func TestExpiredCredentialsInvalidation(t *testing.T) {
+	testCases := map[string]struct {
+		Config func(t *testing.T) *Config
+	}{
+		"assume role": {
+			Config: func(t *testing.T) *Config {
+				return &Config{}
+			},
+		},
+
+		"credentials file cache": {
+			Config: func(t *testing.T) *Config {
+				return &Config{
+					CredentialsFileCache: &CredentialsFileCache{
+						Dir: t.TempDir(),
+					},
+				}
+			},
+		},
+
+		"watch credential files": {
+			Config: func(t *testing.T) *Config {
+				return &Config{
+					WatchCredentialFiles: &WatchCredentialFiles{},
+				}
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			ts, revoke, assumeRoleCount := servicemocks.MockStsRevokedCredentialsServer()
+			defer ts.Close()
+
+			config := testCase.Config(t)
+			config.AccessKey = servicemocks.MockStaticAccessKey
+			config.AssumeRole = &AssumeRole{
+				RoleARN:     servicemocks.MockStsAssumeRoleArn,
+				SessionName: servicemocks.MockStsAssumeRoleSessionName,
+			}
+			config.Region = "us-east-1"
+			config.SecretKey = servicemocks.MockStaticSecretKey
+			config.SkipCredsValidation = true
+			config.StsEndpoint = ts.URL
+
+			ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+			if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+			defer CloseAwsConfig(awsConfig)
+
+			testExpiredCredentialsInvalidation(ctx, t, awsConfig, config, revoke, assumeRoleCount)
+		})
+	}
+}
+
+// TestExpiredCredentialsInvalidationDerive checks that a derived aws.Config invalidates its own credentials
+// rather than the base credentials.
+func TestExpiredCredentialsInvalidationDerive(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	ts, revoke, assumeRoleCount := servicemocks.MockStsRevokedCredentialsServer()
+	defer ts.Close()
+
+	config := &Config{
+		AccessKey:           servicemocks.MockStaticAccessKey,
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+		StsEndpoint:         ts.URL,
+	}
+
+	ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	derived, err := NewDeriver(awsConfig, config).Derive(ctx, DeriveOverrides{
+		AssumeRole: &AssumeRole{
+			RoleARN:     servicemocks.MockStsAssumeRoleArn,
+			SessionName: servicemocks.MockStsAssumeRoleSessionName,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected Derive() error: %s", err)
+	}
+
+	testExpiredCredentialsInvalidation(ctx, t, derived, config, revoke, assumeRoleCount)
+}
+
+func testExpiredCredentialsInvalidation(ctx context.Context, t *testing.T, awsConfig aws.Config, config *Config, revoke func(), assumeRoleCount func() int) {
+	t.Helper()
+
+	revoked, err := awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+	}
+	revoke()
+	initialCount := assumeRoleCount()
+
+	if _, err := stsClient(ctx, awsConfig, config).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatalf("unexpected GetCallerIdentity() error: %s", err)
+	}
+
+	if a, e := assumeRoleCount(), initialCount+1; a != e {
+		t.Errorf("expected %d AssumeRole calls, got %d", e, a)
+	}
+
+	credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+	}
+	if credentialsValue.SessionToken == revoked.SessionToken {
+		t.Errorf("expected credentials to be refreshed, got revoked session token %q", revoked.SessionToken)
+	}
+}
+
+func TestExpiredCredentialsNotRefreshable(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	ts, revoke, _ := servicemocks.MockStsRevokedCredentialsServer()
+	defer ts.Close()
+
+	config := &Config{
+		AccessKey:           servicemocks.MockStaticAccessKey,
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+		StsEndpoint:         ts.URL,
+		Token:               servicemocks.MockStsAssumeRoleSessionToken + "0",
+	}
+	revoke()
+
+	ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	_, err = stsClient(ctx, awsConfig, config).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if !tfawserr.IsExpiredCredentials(err) {
+		t.Fatalf("expected expired credentials error, got %v", err)
+	}
+
+	var maxAttemptsErr *retry.MaxAttemptsError
+	if errors.As(err, &maxAttemptsErr) {
+		t.Errorf("expected static credentials not to be retried, got %s", err)
+	}
+}
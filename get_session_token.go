@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/invalidate"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+)
+
+// getSessionTokenProviderName is the Source of credentials from sts:GetSessionToken.
+const getSessionTokenProviderName = "GetSessionTokenProvider"
+
+// getSessionTokenCredentialsProvider exchanges the long-term credentials in awsConfig for temporary credentials.
+func getSessionTokenCredentialsProvider(ctx context.Context, awsConfig aws.Config, c *Config, baseCreds aws.Credentials) (aws.CredentialsProvider, aws.Credentials, error) {
+	logger := logging.RetrieveLogger(ctx)
+	st := c.GetSessionToken
+
+	if baseCreds.SessionToken != "" {
+		return nil, aws.Credentials{}, c.NewCannotGetSessionTokenError(
+			fmt.Errorf("long-term IAM user credentials are required, got temporary credentials from %s", baseCreds.Source),
+		)
+	}
+
+	logger.Info(ctx, "Getting session token", map[string]any{
+		"tf_aws.get_session_token.serial_number": st.SerialNumber,
+	})
+
+	provider := &getSessionTokenProvider{
+		client:  stsClient(ctx, awsConfig, c),
+		options: st,
+	}
+
+	var creds aws.Credentials
+	err := withPhaseTimeout(ctx, c, PhaseCredentialsRetrieval, func(ctx context.Context) (err error) {
+		creds, err = provider.getSessionToken(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, aws.Credentials{}, c.NewCannotGetSessionTokenError(err)
+	}
+
+	// MFA token codes cannot be reused, so the first retrieval must return the credentials obtained when resolving the configuration
+	return invalidate.NewCredentialsCache(newResolvedCredentialsProvider(provider, creds), c.CredentialsCacheOptions), creds, nil
+}
+
+// getSessionTokenProvider retrieves temporary credentials using sts:GetSessionToken.
+type getSessionTokenProvider struct {
+	client  *sts.Client
+	options *GetSessionToken
+}
+
+func (p *getSessionTokenProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	return p.getSessionToken(ctx)
+}
+
+func (p *getSessionTokenProvider) getSessionToken(ctx context.Context) (aws.Credentials, error) {
+	input := &sts.GetSessionTokenInput{}
+	if p.options.Duration != 0 {
+		input.DurationSeconds = aws.Int32(int32(p.options.Duration / time.Second))
+	}
+	if p.options.SerialNumber != "" {
+		code := p.options.TokenCode
+		if p.options.TokenProvider != nil {
+			var err error
+			code, err = p.options.TokenProvider()
+			if err != nil {
+				return aws.Credentials{Source: getSessionTokenProviderName}, fmt.Errorf("getting MFA token code: %w", err)
+			}
+		}
+		input.SerialNumber = aws.String(p.options.SerialNumber)
+		input.TokenCode = aws.String(code)
+	}
+
+	output, err := p.client.GetSessionToken(ctx, input)
+	if err != nil {
+		return aws.Credentials{Source: getSessionTokenProviderName}, err
+	}
+	if output.Credentials == nil {
+		return aws.Credentials{Source: getSessionTokenProviderName}, errors.New("GetSessionToken response contains no credentials")
+	}
+
+	return aws.Credentials{
+		AccessKeyID:     aws.ToString(output.Credentials.AccessKeyId),
+		SecretAccessKey: aws.ToString(output.Credentials.SecretAccessKey),
+		SessionToken:    aws.ToString(output.Credentials.SessionToken),
+		Source:          getSessionTokenProviderName,
+		CanExpire:       true,
+		Expires:         aws.ToTime(output.Credentials.Expiration),
+	}, nil
+}
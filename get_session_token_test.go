@@ -0,0 +1,231 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/hashicorp/aws-sdk-go-base/v2/mockdata"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestGetSessionToken(t *testing.T) {
+	testCases := map[string]struct {
+		GetSessionToken      *GetSessionToken
+		EnvironmentVariables map[string]string
+		MockStsEndpoints     []*servicemocks.MockEndpoint
+		ExpectedAccessKeyID  string
+		ExpectedSource       string
+		ExpectedError        func(err error) bool
+	}{
+		"no MFA": {
+			GetSessionToken: &GetSessionToken{},
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetSessionTokenValidEndpoint,
+			},
+			ExpectedAccessKeyID: servicemocks.MockStsGetSessionTokenAccessKey,
+			ExpectedSource:      getSessionTokenProviderName,
+		},
+
+		"MFA token code": {
+			GetSessionToken: &GetSessionToken{
+				SerialNumber: servicemocks.MockStsGetSessionTokenSerialNumber,
+				TokenCode:    servicemocks.MockStsGetSessionTokenTokenCode,
+			},
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetSessionTokenWithMFAValidEndpoint,
+			},
+			ExpectedAccessKeyID: servicemocks.MockStsGetSessionTokenAccessKey,
+			ExpectedSource:      getSessionTokenProviderName,
+		},
+
+		"MFA token provider": {
+			GetSessionToken: &GetSessionToken{
+				SerialNumber: servicemocks.MockStsGetSessionTokenSerialNumber,
+				TokenProvider: func() (string, error) {
+					return servicemocks.MockStsGetSessionTokenTokenCode, nil
+				},
+			},
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetSessionTokenWithMFAValidEndpoint,
+			},
+			ExpectedAccessKeyID: servicemocks.MockStsGetSessionTokenAccessKey,
+			ExpectedSource:      getSessionTokenProviderName,
+		},
+
+		"MFA token provider error": {
+			GetSessionToken: &GetSessionToken{
+				SerialNumber: servicemocks.MockStsGetSessionTokenSerialNumber,
+				TokenProvider: func() (string, error) {
+					return "", errors.New("no token")
+				},
+			},
+			ExpectedError: IsCannotGetSessionTokenError,
+		},
+
+		"invalid MFA token code": {
+			GetSessionToken: &GetSessionToken{
+				SerialNumber: servicemocks.MockStsGetSessionTokenSerialNumber,
+				TokenCode:    servicemocks.MockStsGetSessionTokenTokenCode,
+			},
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetSessionTokenWithMFAInvalidEndpointAccessDenied,
+			},
+			ExpectedError: IsCannotGetSessionTokenError,
+		},
+
+		"temporary credentials": {
+			GetSessionToken: &GetSessionToken{},
+			EnvironmentVariables: map[string]string{
+				"AWS_ACCESS_KEY_ID":     servicemocks.MockEnvAccessKey,
+				"AWS_SECRET_ACCESS_KEY": servicemocks.MockEnvSecretKey,
+				"AWS_SESSION_TOKEN":     servicemocks.MockEnvSessionToken,
+			},
+			ExpectedError: IsCannotGetSessionTokenError,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			for k, v := range testCase.EnvironmentVariables {
+				os.Setenv(k, v)
+			}
+
+			closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", testCase.MockStsEndpoints)
+			defer closeSts()
+
+			config := &Config{
+				GetSessionToken:     testCase.GetSessionToken,
+				Region:              "us-east-1",
+				SkipCredsValidation: true,
+				StsEndpoint:         stsEndpoint,
+			}
+			if testCase.EnvironmentVariables == nil {
+				config.AccessKey = servicemocks.MockStaticAccessKey
+				config.SecretKey = servicemocks.MockStaticSecretKey
+			}
+			var resolvedSource string
+			config.OnCredentialsResolved = func(source string, _ time.Time) {
+				resolvedSource = source
+			}
+
+			ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+			if testCase.ExpectedError != nil {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				if !testCase.ExpectedError(err) {
+					t.Fatalf("unexpected GetAwsConfig() error '%[1]T': %[1]s", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+
+			credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+			if err != nil {
+				t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+			}
+			if a, e := credentialsValue.AccessKeyID, testCase.ExpectedAccessKeyID; a != e {
+				t.Errorf("expected access key %q, got %q", e, a)
+			}
+			if a, e := credentialsValue.Source, testCase.ExpectedSource; a != e {
+				t.Errorf("expected source %q, got %q", e, a)
+			}
+			if a, e := resolvedSource, testCase.ExpectedSource; a != e {
+				t.Errorf("expected resolved source %q, got %q", e, a)
+			}
+		})
+	}
+}
+
+// TestGetSessionTokenAssumeRole checks that the role is assumed using the temporary credentials from GetSessionToken
+// and that the MFA token code is used only once.
+func TestGetSessionTokenAssumeRole(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	var getSessionTokenCount int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var accessKey string
+		switch r.Form.Get("Action") {
+		case "GetSessionToken":
+			atomic.AddInt32(&getSessionTokenCount, 1)
+			accessKey = servicemocks.MockStaticAccessKey
+		case "AssumeRole":
+			accessKey = servicemocks.MockStsGetSessionTokenAccessKey
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !strings.Contains(r.Header.Get("Authorization"), fmt.Sprintf("Credential=%s/", accessKey)) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/xml")
+		if r.Form.Get("Action") == "GetSessionToken" {
+			fmt.Fprintln(w, servicemocks.MockStsGetSessionTokenValidResponseBody)
+		} else {
+			fmt.Fprintln(w, servicemocks.MockStsAssumeRoleValidResponseBody)
+		}
+	}))
+	defer ts.Close()
+
+	config := &Config{
+		AccessKey: servicemocks.MockStaticAccessKey,
+		AssumeRole: &AssumeRole{
+			RoleARN:     servicemocks.MockStsAssumeRoleArn,
+			SessionName: servicemocks.MockStsAssumeRoleSessionName,
+		},
+		GetSessionToken: &GetSessionToken{
+			SerialNumber: servicemocks.MockStsGetSessionTokenSerialNumber,
+			TokenCode:    servicemocks.MockStsGetSessionTokenTokenCode,
+		},
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+		StsEndpoint:         ts.URL,
+	}
+
+	ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+	}
+	if a, e := credentialsValue.AccessKeyID, servicemocks.MockStsAssumeRoleAccessKey; a != e {
+		t.Errorf("expected access key %q, got %q", e, a)
+	}
+	if a, e := credentialsValue.Source, stscreds.ProviderName; a != e {
+		t.Errorf("expected source %q, got %q", e, a)
+	}
+	if a, e := atomic.LoadInt32(&getSessionTokenCount), int32(1); a != e {
+		t.Errorf("expected %d GetSessionToken calls, got %d", e, a)
+	}
+}
@@ -13,6 +13,7 @@ require (
 	github.com/hashicorp/terraform-plugin-log v0.8.0
 	github.com/mitchellh/go-homedir v1.1.0
 	go.opentelemetry.io/otel v1.13.0
+	go.opentelemetry.io/otel/metric v0.36.0
 	golang.org/x/exp v0.0.0-20230131160201-f062dba9d201
 )
 
@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/invalidate"
+)
+
+func credentialsExpiry(creds aws.Credentials) time.Time {
+	if !creds.CanExpire {
+		return time.Time{}
+	}
+	return creds.Expires
+}
+
+func credentialsResolved(c *Config, creds aws.Credentials) {
+	if c.OnCredentialsResolved != nil {
+		c.OnCredentialsResolved(creds.Source, credentialsExpiry(creds))
+	}
+}
+
+func credentialsRefreshed(c *Config, creds aws.Credentials) {
+	if c.OnCredentialsRefreshed != nil {
+		c.OnCredentialsRefreshed(creds.Source, credentialsExpiry(creds))
+	}
+}
+
+func credentialsError(c *Config, err error) {
+	if c.OnCredentialsError != nil {
+		c.OnCredentialsError(err)
+	}
+}
+
+// assumeRoleHooksProvider calls the OnAssumeRole hook for each call to an assume role credentials provider.
+type assumeRoleHooksProvider struct {
+	provider    aws.CredentialsProvider
+	config      *Config
+	roleARN     string
+	sessionName string
+}
+
+func (p *assumeRoleHooksProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	creds, err := p.provider.Retrieve(ctx)
+
+	if p.config.OnAssumeRole != nil {
+		p.config.OnAssumeRole(p.roleARN, p.sessionName, AssumeRoleResult{
+			Source:      creds.Source,
+			AccessKeyID: creds.AccessKeyID,
+			Expires:     credentialsExpiry(creds),
+			Err:         err,
+		})
+	}
+
+	return creds, err
+}
+
+// withCredentialsHooks returns provider, the final credentials provider of an aws.Config, wrapped so that
+// the OnCredentialsRefreshed and OnCredentialsError hooks are called for each retrieval from it that is not cached.
+// provider must cache credentials, as every retrieval after the first, which returns the resolved credentials, is a refresh.
+func withCredentialsHooks(provider aws.CredentialsProvider, c *Config) aws.CredentialsProvider {
+	if c.OnCredentialsRefreshed == nil && c.OnCredentialsError == nil {
+		return provider
+	}
+
+	// The cache has no expiry window, as provider already applies the configured one
+	return invalidate.NewCredentialsCache(&credentialsHooksProvider{
+		provider: provider,
+		config:   c,
+	})
+}
+
+// credentialsHooksProvider calls the OnCredentialsRefreshed and OnCredentialsError hooks for each call to a credentials provider.
+type credentialsHooksProvider struct {
+	provider aws.CredentialsProvider
+	config   *Config
+
+	// retrieved is set after the first successful retrieval, which returns the resolved credentials.
+	retrieved int32
+}
+
+func (p *credentialsHooksProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	creds, err := p.provider.Retrieve(ctx)
+	if err != nil {
+		credentialsError(p.config, err)
+		return creds, err
+	}
+
+	if !atomic.CompareAndSwapInt32(&p.retrieved, 0, 1) {
+		credentialsRefreshed(p.config, creds)
+	}
+
+	return creds, nil
+}
+
+// Invalidate invalidates the underlying provider.
+func (p *credentialsHooksProvider) Invalidate() {
+	invalidate.Credentials(p.provider)
+}
@@ -0,0 +1,350 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/endpointcreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/aws-sdk-go-base/v2/mockdata"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+type hookCalls struct {
+	mu sync.Mutex
+
+	assumeRole []AssumeRoleResult
+	errors     []error
+	refreshed  []string
+	resolved   []string
+}
+
+func (h *hookCalls) configure(c *Config) {
+	c.OnAssumeRole = func(roleARN, sessionName string, result AssumeRoleResult) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.assumeRole = append(h.assumeRole, result)
+	}
+	c.OnCredentialsError = func(err error) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.errors = append(h.errors, err)
+	}
+	c.OnCredentialsRefreshed = func(source string, _ time.Time) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.refreshed = append(h.refreshed, source)
+	}
+	c.OnCredentialsResolved = func(source string, _ time.Time) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.resolved = append(h.resolved, source)
+	}
+}
+
+func TestCredentialsHooks(t *testing.T) {
+	testCases := map[string]struct {
+		Config                   *Config
+		MockStsEndpoints         []*servicemocks.MockEndpoint
+		ExpectedError            bool
+		ExpectedAssumeRoleCalls  int
+		ExpectedAssumeRoleErrors int
+		ExpectedErrors           int
+		ExpectedResolved         []string
+	}{
+		"static credentials": {
+			Config: &Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				SecretKey: servicemocks.MockStaticSecretKey,
+			},
+			ExpectedResolved: []string{credentials.StaticCredentialsName},
+		},
+
+		"assume role": {
+			Config: &Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				AssumeRole: &AssumeRole{
+					RoleARN:     servicemocks.MockStsAssumeRoleArn,
+					SessionName: servicemocks.MockStsAssumeRoleSessionName,
+				},
+				SecretKey: servicemocks.MockStaticSecretKey,
+			},
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsAssumeRoleValidEndpoint,
+			},
+			ExpectedAssumeRoleCalls: 1,
+			ExpectedResolved:        []string{stscreds.ProviderName},
+		},
+
+		"assume role error": {
+			Config: &Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				AssumeRole: &AssumeRole{
+					RoleARN:     servicemocks.MockStsAssumeRoleArn,
+					SessionName: servicemocks.MockStsAssumeRoleSessionName,
+				},
+				SecretKey: servicemocks.MockStaticSecretKey,
+			},
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsAssumeRoleInvalidEndpointInvalidClientTokenId,
+			},
+			ExpectedError:            true,
+			ExpectedAssumeRoleCalls:  1,
+			ExpectedAssumeRoleErrors: 1,
+			ExpectedErrors:           1,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", testCase.MockStsEndpoints)
+			defer closeSts()
+
+			var calls hookCalls
+			testCase.Config.Region = "us-east-1"
+			testCase.Config.SkipCredsValidation = true
+			testCase.Config.StsEndpoint = stsEndpoint
+			calls.configure(testCase.Config)
+
+			_, _, err := GetAwsConfig(context.Background(), testCase.Config)
+			if testCase.ExpectedError {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+			} else if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+
+			if a, e := len(calls.assumeRole), testCase.ExpectedAssumeRoleCalls; a != e {
+				t.Errorf("expected %d OnAssumeRole calls, got %d", e, a)
+			}
+			var assumeRoleErrors int
+			for _, result := range calls.assumeRole {
+				if result.Err != nil {
+					assumeRoleErrors++
+				} else if a, e := result.AccessKeyID, servicemocks.MockStsAssumeRoleAccessKey; a != e {
+					t.Errorf("expected OnAssumeRole access key %q, got %q", e, a)
+				}
+			}
+			if a, e := assumeRoleErrors, testCase.ExpectedAssumeRoleErrors; a != e {
+				t.Errorf("expected %d OnAssumeRole errors, got %d", e, a)
+			}
+			if a, e := len(calls.errors), testCase.ExpectedErrors; a != e {
+				t.Errorf("expected %d OnCredentialsError calls, got %d", e, a)
+			}
+			if len(calls.refreshed) != 0 {
+				t.Errorf("expected no OnCredentialsRefreshed calls, got %v", calls.refreshed)
+			}
+			if diff := cmp.Diff(testCase.ExpectedResolved, calls.resolved); diff != "" {
+				t.Errorf("unexpected OnCredentialsResolved calls: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCredentialsHooksRefresh(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", []*servicemocks.MockEndpoint{
+		servicemocks.MockStsAssumeRoleValidEndpoint,
+	})
+	defer closeSts()
+
+	var calls hookCalls
+	config := &Config{
+		AccessKey: servicemocks.MockStaticAccessKey,
+		AssumeRole: &AssumeRole{
+			RoleARN:     servicemocks.MockStsAssumeRoleArn,
+			SessionName: servicemocks.MockStsAssumeRoleSessionName,
+		},
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+		StsEndpoint:         stsEndpoint,
+	}
+	calls.configure(config)
+
+	ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	cache, ok := awsConfig.Credentials.(*aws.CredentialsCache)
+	if !ok {
+		t.Fatalf("expected credentials cache, got %T", awsConfig.Credentials)
+	}
+	cache.Invalidate()
+	if _, err := awsConfig.Credentials.Retrieve(ctx); err != nil {
+		t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+	}
+
+	if diff := cmp.Diff([]string{stscreds.ProviderName}, calls.refreshed); diff != "" {
+		t.Errorf("unexpected OnCredentialsRefreshed calls: %s", diff)
+	}
+	if a, e := len(calls.resolved), 1; a != e {
+		t.Errorf("expected %d OnCredentialsResolved calls, got %d", e, a)
+	}
+}
+
+func TestCredentialsHooksContainerCredentials(t *testing.T) {
+	testCases := map[string]struct {
+		BackgroundRefresh bool
+		FailRefresh       bool
+	}{
+		"refresh": {},
+		"background refresh": {
+			BackgroundRefresh: true,
+		},
+		"background refresh error": {
+			BackgroundRefresh: true,
+			FailRefresh:       true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			// Credentials expire immediately, so that every retrieval is a refresh
+			var requests int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) > 1 && testCase.FailRefresh {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"AccessKeyId":     servicemocks.MockEcsCredentialsAccessKey,
+					"Expiration":      time.Now().UTC().Format(time.RFC3339),
+					"SecretAccessKey": servicemocks.MockEcsCredentialsSecretKey,
+					"Token":           servicemocks.MockEcsCredentialsSessionToken,
+				})
+			}))
+			defer ts.Close()
+
+			var calls hookCalls
+			config := &Config{
+				ContainerCredentials: &ContainerCredentials{
+					FullURI: ts.URL,
+				},
+				Region:              "us-east-1",
+				SkipCredsValidation: true,
+			}
+			if testCase.BackgroundRefresh {
+				config.CredentialsRefresh = &CredentialsRefresh{
+					BackgroundRefresh: true,
+				}
+			}
+			calls.configure(config)
+
+			ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+			if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+			defer CloseAwsConfig(awsConfig)
+
+			if !testCase.BackgroundRefresh {
+				if _, err := awsConfig.Credentials.Retrieve(ctx); err != nil {
+					t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+				}
+			}
+
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				calls.mu.Lock()
+				n := len(calls.refreshed) + len(calls.errors)
+				calls.mu.Unlock()
+				if n > 0 {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("expected OnCredentialsRefreshed or OnCredentialsError call")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			calls.mu.Lock()
+			defer calls.mu.Unlock()
+
+			if testCase.FailRefresh {
+				if len(calls.errors) == 0 {
+					t.Error("expected OnCredentialsError calls, got none")
+				}
+				if len(calls.refreshed) != 0 {
+					t.Errorf("expected no OnCredentialsRefreshed calls, got %v", calls.refreshed)
+				}
+			} else {
+				if len(calls.errors) != 0 {
+					t.Errorf("expected no OnCredentialsError calls, got %v", calls.errors)
+				}
+				for _, source := range calls.refreshed {
+					if a, e := source, endpointcreds.ProviderName; a != e {
+						t.Errorf("expected OnCredentialsRefreshed source %q, got %q", e, a)
+					}
+				}
+			}
+			if diff := cmp.Diff([]string{endpointcreds.ProviderName}, calls.resolved); diff != "" {
+				t.Errorf("unexpected OnCredentialsResolved calls: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCredentialsHooksDerive(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", []*servicemocks.MockEndpoint{
+		servicemocks.MockStsAssumeRoleInvalidEndpointInvalidClientTokenId,
+	})
+	defer closeSts()
+
+	var calls hookCalls
+	config := &Config{
+		AccessKey:           servicemocks.MockStaticAccessKey,
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+		StsEndpoint:         stsEndpoint,
+	}
+	calls.configure(config)
+
+	ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	_, err = NewDeriver(awsConfig, config).Derive(ctx, DeriveOverrides{
+		AssumeRole: &AssumeRole{
+			RoleARN:     servicemocks.MockStsAssumeRoleArn,
+			SessionName: servicemocks.MockStsAssumeRoleSessionName,
+		},
+	})
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	if a, e := len(calls.errors), 1; a != e {
+		t.Errorf("expected %d OnCredentialsError calls, got %d", e, a)
+	}
+}
@@ -166,3 +166,36 @@ func GetRetryMaxAttempts(ctx context.Context, sources []interface{}) (v int, fou
 	}
 	return v, found, err
 }
+
+// Copied and renamed from https://github.com/aws/aws-sdk-go-v2/blob/main/config/provider.go
+type RetryModeProvider interface {
+	GetRetryMode(context.Context) (aws.RetryMode, bool, error)
+}
+
+// Copied and renamed from https://github.com/aws/aws-sdk-go-v2/blob/main/config/provider.go
+func GetRetryMode(ctx context.Context, sources []interface{}) (v aws.RetryMode, found bool, err error) {
+	for _, c := range sources {
+		if p, ok := c.(RetryModeProvider); ok {
+			v, found, err = p.GetRetryMode(ctx)
+			if err != nil || found {
+				break
+			}
+		}
+	}
+	return v, found, err
+}
+
+// ResolveSource returns the first of the configSources from which resolve finds a value.
+// resolve is called with each source in turn, so any of the resolvers in this package can be used.
+func ResolveSource(configSources []interface{}, resolve func(sources []interface{}) (found bool, err error)) (source interface{}, found bool, err error) {
+	for _, source := range configSources {
+		found, err = resolve([]interface{}{source})
+		if err != nil {
+			return nil, false, err
+		}
+		if found {
+			return source, true, nil
+		}
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,158 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package cache provides a concurrency-safe cache that loads each key at most once at a time.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/detach"
+)
+
+// Cache memoizes values by key.
+// Concurrent calls to Get for the same key share a single call of the load function.
+type Cache[V any] struct {
+	mu      sync.Mutex
+	entries map[string]*entry[V]
+	onEvict func(V)
+
+	// now is replaced in tests.
+	now func() time.Time
+}
+
+type entry[V any] struct {
+	done    chan struct{}
+	value   V
+	err     error
+	expires time.Time
+
+	// loaded is set, while holding the cache's lock, when load has returned.
+	loaded bool
+}
+
+// New returns an empty Cache.
+func New[V any]() *Cache[V] {
+	return NewWithEvict[V](nil)
+}
+
+// NewWithEvict returns an empty Cache that calls onEvict with each loaded value that is removed from the cache,
+// when it expires, is deleted or is purged. A value removed while it is loading is passed to onEvict once it has loaded,
+// after it is returned to the callers waiting on it. onEvict is not called for errors.
+func NewWithEvict[V any](onEvict func(V)) *Cache[V] {
+	return &Cache[V]{
+		entries: make(map[string]*entry[V]),
+		onEvict: onEvict,
+		now:     time.Now,
+	}
+}
+
+// Get returns the value cached for key, calling load if there is no unexpired value.
+// load returns the value and the time at which it expires. A zero expiry time never expires.
+// Errors are returned to all callers waiting on the load but are not cached. A panic in load is returned as an error.
+//
+// load is called with a context that has the values of ctx but is not canceled with it, so that the load
+// continues for the other callers waiting on it, and is cached, if the caller that started it stops waiting.
+func (c *Cache[V]) Get(ctx context.Context, key string, load func(ctx context.Context) (V, time.Time, error)) (V, error) {
+	var evicted []*entry[V]
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if ok && e.loaded && !e.expires.IsZero() && !c.now().Before(e.expires) {
+		delete(c.entries, key)
+		evicted = append(evicted, e)
+		ok = false
+	}
+	if !ok {
+		e = &entry[V]{
+			done: make(chan struct{}),
+		}
+		c.entries[key] = e
+		go c.load(detach.Context(ctx), key, e, load)
+	}
+	c.mu.Unlock()
+
+	c.evict(evicted)
+
+	select {
+	case <-e.done:
+		return e.value, e.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (c *Cache[V]) load(ctx context.Context, key string, e *entry[V], load func(ctx context.Context) (V, time.Time, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			var zero V
+			e.value, e.expires, e.err = zero, time.Time{}, fmt.Errorf("panic: %v", r)
+		}
+
+		c.mu.Lock()
+		e.loaded = true
+		removed := c.entries[key] != e
+		if e.err != nil && !removed {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+
+		close(e.done)
+
+		// The entry was deleted or purged while loading
+		if removed {
+			c.evict([]*entry[V]{e})
+		}
+	}()
+
+	e.value, e.expires, e.err = load(ctx)
+}
+
+// evict calls onEvict with the values of entries that were removed from the cache after they loaded.
+// Entries that were removed while loading are evicted by load.
+func (c *Cache[V]) evict(entries []*entry[V]) {
+	if c.onEvict == nil {
+		return
+	}
+	for _, e := range entries {
+		if e.err == nil {
+			c.onEvict(e.value)
+		}
+	}
+}
+
+// Delete removes the value cached for key.
+func (c *Cache[V]) Delete(key string) {
+	var evicted []*entry[V]
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		delete(c.entries, key)
+		if e.loaded {
+			evicted = append(evicted, e)
+		}
+	}
+	c.mu.Unlock()
+
+	c.evict(evicted)
+}
+
+// Purge removes all cached values.
+func (c *Cache[V]) Purge() {
+	var evicted []*entry[V]
+
+	c.mu.Lock()
+	for _, e := range c.entries {
+		if e.loaded {
+			evicted = append(evicted, e)
+		}
+	}
+	c.entries = make(map[string]*entry[V])
+	c.mu.Unlock()
+
+	c.evict(evicted)
+}
@@ -0,0 +1,282 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetSingleFlight(t *testing.T) {
+	c := New[int]()
+
+	var calls int32
+	release := make(chan struct{})
+	load := func(context.Context) (int, time.Time, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 42, time.Time{}, nil
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make([]int, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := c.Get(context.Background(), "key", load)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			results[i] = v
+		}(i)
+	}
+
+	// Allow the callers to start waiting on the first load
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if a, e := atomic.LoadInt32(&calls), int32(1); a != e {
+		t.Errorf("expected %d load calls, got %d", e, a)
+	}
+	for i, v := range results {
+		if v != 42 {
+			t.Errorf("caller %d: expected 42, got %d", i, v)
+		}
+	}
+}
+
+func TestGetExpiry(t *testing.T) {
+	now := time.Now()
+	c := New[int]()
+	c.now = func() time.Time { return now }
+
+	var calls int
+	load := func(context.Context) (int, time.Time, error) {
+		calls++
+		return calls, now.Add(time.Minute), nil
+	}
+
+	testCases := []struct {
+		advance  time.Duration
+		expected int
+	}{
+		{0, 1},
+		{30 * time.Second, 1},
+		{30 * time.Second, 2},
+		{59 * time.Second, 2},
+	}
+
+	for i, testCase := range testCases {
+		now = now.Add(testCase.advance)
+
+		v, err := c.Get(context.Background(), "key", load)
+		if err != nil {
+			t.Fatalf("step %d: unexpected error: %s", i, err)
+		}
+		if v != testCase.expected {
+			t.Errorf("step %d: expected %d, got %d", i, testCase.expected, v)
+		}
+	}
+}
+
+func TestGetError(t *testing.T) {
+	c := New[int]()
+
+	var calls int
+	load := func(context.Context) (int, time.Time, error) {
+		calls++
+		if calls == 1 {
+			return 0, time.Time{}, errors.New("failed")
+		}
+		return calls, time.Time{}, nil
+	}
+
+	if _, err := c.Get(context.Background(), "key", load); err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	v, err := c.Get(context.Background(), "key", load)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if v != 2 {
+		t.Errorf("expected 2, got %d", v)
+	}
+}
+
+func TestGetContextCanceled(t *testing.T) {
+	c := New[int]()
+
+	release := make(chan struct{})
+	defer close(release)
+	go func() {
+		_, _ = c.Get(context.Background(), "key", func(context.Context) (int, time.Time, error) {
+			<-release
+			return 1, time.Time{}, nil
+		})
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.Get(ctx, "key", func(context.Context) (int, time.Time, error) {
+		t.Error("unexpected load call")
+		return 0, time.Time{}, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestGetPanic(t *testing.T) {
+	c := New[int]()
+
+	_, err := c.Get(context.Background(), "key", func(context.Context) (int, time.Time, error) {
+		panic("test")
+	})
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	v, err := c.Get(context.Background(), "key", func(context.Context) (int, time.Time, error) {
+		return 42, time.Time{}, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if v != 42 {
+		t.Errorf("expected 42, got %d", v)
+	}
+}
+
+// TestGetFirstCallerCanceled checks that the load continues for the other callers when the caller that started it stops waiting.
+func TestGetFirstCallerCanceled(t *testing.T) {
+	c := New[int]()
+
+	release := make(chan struct{})
+	load := func(ctx context.Context) (int, time.Time, error) {
+		<-release
+		if err := ctx.Err(); err != nil {
+			return 0, time.Time{}, err
+		}
+		return 42, time.Time{}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := c.Get(ctx, "key", load)
+		firstErr <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	secondResult := make(chan int, 1)
+	go func() {
+		v, err := c.Get(context.Background(), "key", func(context.Context) (int, time.Time, error) {
+			t.Error("unexpected load call")
+			return 0, time.Time{}, nil
+		})
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+		secondResult <- v
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	close(release)
+	if v := <-secondResult; v != 42 {
+		t.Errorf("expected 42, got %d", v)
+	}
+}
+
+func TestEvict(t *testing.T) {
+	now := time.Now()
+
+	var mu sync.Mutex
+	var evicted []int
+	c := NewWithEvict(func(v int) {
+		mu.Lock()
+		defer mu.Unlock()
+		evicted = append(evicted, v)
+	})
+	c.now = func() time.Time { return now }
+
+	get := func(key string, v int, expires time.Time) {
+		t.Helper()
+		if _, err := c.Get(context.Background(), key, func(context.Context) (int, time.Time, error) {
+			return v, expires, nil
+		}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	// Expiry
+	get("expires", 1, now.Add(time.Minute))
+	now = now.Add(time.Minute)
+	get("expires", 2, time.Time{})
+
+	// Delete
+	get("deleted", 3, time.Time{})
+	c.Delete("deleted")
+
+	// Purge
+	get("purged", 4, time.Time{})
+	c.Purge()
+
+	// Deleted while loading
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = c.Get(context.Background(), "loading", func(context.Context) (int, time.Time, error) {
+			<-release
+			return 5, time.Time{}, nil
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	c.Delete("loading")
+	close(release)
+	<-done
+
+	// Errors are not evicted
+	_, _ = c.Get(context.Background(), "error", func(context.Context) (int, time.Time, error) {
+		return 6, time.Time{}, errors.New("error")
+	})
+	c.Purge()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(evicted)
+		mu.Unlock()
+		if n >= 5 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Ints(evicted)
+	if a, e := evicted, []int{1, 2, 3, 4, 5}; !reflect.DeepEqual(a, e) {
+		t.Errorf("expected evicted values %v, got %v", e, a)
+	}
+}
@@ -13,8 +13,13 @@ import (
 	"time"
 
 	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/constants"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/expand"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+	"go.opentelemetry.io/otel/metric"
 )
 
 type Config struct {
@@ -24,15 +29,30 @@ type Config struct {
 	AssumeRoleWithWebIdentity      *AssumeRoleWithWebIdentity
 	CallerDocumentationURL         string
 	CallerName                     string
+	ContainerCredentials           *ContainerCredentials
+	CredentialSourcePolicy         *CredentialSourcePolicy
+	CredentialsFileCache           *CredentialsFileCache
+	CredentialsRefresh             *CredentialsRefresh
 	CustomCABundle                 string
+	DebugLogFilter                 *DebugLogFilter
+	DecodeAuthorizationMessages    bool
 	EC2MetadataServiceEnableState  imds.ClientEnableState
 	EC2MetadataServiceEndpoint     string
 	EC2MetadataServiceEndpointMode string
+	GetSessionToken                *GetSessionToken
 	HTTPClient                     *http.Client
 	HTTPProxy                      string
+	HTTPTrafficLogFile             string
+	HTTPTrafficLogFormat           string
 	IamEndpoint                    string
 	Insecure                       bool
 	MaxRetries                     int
+	MeterProvider                  metric.MeterProvider
+	Offline                        bool
+	OnAssumeRole                   AssumeRoleHook       `json:"-"`
+	OnCredentialsError             CredentialsErrorHook `json:"-"`
+	OnCredentialsRefreshed         CredentialsHook      `json:"-"`
+	OnCredentialsResolved          CredentialsHook      `json:"-"`
 	Profile                        string
 	Region                         string
 	SecretKey                      string
@@ -43,10 +63,64 @@ type Config struct {
 	StsEndpoint                    string
 	StsRegion                      string
 	SuppressDebugLog               bool
+	Timeouts                       *Timeouts
 	Token                          string
 	UseDualStackEndpoint           bool
 	UseFIPSEndpoint                bool
 	UserAgent                      UserAgentProducts
+	WatchCredentialFiles           *WatchCredentialFiles
+}
+
+const defaultCredentialsFileCacheDir = "~/.aws/cli/cache"
+
+// CredentialsFileCache enables caching temporary credentials from assuming a role, assuming a role with a web identity
+// and SSO on disk, in the format of the AWS CLI's credentials cache, so that they are reused between processes.
+// Credentials from assuming a role are keyed by the role ARN, session name and source identity.
+type CredentialsFileCache struct {
+	// Dir is the cache directory. Defaults to "~/.aws/cli/cache", the AWS CLI's credentials cache.
+	Dir string
+}
+
+// WatchCredentialFiles enables watching the shared credentials and configuration files and the web identity token file.
+// When they change, cached credentials are discarded and resolved again on next use.
+// Watching continues until CloseAwsConfig is called with the aws.Config returned by GetAwsConfig.
+type WatchCredentialFiles struct {
+	// Interval is the time between checks of the files. Defaults to 5 seconds.
+	Interval time.Duration
+
+	// Debounce is the time that files must be unchanged after a change before credentials are resolved again,
+	// so that partially written files are not read. Defaults to 1 second.
+	Debounce time.Duration
+}
+
+// DebugLogFilter controls which HTTP requests and responses are written to the debug log.
+type DebugLogFilter struct {
+	// Rules are evaluated in order and the first matching rule is applied.
+	Rules []DebugLogRule
+
+	// ExcludeUnmatched excludes requests that do not match any rule from the debug log.
+	ExcludeUnmatched bool
+
+	// BodiesOnErrorOnly logs request and response bodies only when the response is an error.
+	BodiesOnErrorOnly bool
+}
+
+// DebugLogRule matches HTTP requests by AWS service ID and operation name.
+type DebugLogRule struct {
+	// Service is the AWS service ID, e.g. "EC2". An empty value matches all services.
+	Service string
+
+	// Operation is the API operation name, e.g. "DescribeInstances". An empty value matches all operations.
+	Operation string
+
+	// Exclude excludes matching requests from the debug log.
+	Exclude bool
+
+	// SampleRate logs one in every SampleRate matching requests. Values of 0 or 1 log every request.
+	SampleRate int
+
+	// BodiesOnErrorOnly logs request and response bodies only when the response is an error.
+	BodiesOnErrorOnly bool
 }
 
 type AssumeRole struct {
@@ -111,6 +185,30 @@ func (c Config) HTTPTransportOptions() (func(*http.Transport), error) {
 	return opts, nil
 }
 
+// HTTPTrafficLog returns the log used to record HTTP requests and responses, or nil if not configured.
+// The file and format can be set in the configuration or using environment variables.
+func (c Config) HTTPTrafficLog() (*logging.TrafficLog, error) {
+	file := c.HTTPTrafficLogFile
+	if file == "" {
+		file = os.Getenv(constants.HTTPTrafficLogFileEnvVar)
+	}
+	if file == "" {
+		return nil, nil
+	}
+
+	format := c.HTTPTrafficLogFormat
+	if format == "" {
+		format = os.Getenv(constants.HTTPTrafficLogFormatEnvVar)
+	}
+
+	file, err := expand.FilePath(file)
+	if err != nil {
+		return nil, fmt.Errorf("expanding HTTP traffic log file: %w", err)
+	}
+
+	return logging.OpenTrafficLog(file, format)
+}
+
 func (c Config) ResolveSharedConfigFiles() ([]string, error) {
 	v, err := expand.FilePaths(c.SharedConfigFiles)
 	if err != nil {
@@ -135,6 +233,10 @@ type AssumeRoleWithWebIdentity struct {
 	SessionName          string
 	WebIdentityToken     string
 	WebIdentityTokenFile string
+
+	// WebIdentityTokenRetriever retrieves the web identity token each time credentials are retrieved,
+	// e.g. from a CI/CD system's OIDC token endpoint.
+	WebIdentityTokenRetriever stscreds.IdentityTokenRetriever `json:"-"`
 }
 
 func (c AssumeRoleWithWebIdentity) resolveWebIdentityTokenFile() (string, error) {
@@ -146,11 +248,14 @@ func (c AssumeRoleWithWebIdentity) resolveWebIdentityTokenFile() (string, error)
 }
 
 func (c AssumeRoleWithWebIdentity) HasValidTokenSource() bool {
-	return c.WebIdentityToken != "" || c.WebIdentityTokenFile != ""
+	return c.WebIdentityToken != "" || c.WebIdentityTokenFile != "" || c.WebIdentityTokenRetriever != nil
 }
 
 // Implements `stscreds.IdentityTokenRetriever`
 func (c AssumeRoleWithWebIdentity) GetIdentityToken() ([]byte, error) {
+	if c.WebIdentityTokenRetriever != nil {
+		return c.WebIdentityTokenRetriever.GetIdentityToken()
+	}
 	if c.WebIdentityToken != "" {
 		return []byte(c.WebIdentityToken), nil
 	}
@@ -166,3 +271,52 @@ func (c AssumeRoleWithWebIdentity) GetIdentityToken() ([]byte, error) {
 
 	return b, nil
 }
+
+// ResolveCredentialsFileCacheDir returns the credentials file cache directory, with "~" and environment variables expanded.
+func (c Config) ResolveCredentialsFileCacheDir() (string, error) {
+	dir := defaultCredentialsFileCacheDir
+	if c.CredentialsFileCache != nil && c.CredentialsFileCache.Dir != "" {
+		dir = c.CredentialsFileCache.Dir
+	}
+	return expand.FilePath(dir)
+}
+
+// ResolveCredentialFiles returns the files from which credentials can be resolved: the shared credentials and configuration files,
+// or their defaults, and the web identity token file from AssumeRoleWithWebIdentity or the environment.
+func (c Config) ResolveCredentialFiles() ([]string, error) {
+	credentialsFiles, err := c.ResolveSharedCredentialsFiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(credentialsFiles) == 0 {
+		credentialsFiles = []string{config.DefaultSharedCredentialsFilename()}
+		if v := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); v != "" {
+			credentialsFiles = []string{v}
+		}
+	}
+
+	configFiles, err := c.ResolveSharedConfigFiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(configFiles) == 0 {
+		configFiles = []string{config.DefaultSharedConfigFilename()}
+		if v := os.Getenv("AWS_CONFIG_FILE"); v != "" {
+			configFiles = []string{v}
+		}
+	}
+
+	files := append(credentialsFiles, configFiles...)
+
+	if c.AssumeRoleWithWebIdentity != nil && c.AssumeRoleWithWebIdentity.WebIdentityTokenFile != "" {
+		v, err := c.AssumeRoleWithWebIdentity.resolveWebIdentityTokenFile()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, v)
+	} else if v := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"); v != "" {
+		files = append(files, v)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,158 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/expand"
+)
+
+const (
+	// ecsContainerCredentialsEndpoint is the endpoint to which RelativeURI is relative.
+	ecsContainerCredentialsEndpoint = "http://169.254.170.2"
+
+	containerCredentialsFullURIEnvVar                = "AWS_CONTAINER_CREDENTIALS_FULL_URI"
+	containerCredentialsRelativeURIEnvVar            = "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"
+	containerCredentialsAuthorizationTokenEnvVar     = "AWS_CONTAINER_AUTHORIZATION_TOKEN"
+	containerCredentialsAuthorizationTokenFileEnvVar = "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"
+)
+
+// defaultContainerCredentialsHosts are the hosts of the Amazon ECS and Amazon EKS Pod Identity credentials endpoints.
+var defaultContainerCredentialsHosts = []string{
+	"169.254.170.2",
+	"169.254.170.23",
+	"fd00:ec2::23",
+}
+
+// ContainerCredentials configures retrieving credentials from a container credentials endpoint,
+// as used by Amazon ECS task roles and Amazon EKS Pod Identity.
+// Fields that are not set default to the corresponding AWS_CONTAINER_* environment variables.
+type ContainerCredentials struct {
+	// FullURI is the URL of the credentials endpoint.
+	FullURI string
+
+	// RelativeURI is the path of the credentials endpoint on the Amazon ECS endpoint, http://169.254.170.2.
+	// It takes precedence over FullURI.
+	RelativeURI string
+
+	// AuthorizationToken is sent in the Authorization header.
+	AuthorizationToken string
+
+	// AuthorizationTokenFile is the name of a file containing the token sent in the Authorization header.
+	// The file is read on each request, so that rotated tokens are used. It takes precedence over AuthorizationToken.
+	AuthorizationTokenFile string
+
+	// AllowedHosts restricts the hosts of the credentials endpoint.
+	// By default, HTTPS endpoints are allowed on any host, and HTTP endpoints only on loopback hosts and
+	// the Amazon ECS and Amazon EKS Pod Identity endpoints.
+	AllowedHosts []string
+
+	// Timeout limits each request to the credentials endpoint. A zero value does not limit requests other than by the context.
+	Timeout time.Duration
+}
+
+// ResolveEndpoint returns the URL of the credentials endpoint and checks that its host is allowed.
+func (c ContainerCredentials) ResolveEndpoint() (string, error) {
+	var endpoint string
+	switch {
+	case c.RelativeURI != "":
+		endpoint = ecsContainerCredentialsEndpoint + c.RelativeURI
+	case c.FullURI != "":
+		endpoint = c.FullURI
+	case os.Getenv(containerCredentialsRelativeURIEnvVar) != "":
+		endpoint = ecsContainerCredentialsEndpoint + os.Getenv(containerCredentialsRelativeURIEnvVar)
+	case os.Getenv(containerCredentialsFullURIEnvVar) != "":
+		endpoint = os.Getenv(containerCredentialsFullURIEnvVar)
+	default:
+		return "", fmt.Errorf("container credentials endpoint not set: one of FullURI, RelativeURI, %s or %s must be set",
+			containerCredentialsFullURIEnvVar, containerCredentialsRelativeURIEnvVar)
+	}
+
+	if err := c.checkHost(endpoint); err != nil {
+		return "", err
+	}
+
+	return endpoint, nil
+}
+
+func (c ContainerCredentials) checkHost(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid container credentials endpoint: %w", err)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("invalid container credentials endpoint %q: must include scheme and host", endpoint)
+	}
+
+	if len(c.AllowedHosts) > 0 {
+		for _, v := range c.AllowedHosts {
+			if strings.EqualFold(host, v) {
+				return nil
+			}
+		}
+		return fmt.Errorf("container credentials endpoint host %q is not in AllowedHosts", host)
+	}
+
+	if u.Scheme == "https" || isLoopbackHost(host) {
+		return nil
+	}
+	for _, v := range defaultContainerCredentialsHosts {
+		if host == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("container credentials endpoint host %q is not allowed: HTTP endpoints must be on a loopback host or a container credentials host", host)
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ResolveAuthorizationToken returns the token to send in the Authorization header, if any.
+// Token files are read each time it is called.
+func (c ContainerCredentials) ResolveAuthorizationToken() (string, error) {
+	switch {
+	case c.AuthorizationTokenFile != "":
+		return readAuthorizationTokenFile(c.AuthorizationTokenFile)
+	case c.AuthorizationToken != "":
+		return c.AuthorizationToken, nil
+	case os.Getenv(containerCredentialsAuthorizationTokenFileEnvVar) != "":
+		return readAuthorizationTokenFile(os.Getenv(containerCredentialsAuthorizationTokenFileEnvVar))
+	default:
+		return os.Getenv(containerCredentialsAuthorizationTokenEnvVar), nil
+	}
+}
+
+func readAuthorizationTokenFile(filename string) (string, error) {
+	f, err := expand.FilePath(filename)
+	if err != nil {
+		return "", fmt.Errorf("expanding container credentials authorization token file: %w", err)
+	}
+	b, err := os.ReadFile(f)
+	if err != nil {
+		return "", fmt.Errorf("reading container credentials authorization token file: %w", err)
+	}
+
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("container credentials authorization token file %q is empty", f)
+	}
+	if strings.ContainsAny(token, "\r\n") {
+		return "", errors.New("container credentials authorization token must not contain line breaks")
+	}
+
+	return token, nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Credential sources, as used in CredentialSourcePolicy.
+const (
+	CredentialSourceStatic            = "static"
+	CredentialSourceEnvironment       = "environment"
+	CredentialSourceSharedCredentials = "shared_credentials"
+	CredentialSourceProcess           = "process"
+	CredentialSourceSSO               = "sso"
+	CredentialSourceWebIdentity       = "web_identity"
+	CredentialSourceAssumeRole        = "assume_role"
+	CredentialSourceSessionToken      = "session_token"
+	CredentialSourceContainer         = "container"
+	CredentialSourceIMDS              = "imds"
+)
+
+func CredentialSource_Values() []string {
+	return []string{
+		CredentialSourceStatic,
+		CredentialSourceEnvironment,
+		CredentialSourceSharedCredentials,
+		CredentialSourceProcess,
+		CredentialSourceSSO,
+		CredentialSourceWebIdentity,
+		CredentialSourceAssumeRole,
+		CredentialSourceSessionToken,
+		CredentialSourceContainer,
+		CredentialSourceIMDS,
+	}
+}
+
+// CredentialSourcePolicy restricts the sources from which credentials can be obtained.
+// It is checked against the source of the initial credentials, against the source of the session credentials if GetSessionToken is set,
+// and against the source of the final credentials after any role is assumed, so a policy allowing only some sources
+// must also allow CredentialSourceSessionToken if GetSessionToken is set and CredentialSourceAssumeRole if a role is assumed.
+type CredentialSourcePolicy struct {
+	// Allowed lists the allowed credential sources. If empty, all sources not in Denied are allowed.
+	Allowed []string
+
+	// Denied lists the denied credential sources.
+	Denied []string
+}
+
+// Check returns a CredentialSourceNotAllowedError if the policy does not allow the aws.Credentials Source.
+// A nil policy allows all sources.
+func (p *CredentialSourcePolicy) Check(providerSource string) error {
+	if p == nil {
+		return nil
+	}
+
+	source := CredentialSource(providerSource)
+	if slices.Contains(p.Denied, source) || (len(p.Allowed) > 0 && !slices.Contains(p.Allowed, source)) {
+		return CredentialSourceNotAllowedError{
+			Source:         source,
+			ProviderSource: providerSource,
+		}
+	}
+	return nil
+}
+
+// CredentialSource returns the credential source for the Source of aws.Credentials.
+// Unrecognized values are returned unchanged.
+func CredentialSource(providerSource string) string {
+	switch {
+	case providerSource == "StaticCredentials":
+		return CredentialSourceStatic
+	case providerSource == "EnvConfigCredentials":
+		return CredentialSourceEnvironment
+	case strings.HasPrefix(providerSource, "SharedConfigCredentials"):
+		return CredentialSourceSharedCredentials
+	case providerSource == "ProcessProvider":
+		return CredentialSourceProcess
+	case providerSource == "SSOProvider":
+		return CredentialSourceSSO
+	case providerSource == "WebIdentityCredentials":
+		return CredentialSourceWebIdentity
+	case providerSource == "AssumeRoleProvider":
+		return CredentialSourceAssumeRole
+	case providerSource == "GetSessionTokenProvider":
+		return CredentialSourceSessionToken
+	case providerSource == "CredentialsEndpointProvider":
+		return CredentialSourceContainer
+	case providerSource == "EC2RoleProvider":
+		return CredentialSourceIMDS
+	}
+	return providerSource
+}
@@ -0,0 +1,34 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// CredentialsRefresh controls when cached temporary credentials are refreshed, such as credentials from assuming a role
+// or from the EC2 instance metadata service, SSO or a container credentials endpoint.
+type CredentialsRefresh struct {
+	// ExpiryWindow is the time before credentials expire at which they are treated as expired and refreshed.
+	ExpiryWindow time.Duration
+
+	// ExpiryWindowJitterFrac randomly shortens ExpiryWindow by up to this fraction, between 0 and 1,
+	// so that processes sharing credentials do not all refresh them at the same time.
+	ExpiryWindowJitterFrac float64
+
+	// BackgroundRefresh refreshes credentials in the background when they enter the expiry window,
+	// so that requests do not wait for credentials to be refreshed.
+	// Background refresh continues until CloseAwsConfig is called with the aws.Config returned by GetAwsConfig.
+	BackgroundRefresh bool
+}
+
+// CredentialsCacheOptions sets the options of an aws.CredentialsCache from CredentialsRefresh.
+func (c Config) CredentialsCacheOptions(o *aws.CredentialsCacheOptions) {
+	if r := c.CredentialsRefresh; r != nil {
+		o.ExpiryWindow = r.ExpiryWindow
+		o.ExpiryWindowJitterFrac = r.ExpiryWindowJitterFrac
+	}
+}
@@ -5,6 +5,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 )
 
 // CannotAssumeRoleError occurs when AssumeRole cannot complete.
@@ -67,6 +68,36 @@ func (c *Config) NewCannotAssumeRoleWithWebIdentityError(err error) CannotAssume
 	return CannotAssumeRoleWithWebIdentityError{Config: c, Err: err}
 }
 
+// CannotGetSessionTokenError occurs when GetSessionToken cannot complete.
+type CannotGetSessionTokenError struct {
+	Config *Config
+	Err    error
+}
+
+func (e CannotGetSessionTokenError) Error() string {
+	if e.Config == nil || e.Config.GetSessionToken == nil || e.Config.GetSessionToken.SerialNumber == "" {
+		return fmt.Sprintf("cannot get session token: %s", e.Err)
+	}
+
+	return fmt.Sprintf(`Session token cannot be obtained with MFA device (%s).
+
+There are a number of possible causes of this - the most common are:
+  * The credentials used in order to get the session token are not long-term IAM user credentials
+  * The MFA token code is invalid or has already been used
+  * The MFA device serial number is not valid
+
+Error: %s
+`, e.Config.GetSessionToken.SerialNumber, e.Err)
+}
+
+func (e CannotGetSessionTokenError) Unwrap() error {
+	return e.Err
+}
+
+func (c *Config) NewCannotGetSessionTokenError(err error) CannotGetSessionTokenError {
+	return CannotGetSessionTokenError{Config: c, Err: err}
+}
+
 // NoValidCredentialSourcesError occurs when all credential lookup methods have been exhausted without results.
 type NoValidCredentialSourcesError struct {
 	Config *Config
@@ -94,3 +125,87 @@ func (e NoValidCredentialSourcesError) Unwrap() error {
 func (c *Config) NewNoValidCredentialSourcesError(err error) NoValidCredentialSourcesError {
 	return NoValidCredentialSourcesError{Config: c, Err: err}
 }
+
+// AuthorizationFailureError wraps an error containing an encoded authorization failure message
+// with the decoded message.
+type AuthorizationFailureError struct {
+	Err            error
+	DecodedMessage string
+}
+
+func (e AuthorizationFailureError) Error() string {
+	return fmt.Sprintf(`%s
+
+Decoded authorization failure message: %s`, e.Err, e.DecodedMessage)
+}
+
+func (e AuthorizationFailureError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError occurs when a configuration field is invalid.
+type ValidationError struct {
+	// Path is the path of the field, e.g. "AssumeRole.RoleARN".
+	Path string
+	Err  error
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// CredentialSourceNotAllowedError occurs when credentials are obtained from a source not allowed by the CredentialSourcePolicy.
+type CredentialSourceNotAllowedError struct {
+	// Source is the credential source, e.g. "imds".
+	Source string
+	// ProviderSource is the Source of the aws.Credentials, e.g. "EC2RoleProvider".
+	ProviderSource string
+}
+
+func (e CredentialSourceNotAllowedError) Error() string {
+	return fmt.Sprintf("credentials from source %q (%s) are not allowed by the credential source policy", e.Source, e.ProviderSource)
+}
+
+// OfflineError occurs when resolving the configuration in offline mode would require a network call.
+type OfflineError struct {
+	// Endpoint is the endpoint that would have been contacted, if known.
+	Endpoint string
+	// Reason describes the configuration requiring network access, if known.
+	Reason string
+}
+
+func (e OfflineError) Error() string {
+	if e.Endpoint != "" {
+		return fmt.Sprintf("offline mode: network access to %s is not allowed", e.Endpoint)
+	}
+	return fmt.Sprintf("offline mode: %s requires network access", e.Reason)
+}
+
+// RetryableError prevents the AWS SDK for Go v2 from retrying requests refused in offline mode.
+func (e OfflineError) RetryableError() bool {
+	return false
+}
+
+// PhaseTimeoutError occurs when a phase of configuration exceeds its limit in Config.Timeouts.
+type PhaseTimeoutError struct {
+	Phase Phase
+	// Endpoint is the endpoint being contacted when the phase timed out, if known.
+	Endpoint string
+	Timeout  time.Duration
+	Err      error
+}
+
+func (e PhaseTimeoutError) Error() string {
+	if e.Endpoint != "" {
+		return fmt.Sprintf("%s timed out after %s contacting %s: %s", e.Phase, e.Timeout, e.Endpoint, e.Err)
+	}
+	return fmt.Sprintf("%s timed out after %s: %s", e.Phase, e.Timeout, e.Err)
+}
+
+func (e PhaseTimeoutError) Unwrap() error {
+	return e.Err
+}
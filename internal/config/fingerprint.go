@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// fingerprintKey keys the fingerprint hash so that a fingerprint cannot be used to test guesses of
+// secret values such as SecretKey outside of the current process.
+var fingerprintKey = func() []byte {
+	b := make([]byte, sha256.Size)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("generating configuration fingerprint key: %s", err))
+	}
+	return b
+}()
+
+// Fingerprint returns an identifier of the configuration that is stable for the life of the process,
+// for use as a cache key.
+// Secret values only contribute to a keyed hash and cannot be recovered from the fingerprint.
+// HTTPClient, MeterProvider, the credentials lifecycle hooks GetSessionToken.TokenProvider and
+// AssumeRoleWithWebIdentity.WebIdentityTokenRetriever are compared by identity.
+func (c Config) Fingerprint() (string, error) {
+	h := hmac.New(sha256.New, fingerprintKey)
+
+	references := []any{
+		c.HTTPClient,
+		c.MeterProvider,
+		c.OnAssumeRole,
+		c.OnCredentialsError,
+		c.OnCredentialsRefreshed,
+		c.OnCredentialsResolved,
+	}
+	if c.GetSessionToken != nil {
+		references = append(references, c.GetSessionToken.TokenProvider)
+	}
+	if c.AssumeRoleWithWebIdentity != nil {
+		references = append(references, c.AssumeRoleWithWebIdentity.WebIdentityTokenRetriever)
+	}
+	c.HTTPClient, c.MeterProvider = nil, nil
+
+	if err := json.NewEncoder(h).Encode(c); err != nil {
+		return "", err
+	}
+	for _, v := range references {
+		fmt.Fprintf(h, "%s\n", identity(v))
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// identity returns a string identifying v by reference, if it is a reference type, or by value otherwise.
+func identity(v any) string {
+	if v == nil {
+		return "nil"
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Map, reflect.Pointer, reflect.Slice, reflect.UnsafePointer:
+		if rv.IsNil() {
+			return fmt.Sprintf("%T:nil", v)
+		}
+		return fmt.Sprintf("%T:%x", v, rv.Pointer())
+	}
+	return fmt.Sprintf("%T:%#v", v, v)
+}
@@ -0,0 +1,25 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"time"
+)
+
+// GetSessionToken exchanges long-term IAM user credentials for temporary credentials using sts:GetSessionToken
+// before any role is assumed, for example to obtain MFA-authenticated credentials.
+type GetSessionToken struct {
+	// Duration is the lifetime of the temporary credentials. Defaults to 12 hours.
+	Duration time.Duration
+
+	// SerialNumber is the serial number or ARN of the MFA device. Requires TokenCode or TokenProvider.
+	SerialNumber string
+
+	// TokenCode is the code from the MFA device.
+	// A code can only be used once, so the temporary credentials cannot be refreshed when they expire.
+	TokenCode string
+
+	// TokenProvider returns a code from the MFA device each time the temporary credentials are refreshed.
+	TokenProvider func() (string, error) `json:"-"`
+}
@@ -0,0 +1,43 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"time"
+)
+
+// Credentials lifecycle hooks are called synchronously and should return quickly.
+// They allow callers to, for example, emit audit events without parsing logs.
+//
+//   - OnAssumeRole is called after each call to sts:AssumeRole or sts:AssumeRoleWithWebIdentity, including refreshes.
+//   - OnCredentialsError is called when credentials cannot be resolved, including when a role cannot be assumed by
+//     Deriver.Derive, or refreshed, including by background refresh.
+//   - OnCredentialsRefreshed is called when cached credentials from any source are retrieved again after they expire
+//     or are invalidated, including by background refresh and after the credential files change.
+//   - OnCredentialsResolved is called when credentials are resolved,
+//     including when they are resolved again after the credential files change.
+
+// AssumeRoleHook is called with the role ARN, the session name and the result of assuming a role.
+type AssumeRoleHook func(roleARN, sessionName string, result AssumeRoleResult)
+
+// CredentialsErrorHook is called with the error when credentials cannot be resolved or refreshed.
+type CredentialsErrorHook func(err error)
+
+// CredentialsHook is called with the source and expiry of credentials. A zero expiry time never expires.
+type CredentialsHook func(source string, expires time.Time)
+
+// AssumeRoleResult is the result of a call to sts:AssumeRole or sts:AssumeRoleWithWebIdentity.
+type AssumeRoleResult struct {
+	// Source is the name of the credentials provider, for example "AssumeRoleProvider".
+	Source string
+
+	// AccessKeyID is the access key ID of the assumed role credentials.
+	AccessKeyID string
+
+	// Expires is the expiry of the assumed role credentials.
+	Expires time.Time
+
+	// Err is the error if the role could not be assumed.
+	Err error
+}
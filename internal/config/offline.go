@@ -0,0 +1,26 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+// CheckOffline returns an OfflineError if Offline is set and the configuration requires network access to resolve credentials.
+// Credential sources resolved from the environment or the shared configuration files are only known once resolved,
+// so they are refused when the network call is made.
+func (c Config) CheckOffline() error {
+	if !c.Offline {
+		return nil
+	}
+	if c.GetSessionToken != nil {
+		return OfflineError{Reason: "GetSessionToken"}
+	}
+	if c.AssumeRole != nil {
+		return OfflineError{Reason: "AssumeRole"}
+	}
+	if c.AssumeRoleWithWebIdentity != nil {
+		return OfflineError{Reason: "AssumeRoleWithWebIdentity"}
+	}
+	if c.ContainerCredentials != nil {
+		return OfflineError{Reason: "ContainerCredentials"}
+	}
+	return nil
+}
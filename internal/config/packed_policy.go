@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+
+	"golang.org/x/exp/slices"
+)
+
+// assumeRolePackedPolicyLimitEstimate is the estimated limit, in compressed bytes, of the session policy,
+// managed policy ARNs and session tags that STS packs into the session token.
+const assumeRolePackedPolicyLimitEstimate = 2048
+
+// PackedPolicySizeEstimate estimates the size of the session policy, managed policy ARNs and session tags after STS
+// packs them into the session token, as a percentage of the limit.
+// STS does not document how they are packed, so values close to 100 may or may not be rejected with PackedPolicyTooLarge.
+func (c AssumeRole) PackedPolicySizeEstimate() int {
+	return packedPolicySizeEstimate(c.Policy, c.PolicyARNs, c.Tags)
+}
+
+// PackedPolicySizeEstimate estimates the size of the session policy and managed policy ARNs after STS
+// packs them into the session token, as a percentage of the limit.
+// STS does not document how they are packed, so values close to 100 may or may not be rejected with PackedPolicyTooLarge.
+func (c AssumeRoleWithWebIdentity) PackedPolicySizeEstimate() int {
+	return packedPolicySizeEstimate(c.Policy, c.PolicyARNs, nil)
+}
+
+func packedPolicySizeEstimate(policy string, policyARNs []string, tags map[string]string) int {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(policy)); err != nil {
+		buf.Reset()
+		buf.WriteString(policy)
+	}
+	for _, v := range policyARNs {
+		buf.WriteString(v)
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		buf.WriteString(k)
+		buf.WriteString(tags[k])
+	}
+
+	if buf.Len() == 0 {
+		return 0
+	}
+
+	// Compressing into a bytes.Buffer with a valid level cannot fail
+	var packed bytes.Buffer
+	w, _ := flate.NewWriter(&packed, flate.BestCompression)
+	_, _ = w.Write(buf.Bytes())
+	_ = w.Close()
+
+	return packed.Len() * 100 / assumeRolePackedPolicyLimitEstimate //nolint:gomnd
+}
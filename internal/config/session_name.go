@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultSessionNameTemplate is the session name template used when AssumeRole.SessionName or
+// AssumeRoleWithWebIdentity.SessionName is not set. It is the same in every run by the same user on the same host,
+// so that sessions can be attributed in CloudTrail and cached credentials can be reused.
+const DefaultSessionNameTemplate = "{{.User}}@{{.Hostname}}"
+
+const (
+	sessionNameMinLength = 2
+	sessionNameMaxLength = 64
+
+	sessionNameTimestampFormat = "20060102T150405Z"
+)
+
+var sessionNameInvalidCharsRegexp = regexp.MustCompile(`[^\w+=,.@-]`)
+
+// SessionNameData is the data available to session name and source identity templates.
+// Templates can also read environment variables with the env function, e.g. `{{env "CI_JOB_ID"}}`.
+type SessionNameData struct {
+	// User is the name of the current operating system user.
+	User string
+
+	// Hostname is the host name reported by the operating system.
+	Hostname string
+
+	// Timestamp is the current time in UTC, formatted as "20060102T150405Z".
+	Timestamp string
+}
+
+func newSessionNameData() SessionNameData {
+	hostname, _ := os.Hostname()
+
+	return SessionNameData{
+		User:      currentUser(),
+		Hostname:  hostname,
+		Timestamp: time.Now().UTC().Format(sessionNameTimestampFormat),
+	}
+}
+
+func currentUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return os.Getenv("USER")
+	}
+	// On Windows, Username has the form DOMAIN\user
+	if i := strings.LastIndex(u.Username, `\`); i >= 0 {
+		return u.Username[i+1:]
+	}
+	return u.Username
+}
+
+// ExpandSessionName expands a session name or source identity template.
+// Values without template actions are returned unchanged.
+func ExpandSessionName(v string) (string, error) {
+	if !strings.Contains(v, "{{") {
+		return v, nil
+	}
+
+	tmpl, err := template.New("session name").Option("missingkey=error").Funcs(template.FuncMap{
+		"env": os.Getenv,
+	}).Parse(v)
+	if err != nil {
+		return "", fmt.Errorf("parsing template %q: %w", v, err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, newSessionNameData()); err != nil {
+		return "", fmt.Errorf("expanding template %q: %w", v, err)
+	}
+
+	return b.String(), nil
+}
+
+// ResolveSessionName returns the session name with any template expanded.
+// If SessionName is not set, DefaultSessionNameTemplate is used.
+func (c AssumeRole) ResolveSessionName() (string, error) {
+	return resolveSessionName(c.SessionName)
+}
+
+// ResolveSourceIdentity returns the source identity with any template expanded.
+func (c AssumeRole) ResolveSourceIdentity() (string, error) {
+	return expandSessionName(c.SourceIdentity)
+}
+
+// ResolveSessionName returns the session name with any template expanded.
+// If SessionName is not set, DefaultSessionNameTemplate is used.
+func (c AssumeRoleWithWebIdentity) ResolveSessionName() (string, error) {
+	return resolveSessionName(c.SessionName)
+}
+
+func resolveSessionName(v string) (string, error) {
+	if v == "" {
+		return defaultSessionName(), nil
+	}
+	return expandSessionName(v)
+}
+
+// expandSessionName expands a template and checks that the result is valid.
+func expandSessionName(v string) (string, error) {
+	s, err := ExpandSessionName(v)
+	if err != nil {
+		return "", err
+	}
+	if s != v && !sessionNameRegexp.MatchString(s) {
+		return "", fmt.Errorf("template %q expands to %q, which %s", v, s, errSessionNameInvalid)
+	}
+	return s, nil
+}
+
+// defaultSessionName expands DefaultSessionNameTemplate, replacing characters not allowed by STS.
+// If the result is too short, the AWS SDK's default session name is used.
+func defaultSessionName() string {
+	s, err := ExpandSessionName(DefaultSessionNameTemplate)
+	if err != nil {
+		return ""
+	}
+	s = sessionNameInvalidCharsRegexp.ReplaceAllString(s, "-")
+	if len(s) > sessionNameMaxLength {
+		s = s[:sessionNameMaxLength]
+	}
+	if len(s) < sessionNameMinLength {
+		return ""
+	}
+	return s
+}
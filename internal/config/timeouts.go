@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"time"
+)
+
+// Phase is a phase of configuration that can be limited by Timeouts.
+type Phase string
+
+const (
+	PhaseCredentialsRetrieval  Phase = "credentials retrieval"
+	PhaseAssumeRole            Phase = "role assumption"
+	PhaseCredentialsValidation Phase = "credentials validation"
+	PhaseAccountIDLookup       Phase = "account ID lookup"
+)
+
+// Timeouts limit the time spent in each phase of configuration.
+// A zero value does not limit the phase other than by the context.
+//
+// A timeout stops waiting for the phase, not necessarily the work of the phase. The initial credentials are retrieved
+// through an aws.CredentialsCache, which does not cancel a retrieval when its caller's context is done, so after
+// a CredentialsRetrieval or AssumeRole timeout a retrieval of the initial credentials, for example a call to IMDS,
+// SSO or sts:AssumeRoleWithWebIdentity, may continue and complete after the PhaseTimeoutError is returned.
+// Its result is discarded.
+type Timeouts struct {
+	// CredentialsRetrieval limits waiting for the initial credentials, e.g. from IMDS, a container endpoint or SSO.
+	CredentialsRetrieval time.Duration
+
+	// AssumeRole limits waiting for the role in AssumeRole or AssumeRoleWithWebIdentity to be assumed.
+	AssumeRole time.Duration
+
+	// CredentialsValidation limits validating the credentials using sts:GetCallerIdentity.
+	CredentialsValidation time.Duration
+
+	// AccountIDLookup limits looking up the account ID and partition in GetAwsAccountIDAndPartition.
+	AccountIDLookup time.Duration
+}
+
+// For returns the timeout for the phase. A nil Timeouts does not limit any phase.
+func (t *Timeouts) For(phase Phase) time.Duration {
+	if t == nil {
+		return 0
+	}
+	switch phase {
+	case PhaseCredentialsRetrieval:
+		return t.CredentialsRetrieval
+	case PhaseAssumeRole:
+		return t.AssumeRole
+	case PhaseCredentialsValidation:
+		return t.CredentialsValidation
+	case PhaseAccountIDLookup:
+		return t.AccountIDLookup
+	}
+	return 0
+}
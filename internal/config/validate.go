@@ -0,0 +1,457 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/endpoints"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/expand"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+	multierror "github.com/hashicorp/go-multierror"
+	"golang.org/x/exp/slices"
+)
+
+// Limits of the STS AssumeRole and AssumeRoleWithWebIdentity APIs.
+const (
+	assumeRoleMinDuration       = 15 * time.Minute
+	assumeRoleMaxDuration       = 12 * time.Hour
+	assumeRoleMinExternalID     = 2
+	assumeRoleMaxExternalID     = 1224
+	assumeRoleMaxPolicyLength   = 2048
+	assumeRoleMaxPolicyARNs     = 10
+	assumeRoleMaxTags           = 50
+	assumeRoleMaxTagKeyLength   = 128
+	assumeRoleMaxTagValueLength = 256
+)
+
+// Limits of the STS GetSessionToken API.
+const (
+	getSessionTokenMinDuration = 15 * time.Minute
+	getSessionTokenMaxDuration = 36 * time.Hour
+)
+
+var (
+	sessionNameRegexp  = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+	externalIDRegexp   = regexp.MustCompile(`^[\w+=,.@:/-]*$`)
+	serialNumberRegexp = regexp.MustCompile(`^[\w+=/:,.@-]{9,256}$`)
+	tokenCodeRegexp    = regexp.MustCompile(`^\d{6}$`)
+	tagRegexp          = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+)
+
+// Validate checks all configuration fields and returns every problem found.
+// Each problem is reported as a ValidationError naming the field.
+// Values that can also be set by environment variables or shared configuration files are only checked when set in the Config.
+func (c Config) Validate() error {
+	var errs *multierror.Error
+
+	add := func(path string, err error) {
+		errs = multierror.Append(errs, ValidationError{Path: path, Err: err})
+	}
+
+	if c.AccessKey != "" && c.SecretKey == "" {
+		add("SecretKey", errors.New("must be set when AccessKey is set"))
+	}
+	if c.SecretKey != "" && c.AccessKey == "" {
+		add("AccessKey", errors.New("must be set when SecretKey is set"))
+	}
+	if c.Token != "" && c.AccessKey == "" {
+		add("Token", errors.New("cannot be set without AccessKey and SecretKey"))
+	}
+
+	if ar := c.AssumeRole; ar != nil {
+		ar.validate(add, "AssumeRole")
+	}
+
+	if ar := c.AssumeRoleWithWebIdentity; ar != nil {
+		ar.validate(add, "AssumeRoleWithWebIdentity")
+		switch {
+		case ar.WebIdentityTokenRetriever != nil && (ar.WebIdentityToken != "" || ar.WebIdentityTokenFile != ""):
+			add("AssumeRoleWithWebIdentity.WebIdentityTokenRetriever", errors.New("cannot be set with WebIdentityToken or WebIdentityTokenFile"))
+		case ar.WebIdentityToken != "" && ar.WebIdentityTokenFile != "":
+			add("AssumeRoleWithWebIdentity.WebIdentityTokenFile", errors.New("cannot be set with WebIdentityToken"))
+		case !ar.HasValidTokenSource():
+			add("AssumeRoleWithWebIdentity.WebIdentityToken", errors.New("one of WebIdentityToken, WebIdentityTokenFile, WebIdentityTokenRetriever must be set"))
+		case ar.WebIdentityTokenFile != "":
+			validateFileExists(add, "AssumeRoleWithWebIdentity.WebIdentityTokenFile", ar.WebIdentityTokenFile)
+		}
+	}
+
+	if cc := c.ContainerCredentials; cc != nil {
+		if c.AccessKey != "" {
+			add("ContainerCredentials", errors.New("cannot be set with AccessKey"))
+		}
+		if cc.FullURI != "" && cc.RelativeURI != "" {
+			add("ContainerCredentials.RelativeURI", errors.New("cannot be set with FullURI"))
+		}
+		if cc.FullURI != "" {
+			validateURL(add, "ContainerCredentials.FullURI", cc.FullURI)
+			if err := cc.checkHost(cc.FullURI); err != nil {
+				add("ContainerCredentials.FullURI", err)
+			}
+		}
+		if cc.RelativeURI != "" && !strings.HasPrefix(cc.RelativeURI, "/") {
+			add("ContainerCredentials.RelativeURI", fmt.Errorf("must start with /, got %q", cc.RelativeURI))
+		}
+		if cc.AuthorizationToken != "" && cc.AuthorizationTokenFile != "" {
+			add("ContainerCredentials.AuthorizationTokenFile", errors.New("cannot be set with AuthorizationToken"))
+		}
+		if strings.ContainsAny(cc.AuthorizationToken, "\r\n") {
+			add("ContainerCredentials.AuthorizationToken", errors.New("must not contain line breaks"))
+		}
+		if cc.AuthorizationTokenFile != "" {
+			validateFileExists(add, "ContainerCredentials.AuthorizationTokenFile", cc.AuthorizationTokenFile)
+		}
+		if cc.Timeout < 0 {
+			add("ContainerCredentials.Timeout", fmt.Errorf("must not be negative, got %s", cc.Timeout))
+		}
+	}
+
+	if c.CustomCABundle != "" {
+		validateFileExists(add, "CustomCABundle", c.CustomCABundle)
+	}
+
+	if p := c.CredentialSourcePolicy; p != nil {
+		for i, v := range p.Allowed {
+			if !slices.Contains(CredentialSource_Values(), v) {
+				add(fmt.Sprintf("CredentialSourcePolicy.Allowed[%d]", i), fmt.Errorf("unknown credential source %q", v))
+			}
+		}
+		for i, v := range p.Denied {
+			if !slices.Contains(CredentialSource_Values(), v) {
+				add(fmt.Sprintf("CredentialSourcePolicy.Denied[%d]", i), fmt.Errorf("unknown credential source %q", v))
+			}
+		}
+	}
+
+	if r := c.CredentialsRefresh; r != nil {
+		if r.ExpiryWindow < 0 {
+			add("CredentialsRefresh.ExpiryWindow", fmt.Errorf("must not be negative, got %s", r.ExpiryWindow))
+		}
+		if r.ExpiryWindowJitterFrac < 0 || r.ExpiryWindowJitterFrac > 1 {
+			add("CredentialsRefresh.ExpiryWindowJitterFrac", fmt.Errorf("must be between 0 and 1, got %g", r.ExpiryWindowJitterFrac))
+		}
+	}
+
+	if c.DebugLogFilter != nil {
+		for i, rule := range c.DebugLogFilter.Rules {
+			if rule.SampleRate < 0 {
+				add(fmt.Sprintf("DebugLogFilter.Rules[%d].SampleRate", i), fmt.Errorf("must not be negative, got %d", rule.SampleRate))
+			}
+		}
+	}
+
+	if c.EC2MetadataServiceEndpoint != "" {
+		validateURL(add, "EC2MetadataServiceEndpoint", c.EC2MetadataServiceEndpoint)
+	}
+	if c.EC2MetadataServiceEndpointMode != "" {
+		var endpointMode imds.EndpointModeState
+		if err := endpointMode.SetFromString(c.EC2MetadataServiceEndpointMode); err != nil {
+			add("EC2MetadataServiceEndpointMode", err)
+		}
+	}
+
+	if st := c.GetSessionToken; st != nil {
+		if c.Token != "" {
+			add("GetSessionToken", errors.New("requires long-term credentials and cannot be used with Token"))
+		}
+		if st.Duration != 0 && (st.Duration < getSessionTokenMinDuration || st.Duration > getSessionTokenMaxDuration) {
+			add("GetSessionToken.Duration", fmt.Errorf("must be between %s and %s, got %s", getSessionTokenMinDuration, getSessionTokenMaxDuration, st.Duration))
+		}
+		if st.SerialNumber != "" && !serialNumberRegexp.MatchString(st.SerialNumber) {
+			add("GetSessionToken.SerialNumber", errors.New("must be between 9 and 256 characters and contain only alphanumeric characters and +=/:,.@-"))
+		}
+		switch {
+		case st.TokenCode != "" && st.TokenProvider != nil:
+			add("GetSessionToken.TokenProvider", errors.New("cannot be set with TokenCode"))
+		case st.TokenCode == "" && st.TokenProvider == nil && st.SerialNumber != "":
+			add("GetSessionToken.TokenCode", errors.New("one of TokenCode, TokenProvider must be set when SerialNumber is set"))
+		case (st.TokenCode != "" || st.TokenProvider != nil) && st.SerialNumber == "":
+			add("GetSessionToken.SerialNumber", errors.New("must be set when TokenCode or TokenProvider is set"))
+		}
+		if st.TokenCode != "" && !tokenCodeRegexp.MatchString(st.TokenCode) {
+			add("GetSessionToken.TokenCode", errors.New("must be 6 digits"))
+		}
+	}
+
+	if c.HTTPClient != nil {
+		if c.HTTPProxy != "" {
+			add("HTTPProxy", errors.New("cannot be set with HTTPClient"))
+		}
+		if c.Insecure {
+			add("Insecure", errors.New("cannot be set with HTTPClient"))
+		}
+	}
+	if c.HTTPProxy != "" {
+		validateURL(add, "HTTPProxy", c.HTTPProxy)
+	}
+
+	switch c.HTTPTrafficLogFormat {
+	case "", logging.TrafficLogFormatHAR, logging.TrafficLogFormatJSONLines:
+	default:
+		add("HTTPTrafficLogFormat", fmt.Errorf("must be one of %q, %q, got %q", logging.TrafficLogFormatHAR, logging.TrafficLogFormatJSONLines, c.HTTPTrafficLogFormat))
+	}
+
+	if c.IamEndpoint != "" {
+		validateURL(add, "IamEndpoint", c.IamEndpoint)
+	}
+
+	if c.MaxRetries < 0 {
+		add("MaxRetries", fmt.Errorf("must not be negative, got %d", c.MaxRetries))
+	}
+
+	if c.Offline {
+		if c.AssumeRole != nil {
+			add("AssumeRole", errors.New("conflicts with Offline"))
+		}
+		if c.AssumeRoleWithWebIdentity != nil {
+			add("AssumeRoleWithWebIdentity", errors.New("conflicts with Offline"))
+		}
+		if c.ContainerCredentials != nil {
+			add("ContainerCredentials", errors.New("conflicts with Offline"))
+		}
+		if c.GetSessionToken != nil {
+			add("GetSessionToken", errors.New("conflicts with Offline"))
+		}
+	}
+
+	if c.Region != "" {
+		validateRegion(add, "Region", c.Region)
+	}
+
+	for i, f := range c.SharedConfigFiles {
+		if _, err := expand.FilePath(f); err != nil {
+			add(fmt.Sprintf("SharedConfigFiles[%d]", i), err)
+		}
+	}
+	for i, f := range c.SharedCredentialsFiles {
+		if _, err := expand.FilePath(f); err != nil {
+			add(fmt.Sprintf("SharedCredentialsFiles[%d]", i), err)
+		}
+	}
+
+	if c.StsEndpoint != "" {
+		validateURL(add, "StsEndpoint", c.StsEndpoint)
+	}
+	if c.StsRegion != "" {
+		validateRegion(add, "StsRegion", c.StsRegion)
+	}
+
+	if t := c.Timeouts; t != nil {
+		for path, v := range map[string]time.Duration{
+			"Timeouts.CredentialsRetrieval":  t.CredentialsRetrieval,
+			"Timeouts.AssumeRole":            t.AssumeRole,
+			"Timeouts.CredentialsValidation": t.CredentialsValidation,
+			"Timeouts.AccountIDLookup":       t.AccountIDLookup,
+		} {
+			if v < 0 {
+				add(path, fmt.Errorf("must not be negative, got %s", v))
+			}
+		}
+	}
+
+	if w := c.WatchCredentialFiles; w != nil {
+		if w.Interval < 0 {
+			add("WatchCredentialFiles.Interval", fmt.Errorf("must not be negative, got %s", w.Interval))
+		}
+		if w.Debounce < 0 {
+			add("WatchCredentialFiles.Debounce", fmt.Errorf("must not be negative, got %s", w.Debounce))
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+type addFunc func(path string, err error)
+
+// collectValidationErrors returns the problems reported by f as ValidationErrors aggregated in a multierror.
+func collectValidationErrors(f func(add addFunc)) error {
+	var errs *multierror.Error
+
+	f(func(path string, err error) {
+		errs = multierror.Append(errs, ValidationError{Path: path, Err: err})
+	})
+
+	return errs.ErrorOrNil()
+}
+
+// Validate checks the inputs to sts:AssumeRole against the limits of the API and returns every problem found,
+// so that invalid inputs are reported before calling STS.
+// Each problem is reported as a ValidationError naming the field, e.g. "AssumeRole.Policy".
+func (c AssumeRole) Validate() error {
+	return collectValidationErrors(func(add addFunc) {
+		c.validate(add, "AssumeRole")
+	})
+}
+
+func (c AssumeRole) validate(add addFunc, path string) {
+	validateRoleARN(add, path+".RoleARN", c.RoleARN)
+	validateAssumeRoleDuration(add, path+".Duration", c.Duration)
+	if l := len(c.ExternalID); l > 0 && (l < assumeRoleMinExternalID || l > assumeRoleMaxExternalID || !externalIDRegexp.MatchString(c.ExternalID)) {
+		add(path+".ExternalID", fmt.Errorf("must be between %d and %d characters and contain only alphanumeric characters and +=,.@:/-", assumeRoleMinExternalID, assumeRoleMaxExternalID))
+	}
+	validatePolicy(add, path+".Policy", c.Policy)
+	validatePolicyARNs(add, path+".PolicyARNs", c.PolicyARNs)
+	validateSessionName(add, path+".SessionName", c.SessionName)
+	validateSessionName(add, path+".SourceIdentity", c.SourceIdentity)
+	validateTags(add, path, c.Tags, c.TransitiveTagKeys)
+}
+
+// Validate checks the inputs to sts:AssumeRoleWithWebIdentity against the limits of the API and returns every problem found,
+// so that invalid inputs are reported before calling STS.
+// Each problem is reported as a ValidationError naming the field, e.g. "AssumeRoleWithWebIdentity.Policy".
+// The web identity token is not checked.
+func (c AssumeRoleWithWebIdentity) Validate() error {
+	return collectValidationErrors(func(add addFunc) {
+		c.validate(add, "AssumeRoleWithWebIdentity")
+	})
+}
+
+func (c AssumeRoleWithWebIdentity) validate(add addFunc, path string) {
+	validateRoleARN(add, path+".RoleARN", c.RoleARN)
+	validateAssumeRoleDuration(add, path+".Duration", c.Duration)
+	validatePolicy(add, path+".Policy", c.Policy)
+	validatePolicyARNs(add, path+".PolicyARNs", c.PolicyARNs)
+	validateSessionName(add, path+".SessionName", c.SessionName)
+}
+
+func validateRoleARN(add addFunc, path, v string) {
+	if v == "" {
+		add(path, errors.New("must be set"))
+		return
+	}
+	a, err := arn.Parse(v)
+	if err != nil {
+		add(path, fmt.Errorf("invalid ARN %q: %w", v, err))
+		return
+	}
+	if a.Service != "iam" || !strings.HasPrefix(a.Resource, "role/") {
+		add(path, fmt.Errorf("invalid IAM role ARN %q", v))
+	}
+}
+
+func validateAssumeRoleDuration(add addFunc, path string, v time.Duration) {
+	if v != 0 && (v < assumeRoleMinDuration || v > assumeRoleMaxDuration) {
+		add(path, fmt.Errorf("must be between %s and %s, got %s", assumeRoleMinDuration, assumeRoleMaxDuration, v))
+	}
+}
+
+func validatePolicy(add addFunc, path, v string) {
+	if v == "" {
+		return
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(v)); err != nil {
+		add(path, fmt.Errorf("invalid JSON: %w", err))
+		return
+	}
+	if buf.Len() > assumeRoleMaxPolicyLength {
+		add(path, fmt.Errorf("must be at most %d characters, excluding whitespace, got %d", assumeRoleMaxPolicyLength, buf.Len()))
+	}
+}
+
+func validatePolicyARNs(add addFunc, path string, v []string) {
+	if len(v) > assumeRoleMaxPolicyARNs {
+		add(path, fmt.Errorf("must contain at most %d policies, got %d", assumeRoleMaxPolicyARNs, len(v)))
+	}
+	for i, s := range v {
+		if _, err := arn.Parse(s); err != nil {
+			add(fmt.Sprintf("%s[%d]", path, i), fmt.Errorf("invalid ARN %q: %w", s, err))
+		}
+	}
+}
+
+var errSessionNameInvalid = fmt.Errorf("must be between %d and %d characters and contain only alphanumeric characters and +=,.@-", sessionNameMinLength, sessionNameMaxLength)
+
+// validateSessionName validates a session name or source identity, expanding any template.
+func validateSessionName(add addFunc, path, v string) {
+	if v == "" {
+		return
+	}
+	if _, err := expandSessionName(v); err != nil {
+		add(path, err)
+		return
+	}
+	if !strings.Contains(v, "{{") && !sessionNameRegexp.MatchString(v) {
+		add(path, errSessionNameInvalid)
+	}
+}
+
+func validateTags(add addFunc, path string, tags map[string]string, transitiveTagKeys []string) {
+	if len(tags) > assumeRoleMaxTags {
+		add(path+".Tags", fmt.Errorf("must contain at most %d tags, got %d", assumeRoleMaxTags, len(tags)))
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	// Tag keys are case-insensitive
+	seen := make(map[string]string, len(tags))
+	for _, k := range keys {
+		v := tags[k]
+		tagPath := fmt.Sprintf("%s.Tags[%q]", path, k)
+		if l := len(k); l < 1 || l > assumeRoleMaxTagKeyLength || !tagRegexp.MatchString(k) {
+			add(tagPath, fmt.Errorf("key must be between 1 and %d characters and contain only letters, numbers, spaces and _.:/=+-@", assumeRoleMaxTagKeyLength))
+		}
+		if len(v) > assumeRoleMaxTagValueLength || !tagRegexp.MatchString(v) {
+			add(tagPath, fmt.Errorf("value must be at most %d characters and contain only letters, numbers, spaces and _.:/=+-@", assumeRoleMaxTagValueLength))
+		}
+		if other, ok := seen[strings.ToLower(k)]; ok {
+			add(tagPath, fmt.Errorf("duplicates tag key %q, tag keys are case-insensitive", other))
+		}
+		seen[strings.ToLower(k)] = k
+	}
+
+	for i, k := range transitiveTagKeys {
+		if _, ok := tags[k]; !ok {
+			add(fmt.Sprintf("%s.TransitiveTagKeys[%d]", path, i), fmt.Errorf("tag %q is not set in Tags", k))
+		}
+	}
+}
+
+func validateURL(add addFunc, path, v string) {
+	u, err := url.Parse(v)
+	if err != nil {
+		add(path, fmt.Errorf("invalid URL: %w", err))
+		return
+	}
+	if u.Scheme == "" || u.Host == "" {
+		add(path, fmt.Errorf("invalid URL %q: must include scheme and host", v))
+	}
+}
+
+func validateFileExists(add addFunc, path, v string) {
+	f, err := expand.FilePath(v)
+	if err != nil {
+		add(path, err)
+		return
+	}
+	if _, err := os.Stat(f); err != nil {
+		add(path, err)
+	}
+}
+
+func validateRegion(add addFunc, path, v string) {
+	for _, partition := range endpoints.Partitions() {
+		for _, region := range partition.Regions() {
+			if v == region {
+				return
+			}
+		}
+	}
+	add(path, fmt.Errorf("invalid AWS Region: %s", v))
+}
@@ -10,6 +10,14 @@ const (
 	// User-Agent header for HTTP requests.
 	AppendUserAgentEnvVar = "TF_APPEND_USER_AGENT"
 
+	// HTTPTrafficLogFileEnvVar names a file to which HTTP requests and
+	// responses are written, with sensitive values masked.
+	HTTPTrafficLogFileEnvVar = "TF_AWS_HTTP_TRAFFIC_LOG_FILE"
+
+	// HTTPTrafficLogFormatEnvVar sets the format of the HTTP traffic log file,
+	// either "har" or "jsonl".
+	HTTPTrafficLogFormatEnvVar = "TF_AWS_HTTP_TRAFFIC_LOG_FORMAT"
+
 	// Maximum network retries.
 	// We depend on the AWS Go SDK DefaultRetryer exponential backoff.
 	// Ensure that if the AWS Config MaxRetries is set high (which it is by
@@ -0,0 +1,34 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package detach
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type testKey struct{}
+
+func TestContext(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.WithValue(context.Background(), testKey{}, "value"), time.Minute)
+	cancel()
+
+	ctx := Context(parent)
+
+	if err := ctx.Err(); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("expected no deadline")
+	}
+	select {
+	case <-ctx.Done():
+		t.Error("expected context not to be done")
+	default:
+	}
+	if a, e := ctx.Value(testKey{}), "value"; a != e {
+		t.Errorf("expected value %q, got %v", e, a)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package filecache caches temporary credentials in files in the format of the AWS CLI's credentials cache.
+package filecache
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/invalidate"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+)
+
+const (
+	// ExpiryWindow is the minimum remaining lifetime of cached credentials for them to be used.
+	// It matches the AWS CLI.
+	ExpiryWindow = 15 * time.Minute
+
+	// ProviderTypeSSO is the provider type the AWS CLI records for SSO credentials.
+	ProviderTypeSSO = "sso"
+)
+
+// Cache stores credentials as JSON files in a directory.
+type Cache struct {
+	dir string
+
+	// now is replaced in tests.
+	now func() time.Time
+}
+
+// New returns a Cache that stores files in dir. The directory is created when credentials are first stored.
+func New(dir string) *Cache {
+	return &Cache{
+		dir: dir,
+		now: time.Now,
+	}
+}
+
+// Key returns the cache key for the given arguments. Arguments with empty values are ignored.
+// Keys are the SHA-1 hash of the arguments encoded as compact JSON with sorted keys, as the AWS CLI uses for SSO credentials.
+func Key(args map[string]string) string {
+	m := make(map[string]string, len(args))
+	for k, v := range args {
+		if v != "" {
+			m[k] = v
+		}
+	}
+
+	// SHA-1 is used for compatibility with the AWS CLI, not for security
+	b, _ := json.Marshal(m) // Marshaling a map[string]string cannot fail
+	h := sha1.Sum(b)
+
+	return hex.EncodeToString(h[:])
+}
+
+// Path returns the name of the file that caches credentials for key.
+func (c *Cache) Path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+type entry struct {
+	ProviderType string           `json:"ProviderType,omitempty"`
+	Credentials  entryCredentials `json:"Credentials"`
+}
+
+type entryCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken"`
+	Expiration      string `json:"Expiration"`
+}
+
+// Load returns the credentials cached for key.
+// The second return value is false if there are no cached credentials or they expire within ExpiryWindow.
+func (c *Cache) Load(key string) (aws.Credentials, bool, error) {
+	b, err := os.ReadFile(c.Path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return aws.Credentials{}, false, nil
+	}
+	if err != nil {
+		return aws.Credentials{}, false, err
+	}
+
+	var e entry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return aws.Credentials{}, false, fmt.Errorf("decoding %q: %w", c.Path(key), err)
+	}
+
+	if e.Credentials.AccessKeyID == "" || e.Credentials.SecretAccessKey == "" {
+		return aws.Credentials{}, false, fmt.Errorf("decoding %q: missing credentials", c.Path(key))
+	}
+
+	expires, err := time.Parse(time.RFC3339, e.Credentials.Expiration)
+	if err != nil {
+		return aws.Credentials{}, false, fmt.Errorf("decoding %q: invalid expiration: %w", c.Path(key), err)
+	}
+	if expires.Sub(c.now()) < ExpiryWindow {
+		return aws.Credentials{}, false, nil
+	}
+
+	return aws.Credentials{
+		AccessKeyID:     e.Credentials.AccessKeyID,
+		SecretAccessKey: e.Credentials.SecretAccessKey,
+		SessionToken:    e.Credentials.SessionToken,
+		CanExpire:       true,
+		Expires:         expires,
+	}, true, nil
+}
+
+// Store caches creds for key. Only the owner can read or write the file.
+func (c *Cache) Store(key, providerType string, creds aws.Credentials) error {
+	b, err := json.Marshal(entry{
+		ProviderType: providerType,
+		Credentials: entryCredentials{
+			AccessKeyID:     creds.AccessKeyID,
+			SecretAccessKey: creds.SecretAccessKey,
+			SessionToken:    creds.SessionToken,
+			Expiration:      creds.Expires.UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.dir, 0700); err != nil { //nolint:gomnd
+		return err
+	}
+
+	// Write to a temporary file and rename it so that concurrent readers never see a partial file.
+	f, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	// CreateTemp creates files with mode 0600, but the mode is set explicitly in case that changes.
+	if err := f.Chmod(0600); err != nil { //nolint:gomnd
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), c.Path(key))
+}
+
+// Provider returns a credentials provider that returns the credentials cached for key if they are valid.
+// Otherwise, it retrieves credentials from provider and caches them.
+// Credentials read from the cache have the given source.
+func (c *Cache) Provider(key, providerType, source string, provider aws.CredentialsProvider) aws.CredentialsProvider {
+	return &cachingProvider{
+		cache:        c,
+		key:          key,
+		providerType: providerType,
+		source:       source,
+		provider:     provider,
+	}
+}
+
+type cachingProvider struct {
+	cache        *Cache
+	key          string
+	providerType string
+	source       string
+	provider     aws.CredentialsProvider
+
+	// invalidated is set by Invalidate so that the next retrieval does not use the cached credentials.
+	invalidated int32
+}
+
+func (p *cachingProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	logger := logging.RetrieveLogger(ctx)
+	path := p.cache.Path(p.key)
+
+	var (
+		creds aws.Credentials
+		ok    bool
+		err   error
+	)
+	if atomic.CompareAndSwapInt32(&p.invalidated, 1, 0) {
+		logger.Debug(ctx, "Ignoring invalidated cached credentials", map[string]any{
+			"tf_aws.credentials_file_cache.file": path,
+		})
+	} else {
+		creds, ok, err = p.cache.Load(p.key)
+	}
+	if err != nil {
+		logger.Warn(ctx, "Unable to read cached credentials", map[string]any{
+			"tf_aws.credentials_file_cache.file": path,
+			"error":                              err,
+		})
+	} else if ok {
+		logger.Debug(ctx, "Using cached credentials", map[string]any{
+			"tf_aws.credentials_file_cache.file": path,
+		})
+		creds.Source = p.source
+		return creds, nil
+	}
+
+	creds, err = p.provider.Retrieve(ctx)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+
+	if creds.CanExpire {
+		if err := p.cache.Store(p.key, p.providerType, creds); err != nil {
+			logger.Warn(ctx, "Unable to cache credentials", map[string]any{
+				"tf_aws.credentials_file_cache.file": path,
+				"error":                              err,
+			})
+		} else {
+			logger.Debug(ctx, "Cached credentials", map[string]any{
+				"tf_aws.credentials_file_cache.file": path,
+			})
+		}
+	}
+
+	return creds, nil
+}
+
+// Invalidate causes the next retrieval to retrieve credentials from the underlying provider, which is also invalidated,
+// and to replace the cached credentials.
+func (p *cachingProvider) Invalidate() {
+	atomic.StoreInt32(&p.invalidated, 1)
+
+	invalidate.Credentials(p.provider)
+}
@@ -0,0 +1,217 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package filecache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestKey(t *testing.T) {
+	// Calculated by the AWS CLI for an SSO profile
+	expected := "3a0fc36f5755b230a1f04b9a602090980186b372"
+
+	key := Key(map[string]string{
+		"accountId":   "123456789012",
+		"roleName":    "Role",
+		"startUrl":    "https://example.awsapps.com/start",
+		"sessionName": "",
+	})
+	if key != expected {
+		t.Errorf("expected key %q, got %q", expected, key)
+	}
+}
+
+func TestStoreLoad(t *testing.T) {
+	now := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		Expires       time.Time
+		ExpectedFound bool
+	}{
+		"valid": {
+			Expires:       now.Add(time.Hour),
+			ExpectedFound: true,
+		},
+		"within expiry window": {
+			Expires: now.Add(ExpiryWindow - time.Minute),
+		},
+		"expired": {
+			Expires: now.Add(-time.Minute),
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "cache")
+			c := New(dir)
+			c.now = func() time.Time { return now }
+
+			creds := aws.Credentials{
+				AccessKeyID:     "AKID",
+				SecretAccessKey: "SECRET",
+				SessionToken:    "TOKEN",
+				CanExpire:       true,
+				Expires:         testCase.Expires,
+			}
+			if err := c.Store("key", ProviderTypeSSO, creds); err != nil {
+				t.Fatalf("unexpected Store() error: %s", err)
+			}
+
+			fi, err := os.Stat(c.Path("key"))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if a, e := fi.Mode().Perm(), os.FileMode(0600); a != e {
+				t.Errorf("expected file mode %s, got %s", e, a)
+			}
+
+			value, found, err := c.Load("key")
+			if err != nil {
+				t.Fatalf("unexpected Load() error: %s", err)
+			}
+			if found != testCase.ExpectedFound {
+				t.Fatalf("expected found to be %t, got %t", testCase.ExpectedFound, found)
+			}
+			if found && value != creds {
+				t.Errorf("expected %+v, got %+v", creds, value)
+			}
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	testCases := map[string]struct {
+		Content string
+	}{
+		"not JSON": {
+			Content: "not JSON",
+		},
+		"missing credentials": {
+			Content: `{"Credentials": {"Expiration": "2099-12-31T23:59:59Z"}}`,
+		},
+		"invalid expiration": {
+			Content: `{"Credentials": {"AccessKeyId": "AKID", "SecretAccessKey": "SECRET", "Expiration": "tomorrow"}}`,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			c := New(t.TempDir())
+			if err := os.WriteFile(c.Path("key"), []byte(testCase.Content), 0600); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if _, found, err := c.Load("key"); err == nil || found {
+				t.Errorf("expected error and not found, got %t, %v", found, err)
+			}
+		})
+	}
+}
+
+// TestLoadAWSCLIFormat checks that entries written by the AWS CLI are loaded.
+func TestLoadAWSCLIFormat(t *testing.T) {
+	testCases := map[string]struct {
+		Content string
+	}{
+		"assume role": {
+			Content: `{
+  "Credentials": {
+    "AccessKeyId": "AKID",
+    "SecretAccessKey": "SECRET",
+    "SessionToken": "TOKEN",
+    "Expiration": "2099-12-31T23:59:59+00:00"
+  },
+  "AssumedRoleUser": {
+    "AssumedRoleId": "AROA:session",
+    "Arn": "arn:aws:sts::123456789012:assumed-role/Role/session"
+  },
+  "ResponseMetadata": {}
+}`,
+		},
+		"sso": {
+			Content: `{"ProviderType": "sso", "Credentials": {"AccessKeyId": "AKID", "SecretAccessKey": "SECRET", "SessionToken": "TOKEN", "Expiration": "2099-12-31T23:59:59Z"}}`,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			c := New(t.TempDir())
+			if err := os.WriteFile(c.Path("key"), []byte(testCase.Content), 0600); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			value, found, err := c.Load("key")
+			if err != nil {
+				t.Fatalf("unexpected Load() error: %s", err)
+			}
+			if !found {
+				t.Fatal("expected cached credentials, got none")
+			}
+			if a, e := value.AccessKeyID, "AKID"; a != e {
+				t.Errorf("expected access key %q, got %q", e, a)
+			}
+			if a, e := value.SessionToken, "TOKEN"; a != e {
+				t.Errorf("expected session token %q, got %q", e, a)
+			}
+			if e := time.Date(2099, time.December, 31, 23, 59, 59, 0, time.UTC); !value.Expires.Equal(e) {
+				t.Errorf("expected expiry %s, got %s", e, value.Expires)
+			}
+		})
+	}
+}
+
+func TestProvider(t *testing.T) {
+	c := New(t.TempDir())
+
+	var calls int
+	var err error
+	provider := c.Provider("key", "", "TestProvider", aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		calls++
+		return aws.Credentials{
+			AccessKeyID:     "AKID",
+			SecretAccessKey: "SECRET",
+			CanExpire:       true,
+			Expires:         time.Now().Add(time.Hour),
+			Source:          "TestProvider",
+		}, err
+	}))
+
+	for i := 0; i < 2; i++ {
+		creds, err := provider.Retrieve(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected Retrieve() error: %s", err)
+		}
+		if a, e := creds.Source, "TestProvider"; a != e {
+			t.Errorf("expected source %q, got %q", e, a)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+
+	// Errors are not cached
+	if err := os.Remove(c.Path("key")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = errors.New("failed")
+	if _, err := provider.Retrieve(context.Background()); err == nil {
+		t.Error("expected error, got none")
+	}
+	if _, err := os.Stat(c.Path("key")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no cache file, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package filewatch polls files for changes.
+package filewatch
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// fileState identifies a version of a file.
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func stat(name string) fileState {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{
+		exists:  true,
+		size:    fi.Size(),
+		modTime: fi.ModTime(),
+	}
+}
+
+// Watch polls the files every interval until ctx is done.
+// When files have changed and then remained unchanged for the debounce duration, onChange is called with the names of the changed files.
+// Creating or removing a file is a change.
+func Watch(ctx context.Context, files []string, interval, debounce time.Duration, onChange func(changed []string)) {
+	states := make(map[string]fileState, len(files))
+	for _, f := range files {
+		states[f] = stat(f)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		pending := make(map[string]bool)
+		var lastChange time.Time
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				for _, f := range files {
+					if s := stat(f); s != states[f] {
+						states[f] = s
+						pending[f] = true
+						lastChange = now
+					}
+				}
+
+				if len(pending) == 0 || now.Sub(lastChange) < debounce {
+					continue
+				}
+
+				changed := make([]string, 0, len(pending))
+				for _, f := range files {
+					if pending[f] {
+						changed = append(changed, f)
+					}
+				}
+				pending = make(map[string]bool)
+
+				onChange(changed)
+			}
+		}
+	}()
+}
@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package filewatch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing")
+	created := filepath.Join(dir, "created")
+	unchanged := filepath.Join(dir, "unchanged")
+
+	for _, f := range []string{existing, unchanged} {
+		if err := os.WriteFile(f, []byte("a"), 0600); err != nil {
+			t.Fatalf("writing file: %s", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan []string, 10)
+	Watch(ctx, []string{existing, created, unchanged}, 5*time.Millisecond, 50*time.Millisecond, func(changed []string) {
+		events <- changed
+	})
+
+	// Several writes within the debounce duration produce a single event
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(existing, []byte(strings.Repeat("a", i+2)), 0600); err != nil {
+			t.Fatalf("writing file: %s", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := os.WriteFile(created, []byte("a"), 0600); err != nil {
+		t.Fatalf("writing file: %s", err)
+	}
+
+	select {
+	case changed := <-events:
+		if diff := cmp.Diff([]string{existing, created}, changed); diff != "" {
+			t.Errorf("unexpected changed files difference: %s", diff)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for change")
+	}
+
+	select {
+	case changed := <-events:
+		t.Errorf("unexpected change: %v", changed)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+}
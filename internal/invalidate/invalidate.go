@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package invalidate invalidates cached credentials through every layer of caching.
+package invalidate
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Invalidator is implemented by aws.CredentialsCache and by credentials providers that cache credentials
+// or wrap a provider that does.
+type Invalidator interface {
+	Invalidate()
+}
+
+// wrapped holds the provider wrapped by each aws.CredentialsCache created by NewCredentialsCache,
+// as aws.CredentialsCache does not expose it.
+var wrapped sync.Map
+
+// NewCredentialsCache returns an aws.CredentialsCache wrapping provider.
+// Credentials invalidates provider when it invalidates the returned cache.
+func NewCredentialsCache(provider aws.CredentialsProvider, optFns ...func(*aws.CredentialsCacheOptions)) *aws.CredentialsCache {
+	cache := aws.NewCredentialsCache(provider, optFns...)
+	wrapped.Store(cache, provider)
+
+	return cache
+}
+
+// Credentials invalidates the credentials cached by provider and by the providers that it wraps, innermost first,
+// so that no layer returns the invalidated credentials on the next retrieval.
+func Credentials(provider aws.CredentialsProvider) {
+	if cache, ok := provider.(*aws.CredentialsCache); ok {
+		if inner, ok := wrapped.Load(cache); ok {
+			Credentials(inner.(aws.CredentialsProvider))
+		}
+	}
+
+	if i, ok := provider.(Invalidator); ok {
+		i.Invalidate()
+	}
+}
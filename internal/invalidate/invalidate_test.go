@@ -0,0 +1,53 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package invalidate
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestCredentials(t *testing.T) {
+	var calls int32
+	inner := aws.NewCredentialsCache(aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		n := atomic.AddInt32(&calls, 1)
+		return aws.Credentials{
+			AccessKeyID:     fmt.Sprintf("AKID%d", n),
+			SecretAccessKey: "secret",
+		}, nil
+	}))
+	outer := NewCredentialsCache(inner)
+
+	ctx := context.Background()
+	creds, err := outer.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := creds.AccessKeyID, "AKID1"; a != e {
+		t.Fatalf("expected access key %q, got %q", e, a)
+	}
+
+	// Invalidating only the outer cache returns the credentials still cached by the inner cache
+	outer.Invalidate()
+	creds, err = outer.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := creds.AccessKeyID, "AKID1"; a != e {
+		t.Fatalf("expected access key %q, got %q", e, a)
+	}
+
+	Credentials(outer)
+	creds, err = outer.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if a, e := creds.AccessKeyID, "AKID2"; a != e {
+		t.Errorf("expected access key %q, got %q", e, a)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logfilter
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/config"
+)
+
+// Filter decides which HTTP requests and responses are written to the debug log.
+// A nil Filter logs every request with bodies.
+type Filter struct {
+	config   config.DebugLogFilter
+	counters []uint64
+}
+
+// Decision is the result of evaluating a Filter for a request.
+type Decision struct {
+	// Log is true if the request and response should be logged.
+	Log bool
+
+	// BodiesOnErrorOnly is true if request and response bodies should only be logged for error responses.
+	BodiesOnErrorOnly bool
+}
+
+// New returns a Filter for the configuration, or nil if c is nil.
+func New(c *config.DebugLogFilter) (*Filter, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	for i, rule := range c.Rules {
+		if rule.SampleRate < 0 {
+			return nil, fmt.Errorf("debug log filter rule %d: sample rate must not be negative, got %d", i, rule.SampleRate)
+		}
+	}
+
+	return &Filter{
+		config:   *c,
+		counters: make([]uint64, len(c.Rules)),
+	}, nil
+}
+
+// Evaluate returns the logging decision for a request to the service and operation.
+func (f *Filter) Evaluate(service, operation string) Decision {
+	if f == nil {
+		return Decision{Log: true}
+	}
+
+	for i, rule := range f.config.Rules {
+		if !matches(rule.Service, service) || !matches(rule.Operation, operation) {
+			continue
+		}
+
+		if rule.Exclude {
+			return Decision{}
+		}
+
+		return Decision{
+			Log:               f.sample(i, rule.SampleRate),
+			BodiesOnErrorOnly: f.config.BodiesOnErrorOnly || rule.BodiesOnErrorOnly,
+		}
+	}
+
+	return Decision{
+		Log:               !f.config.ExcludeUnmatched,
+		BodiesOnErrorOnly: f.config.BodiesOnErrorOnly,
+	}
+}
+
+// sample returns true for the first of every `rate` requests matching rule `i`.
+func (f *Filter) sample(i, rate int) bool {
+	if rate <= 1 {
+		return true
+	}
+	n := atomic.AddUint64(&f.counters[i], 1)
+	return (n-1)%uint64(rate) == 0
+}
+
+func matches(pattern, value string) bool {
+	return pattern == "" || strings.EqualFold(pattern, value)
+}
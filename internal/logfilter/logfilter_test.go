@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logfilter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/config"
+)
+
+func TestEvaluate(t *testing.T) {
+	type request struct {
+		Service, Operation string
+	}
+
+	testCases := map[string]struct {
+		Config   *config.DebugLogFilter
+		Requests []request
+		Expected []Decision
+	}{
+		"nil filter": {
+			Requests: []request{{"EC2", "DescribeInstances"}},
+			Expected: []Decision{{Log: true}},
+		},
+		"no rules": {
+			Config:   &config.DebugLogFilter{},
+			Requests: []request{{"EC2", "DescribeInstances"}},
+			Expected: []Decision{{Log: true}},
+		},
+		"exclude service": {
+			Config: &config.DebugLogFilter{
+				Rules: []config.DebugLogRule{
+					{Service: "EC2", Exclude: true},
+				},
+			},
+			Requests: []request{{"EC2", "DescribeInstances"}, {"S3", "ListBuckets"}},
+			Expected: []Decision{{Log: false}, {Log: true}},
+		},
+		"include operation only": {
+			Config: &config.DebugLogFilter{
+				Rules: []config.DebugLogRule{
+					{Service: "ec2", Operation: "RunInstances"},
+				},
+				ExcludeUnmatched: true,
+			},
+			Requests: []request{{"EC2", "RunInstances"}, {"EC2", "DescribeInstances"}},
+			Expected: []Decision{{Log: true}, {Log: false}},
+		},
+		"first matching rule wins": {
+			Config: &config.DebugLogFilter{
+				Rules: []config.DebugLogRule{
+					{Service: "EC2", Operation: "RunInstances"},
+					{Service: "EC2", Exclude: true},
+				},
+			},
+			Requests: []request{{"EC2", "RunInstances"}, {"EC2", "DescribeInstances"}},
+			Expected: []Decision{{Log: true}, {Log: false}},
+		},
+		"sampling": {
+			Config: &config.DebugLogFilter{
+				Rules: []config.DebugLogRule{
+					{Operation: "DescribeInstances", SampleRate: 3},
+				},
+			},
+			Requests: []request{
+				{"EC2", "DescribeInstances"},
+				{"EC2", "DescribeInstances"},
+				{"EC2", "RunInstances"},
+				{"EC2", "DescribeInstances"},
+				{"EC2", "DescribeInstances"},
+			},
+			Expected: []Decision{{Log: true}, {Log: false}, {Log: true}, {Log: false}, {Log: true}},
+		},
+		"bodies on error only": {
+			Config: &config.DebugLogFilter{
+				Rules: []config.DebugLogRule{
+					{Service: "EC2", BodiesOnErrorOnly: true},
+				},
+			},
+			Requests: []request{{"EC2", "DescribeInstances"}, {"S3", "ListBuckets"}},
+			Expected: []Decision{{Log: true, BodiesOnErrorOnly: true}, {Log: true}},
+		},
+		"bodies on error only globally": {
+			Config: &config.DebugLogFilter{
+				Rules: []config.DebugLogRule{
+					{Service: "EC2"},
+				},
+				BodiesOnErrorOnly: true,
+			},
+			Requests: []request{{"EC2", "DescribeInstances"}, {"S3", "ListBuckets"}},
+			Expected: []Decision{{Log: true, BodiesOnErrorOnly: true}, {Log: true, BodiesOnErrorOnly: true}},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			filter, err := New(testCase.Config)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var decisions []Decision
+			for _, r := range testCase.Requests {
+				decisions = append(decisions, filter.Evaluate(r.Service, r.Operation))
+			}
+
+			if diff := cmp.Diff(decisions, testCase.Expected); diff != "" {
+				t.Errorf("unexpected decisions: (- got, + expected)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNew_negativeSampleRate(t *testing.T) {
+	_, err := New(&config.DebugLogFilter{
+		Rules: []config.DebugLogRule{
+			{SampleRate: -1},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/unit"
+)
+
+const instrumentationName = "github.com/hashicorp/aws-sdk-go-base/v2"
+
+const (
+	CallDurationName    = "aws.api.call.duration"
+	AttemptDurationName = "aws.api.attempt.duration"
+	AttemptsName        = "aws.api.attempts"
+	ThrottlesName       = "aws.api.throttles"
+	ErrorsName          = "aws.api.errors"
+)
+
+// UnknownErrorCode is the error code recorded for errors without an AWS error code, such as network errors.
+const UnknownErrorCode = "Unknown"
+
+// Recorder records AWS API call metrics.
+// A nil Recorder is valid and records nothing.
+type Recorder struct {
+	callDuration instrument.Float64Histogram
+	duration     instrument.Float64Histogram
+	attempts     instrument.Int64Counter
+	throttles    instrument.Int64Counter
+	errors       instrument.Int64Counter
+}
+
+// New returns a Recorder using the given MeterProvider.
+// If provider is nil, New returns a nil Recorder.
+func New(provider metric.MeterProvider) (*Recorder, error) {
+	if provider == nil {
+		return nil, nil
+	}
+
+	meter := provider.Meter(instrumentationName)
+
+	callDuration, err := meter.Float64Histogram(CallDurationName,
+		instrument.WithDescription("Duration of AWS API calls, including all attempts"),
+		instrument.WithUnit(unit.Milliseconds),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating %q instrument: %w", CallDurationName, err)
+	}
+
+	duration, err := meter.Float64Histogram(AttemptDurationName,
+		instrument.WithDescription("Duration of individual AWS API call attempts"),
+		instrument.WithUnit(unit.Milliseconds),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating %q instrument: %w", AttemptDurationName, err)
+	}
+
+	attempts, err := meter.Int64Counter(AttemptsName,
+		instrument.WithDescription("Number of AWS API call attempts, including retries"),
+		instrument.WithUnit(unit.Dimensionless),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating %q instrument: %w", AttemptsName, err)
+	}
+
+	throttles, err := meter.Int64Counter(ThrottlesName,
+		instrument.WithDescription("Number of AWS API call attempts rejected due to throttling"),
+		instrument.WithUnit(unit.Dimensionless),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating %q instrument: %w", ThrottlesName, err)
+	}
+
+	errors, err := meter.Int64Counter(ErrorsName,
+		instrument.WithDescription("Number of AWS API call attempts returning an error, by error code"),
+		instrument.WithUnit(unit.Dimensionless),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating %q instrument: %w", ErrorsName, err)
+	}
+
+	return &Recorder{
+		callDuration: callDuration,
+		duration:     duration,
+		attempts:     attempts,
+		throttles:    throttles,
+		errors:       errors,
+	}, nil
+}
+
+// Call describes an AWS API call, including all of its attempts.
+type Call struct {
+	Service   string
+	Operation string
+	Region    string
+	Duration  time.Duration
+}
+
+// RecordCall records the duration of an AWS API call.
+func (r *Recorder) RecordCall(ctx context.Context, c Call) {
+	if r == nil {
+		return
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("aws.service", c.Service),
+		attribute.String("aws.operation", c.Operation),
+		attribute.String("aws.region", c.Region),
+	}
+
+	r.callDuration.Record(ctx, float64(c.Duration)/float64(time.Millisecond), attrs...)
+}
+
+// Attempt describes a single AWS API call attempt.
+type Attempt struct {
+	Service    string
+	Operation  string
+	Region     string
+	Duration   time.Duration
+	StatusCode int
+	// ErrorCode is the AWS error code, if any.
+	ErrorCode string
+	// Failed is set when the attempt returned an error, whether or not it has an error code.
+	Failed    bool
+	Throttled bool
+}
+
+func (a Attempt) attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("aws.service", a.Service),
+		attribute.String("aws.operation", a.Operation),
+		attribute.String("aws.region", a.Region),
+	}
+}
+
+// RecordAttempt records the measurements for a single AWS API call attempt.
+func (r *Recorder) RecordAttempt(ctx context.Context, a Attempt) {
+	if r == nil {
+		return
+	}
+
+	attrs := a.attributes()
+
+	r.attempts.Add(ctx, 1, attrs...)
+	r.duration.Record(ctx, float64(a.Duration)/float64(time.Millisecond), attrs...)
+
+	if a.Throttled {
+		r.throttles.Add(ctx, 1, attrs...)
+	}
+
+	if a.Failed || a.ErrorCode != "" {
+		errorCode := a.ErrorCode
+		if errorCode == "" {
+			errorCode = UnknownErrorCode
+		}
+		errorAttrs := append(attrs, attribute.String("aws.error_code", errorCode))
+		if a.StatusCode != 0 {
+			errorAttrs = append(errorAttrs, attribute.Int("http.status_code", a.StatusCode))
+		}
+		r.errors.Add(ctx, 1, errorAttrs...)
+	}
+}
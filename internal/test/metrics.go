@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package test
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+)
+
+// Measurement is a single value recorded by a MeterProvider instrument.
+type Measurement struct {
+	Instrument string
+	Value      float64
+	Attributes map[string]any
+}
+
+// MeterProvider is a metric.MeterProvider that records the measurements made by
+// synchronous counters and histograms.
+type MeterProvider struct {
+	mu           sync.Mutex
+	measurements []Measurement
+}
+
+func (p *MeterProvider) Meter(string, ...metric.MeterOption) metric.Meter {
+	return &meter{
+		Meter:    metric.NewNoopMeter(),
+		provider: p,
+	}
+}
+
+// Measurements returns the measurements recorded by the named instrument.
+func (p *MeterProvider) Measurements(name string) []Measurement {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var result []Measurement
+	for _, m := range p.measurements {
+		if m.Instrument == name {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func (p *MeterProvider) record(name string, v float64, attrs []attribute.KeyValue) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m := Measurement{
+		Instrument: name,
+		Value:      v,
+		Attributes: make(map[string]any, len(attrs)),
+	}
+	for _, attr := range attrs {
+		m.Attributes[string(attr.Key)] = attr.Value.AsInterface()
+	}
+	p.measurements = append(p.measurements, m)
+}
+
+type meter struct {
+	metric.Meter
+	provider *MeterProvider
+}
+
+func (m *meter) Int64Counter(name string, _ ...instrument.Int64Option) (instrument.Int64Counter, error) {
+	return &int64Instrument{name: name, provider: m.provider}, nil
+}
+
+func (m *meter) Float64Histogram(name string, _ ...instrument.Float64Option) (instrument.Float64Histogram, error) {
+	return &float64Instrument{name: name, provider: m.provider}, nil
+}
+
+type int64Instrument struct {
+	instrument.Synchronous
+	name     string
+	provider *MeterProvider
+}
+
+func (i *int64Instrument) Add(_ context.Context, incr int64, attrs ...attribute.KeyValue) {
+	i.provider.record(i.name, float64(incr), attrs)
+}
+
+type float64Instrument struct {
+	instrument.Synchronous
+	name     string
+	provider *MeterProvider
+}
+
+func (i *float64Instrument) Record(_ context.Context, v float64, attrs ...attribute.KeyValue) {
+	i.provider.record(i.name, v, attrs)
+}
@@ -6,6 +6,7 @@ package awsbase
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -15,9 +16,11 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go"
 	smithylogging "github.com/aws/smithy-go/logging"
 	"github.com/aws/smithy-go/middleware"
 	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/logfilter"
 	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/semconv/v1.17.0/httpconv"
@@ -53,7 +56,13 @@ func (l debugLogger) WithContext(ctx context.Context) smithylogging.Logger {
 // We want access to the request and response structs, and cannot get it from the built-in.
 // The typical route of adding logging to the http.RoundTripper doesn't work for the AWS SDK for Go v2 without forcing us to manually implement
 // configuration that the SDK handles for us.
+//
+// The response is decomposed here, before the operation deserializer reads the body, and logged by responseLogger
+// once API errors have been deserialized.
 type requestResponseLogger struct {
+	suppressLog bool
+	filter      *logfilter.Filter
+	trafficLog  *logging.TrafficLog
 }
 
 // ID is the middleware identifier.
@@ -67,39 +76,54 @@ func (r *requestResponseLogger) HandleDeserialize(ctx context.Context, in middle
 ) {
 	logger := logging.RetrieveLogger(ctx)
 
-	ctx = logger.SetField(ctx, "aws.sdk", "aws-sdk-go-v2")
-	ctx = logger.SetField(ctx, "aws.service", awsmiddleware.GetServiceID(ctx))
-	ctx = logger.SetField(ctx, "aws.operation", awsmiddleware.GetOperationName(ctx))
-
-	region := awsmiddleware.GetRegion(ctx)
-	ctx = logger.SetField(ctx, "aws.region", region)
-
-	if signingRegion := awsmiddleware.GetSigningRegion(ctx); signingRegion != region {
-		ctx = logger.SetField(ctx, "aws.signing_region", signingRegion)
-	}
-
-	if awsmiddleware.GetEndpointSource(ctx) == aws.EndpointSourceCustom {
-		ctx = logger.SetField(ctx, "aws.custom_endpoint_source", true)
-	}
+	ctx = setAWSLogFields(ctx)
 
 	smithyRequest, ok := in.Request.(*smithyhttp.Request)
 	if !ok {
 		return out, metadata, fmt.Errorf("unknown request type %T", in.Request)
 	}
 
-	rc := smithyRequest.Build(ctx)
-
-	requestFields, err := logging.DecomposeHTTPRequest(rc)
-	if err != nil {
-		return out, metadata, fmt.Errorf("decomposing request: %w", err)
+	decision, ok := getLogDecision(ctx)
+	if !ok {
+		decision = r.filter.Evaluate(awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx))
 	}
-	logger.Debug(ctx, "HTTP Request Sent", requestFields)
+	logEnabled := !r.suppressLog && decision.Log
+
+	var trafficEntry *logging.TrafficEntry
+	var requestBody any
+
+	if logEnabled || r.trafficLog != nil {
+		rc := smithyRequest.Build(ctx)
+
+		if logEnabled {
+			requestFields, err := logging.DecomposeHTTPRequest(rc)
+			if err != nil {
+				return out, metadata, fmt.Errorf("decomposing request: %w", err)
+			}
+			if decision.BodiesOnErrorOnly {
+				// Deferred until the response status is known
+				requestBody = requestFields[requestBodyField]
+				delete(requestFields, requestBodyField)
+			}
+			logger.Debug(ctx, "HTTP Request Sent", requestFields)
+		}
 
-	smithyRequest, err = smithyRequest.SetStream(rc.Body)
-	if err != nil {
-		return out, metadata, err
+		if r.trafficLog != nil {
+			trafficEntry, err = logging.NewTrafficEntry(rc, time.Now())
+			if err != nil {
+				return out, metadata, fmt.Errorf("recording request: %w", err)
+			}
+			trafficEntry.AWSService = awsmiddleware.GetServiceID(ctx)
+			trafficEntry.AWSOperation = awsmiddleware.GetOperationName(ctx)
+			trafficEntry.AWSRegion = awsmiddleware.GetRegion(ctx)
+		}
+
+		smithyRequest, err = smithyRequest.SetStream(rc.Body)
+		if err != nil {
+			return out, metadata, err
+		}
+		in.Request = smithyRequest
 	}
-	in.Request = smithyRequest
 
 	start := time.Now()
 
@@ -107,22 +131,215 @@ func (r *requestResponseLogger) HandleDeserialize(ctx context.Context, in middle
 
 	elapsed := time.Since(start)
 
-	if err == nil {
-		smithyResponse, ok := out.RawResponse.(*smithyhttp.Response)
-		if !ok {
-			return out, metadata, fmt.Errorf("unknown response type: %T", out.RawResponse)
+	setAttemptDuration(&metadata, elapsed)
+
+	attempt, hasAttempt := logging.RequestAttempt(smithyRequest.Header)
+
+	if err != nil {
+		if trafficEntry != nil {
+			trafficEntry.SetError(err, elapsed)
+			r.writeTrafficEntry(ctx, trafficEntry)
 		}
+		if logEnabled {
+			fields := map[string]any{
+				"http.duration": elapsed.Milliseconds(),
+			}
+			if hasAttempt {
+				fields[attemptField] = attempt
+			}
+			if requestBody != nil {
+				fields[requestBodyField] = requestBody
+			}
+			setResponseLog(&metadata, responseLog{fields: fields, failed: true})
+		}
+		return out, metadata, err
+	}
+
+	if !logEnabled && trafficEntry == nil {
+		return out, metadata, err
+	}
 
+	smithyResponse, ok := out.RawResponse.(*smithyhttp.Response)
+	if !ok {
+		return out, metadata, fmt.Errorf("unknown response type: %T", out.RawResponse)
+	}
+
+	if logEnabled {
 		responseFields, err := decomposeHTTPResponse(smithyResponse.Response, elapsed)
 		if err != nil {
 			return out, metadata, fmt.Errorf("decomposing response: %w", err)
 		}
-		logger.Debug(ctx, "HTTP Response Received", responseFields)
+		if decision.BodiesOnErrorOnly {
+			filterBodies(responseFields, smithyResponse.StatusCode, requestBody)
+		}
+		if hasAttempt {
+			responseFields[attemptField] = attempt
+		}
+		setResponseLog(&metadata, responseLog{fields: responseFields})
+	}
+
+	if trafficEntry != nil {
+		body, err := readResponseBody(smithyResponse.Response)
+		if err != nil {
+			return out, metadata, fmt.Errorf("recording response: %w", err)
+		}
+		trafficEntry.SetResponse(smithyResponse.Response, body, elapsed)
+		r.writeTrafficEntry(ctx, trafficEntry)
 	}
 
 	return out, metadata, err
 }
 
+func (r *requestResponseLogger) writeTrafficEntry(ctx context.Context, entry *logging.TrafficEntry) {
+	if err := r.trafficLog.Write(entry); err != nil {
+		logger := logging.RetrieveLogger(ctx)
+		logger.Warn(ctx, "Unable to write HTTP traffic log entry", map[string]any{
+			"error": err,
+		})
+	}
+}
+
+const (
+	requestBodyField  = "http.request.body"
+	responseBodyField = "http.response.body"
+	attemptField      = "aws.attempt"
+)
+
+type logDecisionKey struct{}
+
+func getLogDecision(ctx context.Context) (logfilter.Decision, bool) {
+	v, ok := middleware.GetStackValue(ctx, logDecisionKey{}).(logfilter.Decision)
+	return v, ok
+}
+
+// logDecider evaluates the debug log filter once per API call, so that all attempts of a call are logged,
+// or not, together. It is added at the end of the Initialize step, after the service metadata has been registered.
+type logDecider struct {
+	filter *logfilter.Filter
+}
+
+// ID is the middleware identifier.
+func (d *logDecider) ID() string {
+	return "TF_AWS_LogDecider"
+}
+
+func (d *logDecider) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+) (
+	out middleware.InitializeOutput, metadata middleware.Metadata, err error,
+) {
+	decision := d.filter.Evaluate(awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx))
+	ctx = middleware.WithStackValue(ctx, logDecisionKey{}, decision)
+
+	return next.HandleInitialize(ctx, in)
+}
+
+// responseLogger logs the response, or the failure to receive one, for each attempt.
+// It is added at the front of the Deserialize step so that API errors have already been deserialized
+// and can be added to the fields captured by requestResponseLogger.
+type responseLogger struct{}
+
+// ID is the middleware identifier.
+func (r *responseLogger) ID() string {
+	return "TF_AWS_ResponseLogger"
+}
+
+func (r *responseLogger) HandleDeserialize(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler,
+) (
+	out middleware.DeserializeOutput, metadata middleware.Metadata, err error,
+) {
+	out, metadata, err = next.HandleDeserialize(ctx, in)
+
+	l, ok := getResponseLog(metadata)
+	if !ok {
+		return out, metadata, err
+	}
+
+	ctx = setAWSLogFields(ctx)
+	logger := logging.RetrieveLogger(ctx)
+
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) {
+			l.fields["aws.error_code"] = apiErr.ErrorCode()
+			l.fields["aws.error_message"] = logging.MaskAWSAccessKey(apiErr.ErrorMessage())
+		} else {
+			l.fields["aws.error_message"] = logging.MaskAWSAccessKey(err.Error())
+		}
+	}
+
+	if l.failed {
+		logger.Debug(ctx, "HTTP Request Failed", l.fields)
+	} else {
+		logger.Debug(ctx, "HTTP Response Received", l.fields)
+	}
+
+	return out, metadata, err
+}
+
+// setAWSLogFields adds the fields identifying the API call to the logger.
+func setAWSLogFields(ctx context.Context) context.Context {
+	logger := logging.RetrieveLogger(ctx)
+
+	ctx = logger.SetField(ctx, "aws.sdk", "aws-sdk-go-v2")
+	ctx = logger.SetField(ctx, "aws.service", awsmiddleware.GetServiceID(ctx))
+	ctx = logger.SetField(ctx, "aws.operation", awsmiddleware.GetOperationName(ctx))
+
+	region := awsmiddleware.GetRegion(ctx)
+	ctx = logger.SetField(ctx, "aws.region", region)
+
+	if signingRegion := awsmiddleware.GetSigningRegion(ctx); signingRegion != region {
+		ctx = logger.SetField(ctx, "aws.signing_region", signingRegion)
+	}
+
+	if awsmiddleware.GetEndpointSource(ctx) == aws.EndpointSourceCustom {
+		ctx = logger.SetField(ctx, "aws.custom_endpoint_source", true)
+	}
+
+	return ctx
+}
+
+type responseLogKey struct{}
+
+// responseLog holds the response log fields captured for the current attempt.
+type responseLog struct {
+	fields map[string]any
+	failed bool
+}
+
+func setResponseLog(metadata *middleware.Metadata, l responseLog) {
+	metadata.Set(responseLogKey{}, l)
+}
+
+func getResponseLog(metadata middleware.Metadata) (responseLog, bool) {
+	v, ok := metadata.Get(responseLogKey{}).(responseLog)
+	return v, ok
+}
+
+// filterBodies removes the response body from the response log fields unless the response is an error.
+// For error responses, the request body that was withheld from the request log is added instead.
+func filterBodies(responseFields map[string]any, statusCode int, requestBody any) {
+	if statusCode < http.StatusBadRequest {
+		delete(responseFields, responseBodyField)
+		return
+	}
+	if requestBody != nil {
+		responseFields[requestBodyField] = requestBody
+	}
+}
+
+type attemptDurationKey struct{}
+
+// setAttemptDuration stores the HTTP duration of the current attempt so that middleware
+// further out in the stack, such as attemptMetricsRecorder, can report it.
+func setAttemptDuration(metadata *middleware.Metadata, elapsed time.Duration) {
+	metadata.Set(attemptDurationKey{}, elapsed)
+}
+
+func getAttemptDuration(metadata middleware.Metadata) (time.Duration, bool) {
+	v, ok := metadata.Get(attemptDurationKey{}).(time.Duration)
+	return v, ok
+}
+
 func decomposeHTTPResponse(resp *http.Response, elapsed time.Duration) (map[string]any, error) {
 	var attributes []attribute.KeyValue
 
@@ -132,6 +349,8 @@ func decomposeHTTPResponse(resp *http.Response, elapsed time.Duration) (map[stri
 
 	attributes = append(attributes, logging.DecomposeResponseHeaders(resp)...)
 
+	attributes = append(attributes, logging.DecomposeResponseRequestIDs(resp)...)
+
 	bodyAttribute, err := decomposeResponseBody(resp)
 	if err != nil {
 		return nil, err
@@ -147,15 +366,24 @@ func decomposeHTTPResponse(resp *http.Response, elapsed time.Duration) (map[stri
 }
 
 func decomposeResponseBody(resp *http.Response) (attribute.KeyValue, error) {
-	respBytes, err := io.ReadAll(resp.Body)
+	respBytes, err := readResponseBody(resp)
 	if err != nil {
 		return attribute.KeyValue{}, err
 	}
 
 	body := logging.MaskAWSAccessKey(string(respBytes))
 
-	// Restore the body reader
+	return attribute.String(responseBodyField, body), nil
+}
+
+// readResponseBody reads the response body and restores the body reader
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
 	resp.Body = io.NopCloser(bytes.NewBuffer(respBytes))
 
-	return attribute.String("http.response.body", body), nil
+	return respBytes, nil
 }
@@ -180,22 +180,55 @@ func authorizationHeaderAttribute(v string) (attribute.KeyValue, bool) {
 }
 
 func resendCountAttribute(v string) (kv attribute.KeyValue, ok bool) {
-	re := regexp.MustCompile(`attempt=(\d+);`)
-	match := re.FindStringSubmatch(v)
-	if len(match) != 2 { //nolint:gomnd
+	attempt, ok := parseAttempt(v)
+	if !ok {
 		return
 	}
 
+	if attempt > 1 {
+		return attribute.Int("http.resend_count", attempt), true
+	}
+
+	return kv, false
+}
+
+var attemptRegexp = regexp.MustCompile(`attempt=(\d+);`)
+
+func parseAttempt(v string) (int, bool) {
+	match := attemptRegexp.FindStringSubmatch(v)
+	if len(match) != 2 { //nolint:gomnd
+		return 0, false
+	}
+
 	attempt, err := strconv.Atoi(match[1])
 	if err != nil {
-		return
+		return 0, false
 	}
 
-	if attempt > 1 {
-		return attribute.Int("http.resend_count", attempt), true
+	return attempt, true
+}
+
+// RequestAttempt returns the attempt number from the `amz-sdk-request` header added by the AWS SDK for Go v2.
+func RequestAttempt(header http.Header) (int, bool) {
+	return parseAttempt(header.Get("Amz-Sdk-Request"))
+}
+
+// DecomposeResponseRequestIDs returns the AWS request ID and, for Amazon S3, the extended request ID of a response.
+func DecomposeResponseRequestIDs(resp *http.Response) []attribute.KeyValue {
+	var results []attribute.KeyValue
+
+	for _, name := range []string{"X-Amzn-Requestid", "X-Amz-Request-Id"} {
+		if v := resp.Header.Get(name); v != "" {
+			results = append(results, attribute.String("aws.request_id", v))
+			break
+		}
 	}
 
-	return
+	if v := resp.Header.Get("X-Amz-Id-2"); v != "" {
+		results = append(results, attribute.String("aws.extended_request_id", v))
+	}
+
+	return results
 }
 
 func DecomposeResponseHeaders(resp *http.Response) []attribute.KeyValue {
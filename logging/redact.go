@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const redacted = "*****"
+
+// secretFields are the request and response fields containing credentials or tokens,
+// such as those of STS, SSO and SSO OIDC.
+var secretFields = []string{
+	"AccessToken",
+	"ClientSecret",
+	"RefreshToken",
+	"SAMLAssertion",
+	"SecretAccessKey",
+	"SessionToken",
+	"TokenCode",
+	"WebIdentityToken",
+}
+
+var (
+	secretXMLElementRegexp = regexp.MustCompile(`(?is)(<(` + strings.Join(secretFields, "|") + `)>)(.*?)(</(?:` + strings.Join(secretFields, "|") + `)>)`)
+	secretJSONFieldRegexp  = regexp.MustCompile(`(?i)("(?:` + strings.Join(secretFields, "|") + `)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+)
+
+// isSecretField reports whether name, compared case-insensitively, is a field containing credentials or tokens.
+func isSecretField(name string) bool {
+	for _, v := range secretFields {
+		if strings.EqualFold(name, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactSecrets replaces the values of fields containing credentials or tokens in an XML, JSON or
+// form-encoded body.
+func redactSecrets(body string) string {
+	body = secretXMLElementRegexp.ReplaceAllString(body, "${1}"+redacted+"${4}")
+	body = secretJSONFieldRegexp.ReplaceAllString(body, `${1}"`+redacted+`"`)
+
+	if strings.ContainsAny(body, "<{") {
+		return body
+	}
+	values, err := url.ParseQuery(body)
+	if err != nil {
+		return body
+	}
+	found := false
+	for k := range values {
+		if isSecretField(k) {
+			values[k] = []string{redacted}
+			found = true
+		}
+	}
+	if !found {
+		return body
+	}
+	return values.Encode()
+}
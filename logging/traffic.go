@@ -0,0 +1,435 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+const (
+	// TrafficLogFormatHAR writes an HTTP Archive (HAR) 1.2 file.
+	TrafficLogFormatHAR = "har"
+
+	// TrafficLogFormatJSONLines writes one HAR entry per line.
+	TrafficLogFormatJSONLines = "jsonl"
+)
+
+const harTrailer = "]}}\n"
+
+// TrafficLog writes HTTP request and response pairs to a file.
+// Sensitive values are masked in the same way as in the request and response logs,
+// and credentials and tokens in request and response bodies are redacted.
+type TrafficLog struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	format  string
+	entries int
+}
+
+var (
+	trafficLogsMu sync.Mutex
+	trafficLogs   = make(map[string]*TrafficLog)
+)
+
+// OpenTrafficLog returns the TrafficLog writing to path in the given format.
+// Each path is only opened once per process, so that all clients configured with the same path share the file.
+// If format is empty, the HAR format is used for files with a `.har` extension, otherwise JSON lines.
+func OpenTrafficLog(path, format string) (*TrafficLog, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolving HTTP traffic log file: %w", err)
+	}
+
+	if format == "" {
+		if strings.EqualFold(filepath.Ext(path), ".har") {
+			format = TrafficLogFormatHAR
+		} else {
+			format = TrafficLogFormatJSONLines
+		}
+	}
+
+	trafficLogsMu.Lock()
+	defer trafficLogsMu.Unlock()
+
+	if l, ok := trafficLogs[path]; ok {
+		if l.format != format {
+			return nil, fmt.Errorf("HTTP traffic log file (%s) is already open with format %q", path, l.format)
+		}
+		return l, nil
+	}
+
+	var file *os.File
+	switch format {
+	case TrafficLogFormatHAR:
+		file, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600) //nolint:gomnd
+		if err == nil {
+			_, err = fmt.Fprintf(file, `{"log":{"version":"1.2","creator":{"name":"aws-sdk-go-base","version":"v2"},"entries":[%s`, harTrailer)
+		}
+	case TrafficLogFormatJSONLines:
+		file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600) //nolint:gomnd
+	default:
+		return nil, fmt.Errorf("unsupported HTTP traffic log format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening HTTP traffic log file (%s): %w", path, err)
+	}
+
+	l := &TrafficLog{
+		path:   path,
+		file:   file,
+		format: format,
+	}
+	trafficLogs[path] = l
+
+	return l, nil
+}
+
+// Close closes the traffic log file. Entries written after Close are discarded.
+// A later call to OpenTrafficLog with the same path opens the file again.
+// A nil TrafficLog is valid.
+func (l *TrafficLog) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	trafficLogsMu.Lock()
+	if trafficLogs[l.path] == l {
+		delete(trafficLogs, l.path)
+	}
+	trafficLogsMu.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// CloseTrafficLogs closes all traffic logs opened by OpenTrafficLog.
+// It is typically called when the process is shutting down.
+func CloseTrafficLogs() error {
+	trafficLogsMu.Lock()
+	logs := make([]*TrafficLog, 0, len(trafficLogs))
+	for _, l := range trafficLogs {
+		logs = append(logs, l)
+	}
+	trafficLogsMu.Unlock()
+
+	var errs *multierror.Error
+	for _, l := range logs {
+		if err := l.Close(); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("closing HTTP traffic log file (%s): %w", l.path, err))
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// Write appends an entry to the traffic log.
+// A nil TrafficLog is valid and writes nothing.
+func (l *TrafficLog) Write(entry *TrafficEntry) error {
+	if l == nil || entry == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	switch l.format {
+	case TrafficLogFormatHAR:
+		// Overwrite the closing brackets of the entries array and the enclosing objects
+		if _, err := l.file.Seek(-int64(len(harTrailer)), io.SeekEnd); err != nil {
+			return err
+		}
+		if l.entries > 0 {
+			if _, err := l.file.WriteString(","); err != nil {
+				return err
+			}
+		}
+		if _, err := l.file.Write(b); err != nil {
+			return err
+		}
+		if _, err := l.file.WriteString(harTrailer); err != nil {
+			return err
+		}
+	default:
+		b = append(b, '\n')
+		if _, err := l.file.Write(b); err != nil {
+			return err
+		}
+	}
+	l.entries++
+
+	return nil
+}
+
+// TrafficEntry is an HTTP request and response pair, in HAR 1.2 entry format.
+type TrafficEntry struct {
+	StartedDateTime string          `json:"startedDateTime"`
+	Time            float64         `json:"time"`
+	Request         trafficRequest  `json:"request"`
+	Response        trafficResponse `json:"response"`
+	Cache           struct{}        `json:"cache"`
+	Timings         trafficTimings  `json:"timings"`
+	AWSService      string          `json:"_awsService,omitempty"`
+	AWSOperation    string          `json:"_awsOperation,omitempty"`
+	AWSRegion       string          `json:"_awsRegion,omitempty"`
+	Error           string          `json:"_error,omitempty"`
+}
+
+type trafficRequest struct {
+	Method      string             `json:"method"`
+	URL         string             `json:"url"`
+	HTTPVersion string             `json:"httpVersion"`
+	Cookies     []trafficNameValue `json:"cookies"`
+	Headers     []trafficNameValue `json:"headers"`
+	QueryString []trafficNameValue `json:"queryString"`
+	PostData    *trafficPostData   `json:"postData,omitempty"`
+	HeadersSize int                `json:"headersSize"`
+	BodySize    int                `json:"bodySize"`
+}
+
+type trafficResponse struct {
+	Status      int                `json:"status"`
+	StatusText  string             `json:"statusText"`
+	HTTPVersion string             `json:"httpVersion"`
+	Cookies     []trafficNameValue `json:"cookies"`
+	Headers     []trafficNameValue `json:"headers"`
+	Content     trafficContent     `json:"content"`
+	RedirectURL string             `json:"redirectURL"`
+	HeadersSize int                `json:"headersSize"`
+	BodySize    int                `json:"bodySize"`
+}
+
+type trafficNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type trafficPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type trafficContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type trafficTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// NewTrafficEntry creates a TrafficEntry from an outgoing request.
+// At most maxRequestBodyLen bytes of the request body are read and recorded, and the body is restored.
+// A truncated body is marked by a comment on the entry's postData.
+func NewTrafficEntry(req *http.Request, started time.Time) (*TrafficEntry, error) {
+	body, truncated, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	bodySize := len(body)
+	if truncated {
+		bodySize = int(req.ContentLength)
+		if req.ContentLength <= 0 {
+			bodySize = -1
+		}
+	}
+
+	u := *req.URL
+	query := u.Query()
+	for k := range query {
+		if k == "X-Amz-Security-Token" || k == "X-Amz-Signature" || isSecretField(k) {
+			query.Set(k, redacted)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	entry := &TrafficEntry{
+		StartedDateTime: started.UTC().Format(time.RFC3339Nano),
+		Request: trafficRequest{
+			Method:      req.Method,
+			URL:         MaskAWSAccessKey(u.String()),
+			HTTPVersion: httpVersion(req.Proto),
+			Cookies:     []trafficNameValue{},
+			Headers:     trafficRequestHeaders(req),
+			QueryString: trafficQueryString(query),
+			HeadersSize: -1,
+			BodySize:    bodySize,
+		},
+		Response: trafficResponse{
+			Cookies:     []trafficNameValue{},
+			Headers:     []trafficNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+	}
+
+	if len(body) > 0 {
+		entry.Request.PostData = &trafficPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     MaskAWSAccessKey(redactSecrets(string(body))),
+		}
+		if truncated {
+			entry.Request.PostData.Comment = fmt.Sprintf("Truncated to the first %d bytes", maxRequestBodyLen)
+		}
+	}
+
+	return entry, nil
+}
+
+// SetResponse records the response for the entry.
+func (e *TrafficEntry) SetResponse(resp *http.Response, body []byte, elapsed time.Duration) {
+	ms := float64(elapsed) / float64(time.Millisecond)
+	e.Time = ms
+	e.Timings.Wait = ms
+
+	if resp == nil {
+		return
+	}
+
+	e.Response.Status = resp.StatusCode
+	e.Response.StatusText = http.StatusText(resp.StatusCode)
+	e.Response.HTTPVersion = httpVersion(resp.Proto)
+	e.Response.Headers = trafficHeaders(resp.Header, nil)
+	e.Response.BodySize = len(body)
+	e.Response.Content = trafficContent{
+		Size:     len(body),
+		MimeType: resp.Header.Get("Content-Type"),
+		Text:     MaskAWSAccessKey(redactSecrets(string(body))),
+	}
+}
+
+// SetError records an error that prevented a response from being received.
+func (e *TrafficEntry) SetError(err error, elapsed time.Duration) {
+	ms := float64(elapsed) / float64(time.Millisecond)
+	e.Time = ms
+	e.Timings.Wait = ms
+
+	if err != nil {
+		e.Error = MaskAWSAccessKey(err.Error())
+	}
+}
+
+func trafficRequestHeaders(req *http.Request) []trafficNameValue {
+	header := req.Header.Clone()
+
+	return trafficHeaders(header, func(name, value string) string {
+		switch http.CanonicalHeaderKey(name) {
+		case "Authorization":
+			if attr, ok := authorizationHeaderAttribute(value); ok {
+				return attr.Value.AsString()
+			}
+			return "*****"
+		case "X-Amz-Security-Token":
+			return "*****"
+		}
+		return value
+	})
+}
+
+func trafficHeaders(header http.Header, mask func(name, value string) string) []trafficNameValue {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]trafficNameValue, 0, len(names))
+	for _, name := range names {
+		for _, value := range header[name] {
+			if mask != nil {
+				value = mask(name, value)
+			}
+			result = append(result, trafficNameValue{
+				Name:  name,
+				Value: MaskAWSAccessKey(value),
+			})
+		}
+	}
+	return result
+}
+
+func trafficQueryString(query map[string][]string) []trafficNameValue {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]trafficNameValue, 0, len(names))
+	for _, name := range names {
+		for _, value := range query[name] {
+			result = append(result, trafficNameValue{
+				Name:  name,
+				Value: MaskAWSAccessKey(value),
+			})
+		}
+	}
+	return result
+}
+
+// readRequestBody reads up to maxRequestBodyLen bytes of the request body and restores it.
+// The second return value is true if the body is longer and was truncated.
+func readRequestBody(req *http.Request) ([]byte, bool, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, false, nil
+	}
+
+	b, err := io.ReadAll(io.LimitReader(req.Body, maxRequestBodyLen+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("reading request body: %w", err)
+	}
+	req.Body = &restoredBody{
+		Reader: io.MultiReader(bytes.NewReader(b), req.Body),
+		Closer: req.Body,
+	}
+
+	if len(b) > maxRequestBodyLen {
+		return b[:maxRequestBodyLen], true, nil
+	}
+	return b, false, nil
+}
+
+// restoredBody is a request body of which the start has already been read.
+type restoredBody struct {
+	io.Reader
+	io.Closer
+}
+
+func httpVersion(proto string) string {
+	if proto == "" {
+		return "HTTP/1.1"
+	}
+	return proto
+}
@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/metrics"
+)
+
+// attemptMetricsRecorder records metrics for each API call attempt.
+// The attempt duration is measured by requestResponseLogger, which sits next to the transport.
+type attemptMetricsRecorder struct {
+	metrics *metrics.Recorder
+}
+
+// ID is the middleware identifier.
+func (r *attemptMetricsRecorder) ID() string {
+	return "TF_AWS_AttemptMetricsRecorder"
+}
+
+func (r *attemptMetricsRecorder) HandleDeserialize(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler,
+) (
+	out middleware.DeserializeOutput, metadata middleware.Metadata, err error,
+) {
+	out, metadata, err = next.HandleDeserialize(ctx, in)
+
+	attempt := metrics.Attempt{
+		Service:   awsmiddleware.GetServiceID(ctx),
+		Operation: awsmiddleware.GetOperationName(ctx),
+		Region:    awsmiddleware.GetRegion(ctx),
+	}
+
+	if elapsed, ok := getAttemptDuration(metadata); ok {
+		attempt.Duration = elapsed
+	}
+
+	if smithyResponse, ok := out.RawResponse.(*smithyhttp.Response); ok && smithyResponse.Response != nil {
+		attempt.StatusCode = smithyResponse.StatusCode
+	}
+
+	if err != nil {
+		attempt.Failed = true
+
+		var respErr *smithyhttp.ResponseError
+		if errors.As(err, &respErr) {
+			attempt.StatusCode = respErr.HTTPStatusCode()
+		}
+
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) {
+			attempt.ErrorCode = apiErr.ErrorCode()
+		}
+
+		attempt.Throttled = retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err).Bool()
+	}
+
+	r.metrics.RecordAttempt(ctx, attempt)
+
+	return out, metadata, err
+}
+
+// callMetricsRecorder records the duration of each API call, including all attempts.
+type callMetricsRecorder struct {
+	metrics *metrics.Recorder
+}
+
+// ID is the middleware identifier.
+func (r *callMetricsRecorder) ID() string {
+	return "TF_AWS_CallMetricsRecorder"
+}
+
+func (r *callMetricsRecorder) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+) (
+	out middleware.InitializeOutput, metadata middleware.Metadata, err error,
+) {
+	start := time.Now()
+
+	out, metadata, err = next.HandleInitialize(ctx, in)
+
+	r.metrics.RecordCall(ctx, metrics.Call{
+		Service:   awsmiddleware.GetServiceID(ctx),
+		Operation: awsmiddleware.GetOperationName(ctx),
+		Region:    awsmiddleware.GetRegion(ctx),
+		Duration:  time.Since(start),
+	})
+
+	return out, metadata, err
+}
@@ -0,0 +1,153 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/metrics"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/test"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestMetrics(t *testing.T) {
+	testCases := map[string]struct {
+		SuppressDebugLog   bool
+		MockStsEndpoints   []*servicemocks.MockEndpoint
+		ExpectError        bool
+		ExpectedAttempts   int
+		ExpectedErrorAttrs []map[string]any
+	}{
+		"success": {
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityValidEndpoint,
+			},
+			ExpectedAttempts: 1,
+		},
+		"success with debug log suppressed": {
+			SuppressDebugLog: true,
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityValidEndpoint,
+			},
+			ExpectedAttempts: 1,
+		},
+		"access denied": {
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsGetCallerIdentityInvalidEndpointAccessDenied,
+			},
+			ExpectError:      true,
+			ExpectedAttempts: 1,
+			ExpectedErrorAttrs: []map[string]any{
+				{
+					"aws.service":      "STS",
+					"aws.operation":    "GetCallerIdentity",
+					"aws.region":       "us-east-1",
+					"aws.error_code":   "AccessDenied",
+					"http.status_code": int64(403),
+				},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			ts := servicemocks.MockAwsApiServer("STS", testCase.MockStsEndpoints)
+			defer ts.Close()
+
+			provider := &test.MeterProvider{}
+
+			config := &Config{
+				AccessKey:        servicemocks.MockStaticAccessKey,
+				MeterProvider:    provider,
+				Region:           "us-east-1",
+				SecretKey:        servicemocks.MockStaticSecretKey,
+				StsEndpoint:      ts.URL,
+				SuppressDebugLog: testCase.SuppressDebugLog,
+			}
+
+			_, _, err := GetAwsConfig(context.Background(), config)
+			if err != nil && !testCase.ExpectError {
+				t.Fatalf("expected no error, got '%[1]T' error: %[1]s", err)
+			}
+			if err == nil && testCase.ExpectError {
+				t.Fatal("expected error, got none")
+			}
+
+			attempts := provider.Measurements(metrics.AttemptsName)
+			if a, e := len(attempts), testCase.ExpectedAttempts; a != e {
+				t.Fatalf("expected %d attempts, got %d", e, a)
+			}
+			for _, attempt := range attempts {
+				if a, e := attempt.Attributes["aws.operation"], "GetCallerIdentity"; a != e {
+					t.Errorf("expected operation %q, got %q", e, a)
+				}
+			}
+
+			if a, e := len(provider.Measurements(metrics.AttemptDurationName)), testCase.ExpectedAttempts; a != e {
+				t.Errorf("expected %d duration measurements, got %d", e, a)
+			}
+
+			if a, e := len(provider.Measurements(metrics.CallDurationName)), 1; a != e {
+				t.Errorf("expected %d call duration measurements, got %d", e, a)
+			}
+
+			var errorAttrs []map[string]any
+			for _, m := range provider.Measurements(metrics.ErrorsName) {
+				errorAttrs = append(errorAttrs, m.Attributes)
+			}
+			if diff := cmp.Diff(errorAttrs, testCase.ExpectedErrorAttrs); diff != "" {
+				t.Errorf("unexpected error measurements: (- got, + expected)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMetricsNetworkError(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	ts := servicemocks.MockAwsApiServer("STS", nil)
+	ts.Close()
+
+	provider := &test.MeterProvider{}
+
+	config := &Config{
+		AccessKey:     servicemocks.MockStaticAccessKey,
+		MaxRetries:    2,
+		MeterProvider: provider,
+		Region:        "us-east-1",
+		SecretKey:     servicemocks.MockStaticSecretKey,
+		StsEndpoint:   ts.URL,
+	}
+
+	if _, _, err := GetAwsConfig(context.Background(), config); err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	attempts := len(provider.Measurements(metrics.AttemptsName))
+	if attempts < 2 {
+		t.Fatalf("expected retried attempts, got %d", attempts)
+	}
+
+	errors := provider.Measurements(metrics.ErrorsName)
+	if a, e := len(errors), attempts; a != e {
+		t.Fatalf("expected %d error measurements, got %d", e, a)
+	}
+	for _, m := range errors {
+		if a, e := m.Attributes["aws.error_code"], metrics.UnknownErrorCode; a != e {
+			t.Errorf("expected error code %q, got %q", e, a)
+		}
+	}
+
+	if a, e := len(provider.Measurements(metrics.CallDurationName)), 1; a != e {
+		t.Errorf("expected %d call duration measurements, got %d", e, a)
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// offlineHTTPClient refuses all requests, so that no network calls are made while resolving credentials in offline mode.
+type offlineHTTPClient struct{}
+
+func (offlineHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return nil, OfflineError{
+		Endpoint: fmt.Sprintf("%s://%s", req.URL.Scheme, req.URL.Host),
+	}
+}
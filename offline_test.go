@@ -0,0 +1,186 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestOffline(t *testing.T) {
+	testCases := map[string]struct {
+		Config                  *Config
+		EnvironmentVariables    func(url string) map[string]string
+		SharedConfigurationFile func(url string) string
+		ExpectOfflineError      bool
+		ExpectedOfflineReason   string
+		ExpectedPartition       string
+	}{
+		"static credentials": {
+			Config: &Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				SecretKey: servicemocks.MockStaticSecretKey,
+			},
+			ExpectedPartition: "aws",
+		},
+
+		"environment credentials": {
+			Config: &Config{},
+			EnvironmentVariables: func(string) map[string]string {
+				return map[string]string{
+					"AWS_ACCESS_KEY_ID":     servicemocks.MockEnvAccessKey,
+					"AWS_SECRET_ACCESS_KEY": servicemocks.MockEnvSecretKey,
+				}
+			},
+			ExpectedPartition: "aws",
+		},
+
+		"IMDS": {
+			Config: &Config{},
+			EnvironmentVariables: func(url string) map[string]string {
+				return map[string]string{
+					"AWS_EC2_METADATA_SERVICE_ENDPOINT": url,
+				}
+			},
+			ExpectOfflineError: true,
+		},
+
+		"container credentials": {
+			Config: &Config{},
+			EnvironmentVariables: func(url string) map[string]string {
+				return map[string]string{
+					"AWS_CONTAINER_CREDENTIALS_FULL_URI": url + "/creds",
+				}
+			},
+			ExpectOfflineError: true,
+		},
+
+		"assume role": {
+			Config: &Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				AssumeRole: &AssumeRole{
+					RoleARN:     servicemocks.MockStsAssumeRoleArn,
+					SessionName: servicemocks.MockStsAssumeRoleSessionName,
+				},
+				SecretKey: servicemocks.MockStaticSecretKey,
+			},
+			ExpectOfflineError: true,
+		},
+
+		"get session token": {
+			Config: &Config{
+				AccessKey:       servicemocks.MockStaticAccessKey,
+				GetSessionToken: &GetSessionToken{},
+				SecretKey:       servicemocks.MockStaticSecretKey,
+			},
+			ExpectOfflineError:    true,
+			ExpectedOfflineReason: "GetSessionToken",
+		},
+
+		"shared configuration role": {
+			Config: &Config{
+				Profile: "SharedConfigurationProfile",
+			},
+			SharedConfigurationFile: func(string) string {
+				return fmt.Sprintf(`
+[profile SharedConfigurationProfile]
+role_arn = %[1]s
+role_session_name = %[2]s
+source_profile = SharedConfigurationSourceProfile
+
+[profile SharedConfigurationSourceProfile]
+aws_access_key_id = SharedConfigurationSourceAccessKey
+aws_secret_access_key = SharedConfigurationSourceSecretKey
+`, servicemocks.MockStsAssumeRoleArn, servicemocks.MockStsAssumeRoleSessionName)
+			},
+			ExpectOfflineError: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			var requests int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer ts.Close()
+
+			if testCase.EnvironmentVariables != nil {
+				for k, v := range testCase.EnvironmentVariables(ts.URL) {
+					os.Setenv(k, v)
+				}
+			}
+
+			if testCase.SharedConfigurationFile != nil {
+				file, err := os.CreateTemp("", "aws-sdk-go-base-shared-configuration-file")
+
+				if err != nil {
+					t.Fatalf("unexpected error creating temporary shared configuration file: %s", err)
+				}
+
+				defer os.Remove(file.Name())
+
+				err = os.WriteFile(file.Name(), []byte(testCase.SharedConfigurationFile(ts.URL)), 0600)
+
+				if err != nil {
+					t.Fatalf("unexpected error writing shared configuration file: %s", err)
+				}
+
+				testCase.Config.SharedConfigFiles = []string{file.Name()}
+			}
+
+			testCase.Config.IamEndpoint = ts.URL
+			testCase.Config.Offline = true
+			testCase.Config.Region = "us-east-1"
+			testCase.Config.StsEndpoint = ts.URL
+
+			ctx, awsConfig, err := GetAwsConfig(context.Background(), testCase.Config)
+
+			if testCase.ExpectOfflineError {
+				var offlineErr OfflineError
+				if !errors.As(err, &offlineErr) {
+					t.Fatalf("expected OfflineError, got '%[1]T': %[1]v", err)
+				}
+				if testCase.ExpectedOfflineReason != "" {
+					if a, e := offlineErr.Reason, testCase.ExpectedOfflineReason; a != e {
+						t.Errorf("expected OfflineError reason %q, got %q", e, a)
+					}
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+				}
+
+				accountID, partition, err := GetAwsAccountIDAndPartition(ctx, awsConfig, testCase.Config)
+				if err != nil {
+					t.Fatalf("error in GetAwsAccountIDAndPartition() '%[1]T': %[1]s", err)
+				}
+				if accountID != "" {
+					t.Errorf("expected no account ID, got %q", accountID)
+				}
+				if a, e := partition, testCase.ExpectedPartition; a != e {
+					t.Errorf("expected partition %q, got %q", e, a)
+				}
+			}
+
+			if n := atomic.LoadInt32(&requests); n != 0 {
+				t.Errorf("expected no network requests, got %d", n)
+			}
+		})
+	}
+}
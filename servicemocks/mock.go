@@ -12,6 +12,9 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,9 +23,10 @@ const (
 	MockEc2MetadataSecretKey    = `Ec2MetadataSecretKey`
 	MockEc2MetadataSessionToken = `Ec2MetadataSessionToken`
 
-	MockEcsCredentialsAccessKey    = `EcsCredentialsAccessKey`
-	MockEcsCredentialsSecretKey    = `EcsCredentialsSecretKey`
-	MockEcsCredentialsSessionToken = `EcsCredentialsSessionToken`
+	MockEcsCredentialsAccessKey          = `EcsCredentialsAccessKey`
+	MockEcsCredentialsAuthorizationToken = `EcsCredentialsAuthorizationToken`
+	MockEcsCredentialsSecretKey          = `EcsCredentialsSecretKey`
+	MockEcsCredentialsSessionToken       = `EcsCredentialsSessionToken`
 
 	MockEnvAccessKey    = `EnvAccessKey`
 	MockEnvSecretKey    = `EnvSecretKey`
@@ -31,6 +35,8 @@ const (
 	MockStaticAccessKey = `StaticAccessKey`
 	MockStaticSecretKey = `StaticSecretKey`
 
+	MockRequestID = `1b206dd1-f9a8-11e5-becf-051c60f11c4a`
+
 	MockStsAssumeRoleAccessKey                               = `AssumeRoleAccessKey`
 	MockStsAssumeRoleArn                                     = `arn:aws:iam::555555555555:role/AssumeRole`
 	MockStsAssumeRoleExternalId                              = `AssumeRoleExternalId`
@@ -47,7 +53,7 @@ const (
   "Statement": {
     "Effect": "Allow",
     "Action": "*",
-    "Resource": "*",
+    "Resource": "*"
   }
 }`
 	MockStsAssumeRolePolicyArn         = `arn:aws:iam::555555555555:policy/AssumeRolePolicy1`
@@ -203,6 +209,60 @@ const (
 </GetCallerIdentityResponse>`
 
 	MockWebIdentityToken = `WebIdentityToken`
+
+	MockStsEncodedAuthorizationMessage                             = `EncodedAuthorizationMessage-123_abc`
+	MockStsDecodedAuthorizationMessage                             = `{"allowed":false,"explicitDeny":false,"context":{"principal":{"id":"AIDACKCEVSQ6C2EXAMPLE","name":"Bob"},"action":"sts:GetCallerIdentity","resource":"*"}}`
+	MockStsGetCallerIdentityInvalidResponseBodyEncodedAccessDenied = `<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+<Error>
+  <Type>Sender</Type>
+  <Code>AccessDenied</Code>
+  <Message>User: arn:aws:iam::123456789012:user/Bob is not authorized to perform: sts:GetCallerIdentity. Encoded authorization failure message: ` + MockStsEncodedAuthorizationMessage + `</Message>
+</Error>
+<RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+</ErrorResponse>`
+	MockStsDecodeAuthorizationMessageValidResponseBody = `<DecodeAuthorizationMessageResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <DecodeAuthorizationMessageResult>
+    <DecodedMessage>` + MockStsDecodedAuthorizationMessage + `</DecodedMessage>
+  </DecodeAuthorizationMessageResult>
+  <ResponseMetadata>
+    <RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+  </ResponseMetadata>
+</DecodeAuthorizationMessageResponse>`
+	MockStsDecodeAuthorizationMessageInvalidResponseBodyAccessDenied = `<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+<Error>
+  <Type>Sender</Type>
+  <Code>AccessDenied</Code>
+  <Message>User: arn:aws:iam::123456789012:user/Bob is not authorized to perform: sts:DecodeAuthorizationMessage</Message>
+</Error>
+<RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+</ErrorResponse>`
+
+	MockStsGetSessionTokenAccessKey         = `GetSessionTokenAccessKey`
+	MockStsGetSessionTokenSecretKey         = `GetSessionTokenSecretKey`
+	MockStsGetSessionTokenSerialNumber      = `arn:aws:iam::222222222222:mfa/MFADevice`
+	MockStsGetSessionTokenSessionToken      = `GetSessionTokenSessionToken`
+	MockStsGetSessionTokenTokenCode         = `123456`
+	MockStsGetSessionTokenValidResponseBody = `<GetSessionTokenResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+<GetSessionTokenResult>
+  <Credentials>
+    <AccessKeyId>GetSessionTokenAccessKey</AccessKeyId>
+    <SecretAccessKey>GetSessionTokenSecretKey</SecretAccessKey>
+    <SessionToken>GetSessionTokenSessionToken</SessionToken>
+    <Expiration>2099-12-31T23:59:59Z</Expiration>
+  </Credentials>
+</GetSessionTokenResult>
+<ResponseMetadata>
+  <RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+</ResponseMetadata>
+</GetSessionTokenResponse>`
+	MockStsGetSessionTokenInvalidResponseBodyAccessDenied = `<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+<Error>
+  <Type>Sender</Type>
+  <Code>AccessDenied</Code>
+  <Message>MultiFactorAuthentication failed with invalid MFA one time pass code.</Message>
+</Error>
+<RequestId>01234567-89ab-cdef-0123-456789abcdef</RequestId>
+</ErrorResponse>`
 )
 
 var (
@@ -262,6 +322,54 @@ var (
 		},
 	}
 
+	MockStsDecodeAuthorizationMessageValidEndpoint = &MockEndpoint{
+		Request: &MockRequest{
+			Body: url.Values{
+				"Action":         []string{"DecodeAuthorizationMessage"},
+				"EncodedMessage": []string{MockStsEncodedAuthorizationMessage},
+				"Version":        []string{"2011-06-15"},
+			}.Encode(),
+			Method: http.MethodPost,
+			Uri:    "/",
+		},
+		Response: &MockResponse{
+			Body:        MockStsDecodeAuthorizationMessageValidResponseBody,
+			ContentType: "text/xml",
+			StatusCode:  http.StatusOK,
+		},
+	}
+	MockStsDecodeAuthorizationMessageInvalidEndpointAccessDenied = &MockEndpoint{
+		Request: &MockRequest{
+			Body: url.Values{
+				"Action":         []string{"DecodeAuthorizationMessage"},
+				"EncodedMessage": []string{MockStsEncodedAuthorizationMessage},
+				"Version":        []string{"2011-06-15"},
+			}.Encode(),
+			Method: http.MethodPost,
+			Uri:    "/",
+		},
+		Response: &MockResponse{
+			Body:        MockStsDecodeAuthorizationMessageInvalidResponseBodyAccessDenied,
+			ContentType: "text/xml",
+			StatusCode:  http.StatusForbidden,
+		},
+	}
+
+	MockStsGetCallerIdentityInvalidEndpointEncodedAccessDenied = &MockEndpoint{
+		Request: &MockRequest{
+			Body: url.Values{
+				"Action":  []string{"GetCallerIdentity"},
+				"Version": []string{"2011-06-15"},
+			}.Encode(),
+			Method: http.MethodPost,
+			Uri:    "/",
+		},
+		Response: &MockResponse{
+			Body:        MockStsGetCallerIdentityInvalidResponseBodyEncodedAccessDenied,
+			ContentType: "text/xml",
+			StatusCode:  http.StatusForbidden,
+		},
+	}
 	MockStsGetCallerIdentityInvalidEndpointAccessDenied = &MockEndpoint{
 		Request: &MockRequest{
 			Body: url.Values{
@@ -397,6 +505,56 @@ var (
 			StatusCode:  http.StatusOK,
 		},
 	}
+
+	MockStsGetSessionTokenValidEndpoint = &MockEndpoint{
+		Request: &MockRequest{
+			Body: url.Values{
+				"Action":  []string{"GetSessionToken"},
+				"Version": []string{"2011-06-15"},
+			}.Encode(),
+			Method: http.MethodPost,
+			Uri:    "/",
+		},
+		Response: &MockResponse{
+			Body:        MockStsGetSessionTokenValidResponseBody,
+			ContentType: "text/xml",
+			StatusCode:  http.StatusOK,
+		},
+	}
+	MockStsGetSessionTokenWithMFAValidEndpoint = &MockEndpoint{
+		Request: &MockRequest{
+			Body: url.Values{
+				"Action":       []string{"GetSessionToken"},
+				"SerialNumber": []string{MockStsGetSessionTokenSerialNumber},
+				"TokenCode":    []string{MockStsGetSessionTokenTokenCode},
+				"Version":      []string{"2011-06-15"},
+			}.Encode(),
+			Method: http.MethodPost,
+			Uri:    "/",
+		},
+		Response: &MockResponse{
+			Body:        MockStsGetSessionTokenValidResponseBody,
+			ContentType: "text/xml",
+			StatusCode:  http.StatusOK,
+		},
+	}
+	MockStsGetSessionTokenWithMFAInvalidEndpointAccessDenied = &MockEndpoint{
+		Request: &MockRequest{
+			Body: url.Values{
+				"Action":       []string{"GetSessionToken"},
+				"SerialNumber": []string{MockStsGetSessionTokenSerialNumber},
+				"TokenCode":    []string{MockStsGetSessionTokenTokenCode},
+				"Version":      []string{"2011-06-15"},
+			}.Encode(),
+			Method: http.MethodPost,
+			Uri:    "/",
+		},
+		Response: &MockResponse{
+			Body:        MockStsGetSessionTokenInvalidResponseBodyAccessDenied,
+			ContentType: "text/xml",
+			StatusCode:  http.StatusForbidden,
+		},
+	}
 )
 
 // MockAwsApiServer establishes a httptest server to simulate behaviour of a real AWS API server
@@ -418,10 +576,10 @@ func MockAwsApiServer(svcName string, endpoints []*MockEndpoint) *httptest.Serve
 				log.Printf("[DEBUG] Mocked %s API responding with %d: %s",
 					svcName, e.Response.StatusCode, e.Response.Body)
 
-				w.WriteHeader(e.Response.StatusCode)
 				w.Header().Set("Content-Type", e.Response.ContentType)
-				w.Header().Set("X-Amzn-Requestid", "1b206dd1-f9a8-11e5-becf-051c60f11c4a")
+				w.Header().Set("X-Amzn-Requestid", MockRequestID)
 				w.Header().Set("Date", time.Now().Format(time.RFC1123))
+				w.WriteHeader(e.Response.StatusCode)
 
 				fmt.Fprintln(w, e.Response.Body)
 				return
@@ -434,6 +592,59 @@ func MockAwsApiServer(svcName string, endpoints []*MockEndpoint) *httptest.Serve
 	return ts
 }
 
+// MockStsRevokedCredentialsServer establishes a httptest server to simulate STS revoking assumed role credentials before they expire.
+// Each AssumeRole request issues a new session token, "AssumeRoleSessionToken1", "AssumeRoleSessionToken2", and so on.
+// Calling revoke revokes all session tokens issued so far, and GetCallerIdentity requests signed with a revoked session token
+// respond with an ExpiredToken error.
+// assumeRoleCount reports the number of AssumeRole requests.
+func MockStsRevokedCredentialsServer() (ts *httptest.Server, revoke func(), assumeRoleCount func() int) {
+	var issued, revoked int32
+
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "Error reading from HTTP Request Body: %s", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/xml")
+		w.Header().Set("X-Amzn-Requestid", MockRequestID)
+		w.Header().Set("Date", time.Now().Format(time.RFC1123))
+
+		switch r.PostForm.Get("Action") {
+		case "AssumeRole":
+			n := atomic.AddInt32(&issued, 1)
+			sessionToken := fmt.Sprintf("%s%d", MockStsAssumeRoleSessionToken, n)
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, strings.Replace(MockStsAssumeRoleValidResponseBody, MockStsAssumeRoleSessionToken, sessionToken, 1))
+
+		case "GetCallerIdentity":
+			n, err := strconv.Atoi(strings.TrimPrefix(r.Header.Get("X-Amz-Security-Token"), MockStsAssumeRoleSessionToken))
+			if err == nil && int32(n) <= atomic.LoadInt32(&revoked) {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprintln(w, MockStsGetCallerIdentityValidResponseBodyExpiredToken)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, MockStsGetCallerIdentityValidAssumedRoleResponseBody)
+
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+
+	revoke = func() {
+		atomic.StoreInt32(&revoked, atomic.LoadInt32(&issued))
+	}
+	assumeRoleCount = func() int {
+		return int(atomic.LoadInt32(&issued))
+	}
+
+	return ts, revoke, assumeRoleCount
+}
+
 // AwsMetadataApiMock establishes a httptest server to mock out the internal AWS Metadata
 // service. IAM Credentials are retrieved by the EC2RoleProvider, which makes
 // API calls to this internal URL. By replacing the server with a test server,
@@ -458,10 +669,33 @@ func AwsMetadataApiMock(responses []*MetadataResponse) func() {
 
 // EcsCredentialsApiMock establishes a httptest server to mock out the ECS credentials API.
 func EcsCredentialsApiMock() func() {
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	ts := httptest.NewServer(ecsCredentialsHandler(""))
+
+	os.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", ts.URL+"/creds")
+	return ts.Close
+}
+
+// ContainerCredentialsApiMock establishes a httptest server to mock out a container credentials API
+// which requires the given Authorization header value. It returns the credentials URL and a function to close the server.
+func ContainerCredentialsApiMock(authorizationToken string) (string, func()) {
+	ts := httptest.NewServer(ecsCredentialsHandler(authorizationToken))
+
+	return ts.URL + "/creds", ts.Close
+}
+
+func ecsCredentialsHandler(authorizationToken string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Add("Server", "MockECS")
 		log.Printf("[DEBUG] Mock ECS credentials server received request: %s", r.RequestURI)
+		if authorizationToken != "" && r.Header.Get("Authorization") != authorizationToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"code":    "AccessDenied",
+				"message": "invalid authorization token",
+			})
+			return
+		}
 		if r.RequestURI == "/creds" {
 			_ = json.NewEncoder(w).Encode(map[string]string{
 				"AccessKeyId":     MockEcsCredentialsAccessKey,
@@ -473,10 +707,7 @@ func EcsCredentialsApiMock() func() {
 			return
 		}
 		w.WriteHeader(http.StatusBadRequest)
-	}))
-
-	os.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", ts.URL+"/creds")
-	return ts.Close
+	})
 }
 
 // MockStsAssumeRoleValidEndpointWithOptions returns a valid STS AssumeRole response with configurable request options.
@@ -0,0 +1,159 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/aws-sdk-go-base/v2/mockdata"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestAssumeRoleSessionNameTemplate(t *testing.T) {
+	testCases := map[string]struct {
+		SessionName          string
+		SourceIdentity       string
+		EnvironmentVariables map[string]string
+		MockStsEndpoints     []*servicemocks.MockEndpoint
+		ExpectedError        bool
+	}{
+		"session name": {
+			SessionName: `pipeline-{{env "CI_JOB_ID"}}`,
+			EnvironmentVariables: map[string]string{
+				"CI_JOB_ID": "1234",
+			},
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsAssumeRoleValidEndpointWithOptions(map[string]string{
+					"RoleSessionName": "pipeline-1234",
+				}),
+			},
+		},
+
+		"source identity": {
+			SessionName:    servicemocks.MockStsAssumeRoleSessionName,
+			SourceIdentity: `{{env "CI_PROJECT"}}.{{env "CI_JOB_ID"}}`,
+			EnvironmentVariables: map[string]string{
+				"CI_JOB_ID":  "1234",
+				"CI_PROJECT": "infra",
+			},
+			MockStsEndpoints: []*servicemocks.MockEndpoint{
+				servicemocks.MockStsAssumeRoleValidEndpointWithOptions(map[string]string{
+					"SourceIdentity": "infra.1234",
+				}),
+			},
+		},
+
+		"invalid expansion": {
+			SessionName: `{{env "CI_JOB_NAME"}}`,
+			EnvironmentVariables: map[string]string{
+				"CI_JOB_NAME": "plan and apply",
+			},
+			ExpectedError: true,
+		},
+
+		"unknown field": {
+			SessionName:   `{{.Unknown}}`,
+			ExpectedError: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			for k, v := range testCase.EnvironmentVariables {
+				os.Setenv(k, v)
+			}
+
+			closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", testCase.MockStsEndpoints)
+			defer closeSts()
+
+			config := &Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				AssumeRole: &AssumeRole{
+					RoleARN:        servicemocks.MockStsAssumeRoleArn,
+					SessionName:    testCase.SessionName,
+					SourceIdentity: testCase.SourceIdentity,
+				},
+				Region:              "us-east-1",
+				SecretKey:           servicemocks.MockStaticSecretKey,
+				SkipCredsValidation: true,
+				StsEndpoint:         stsEndpoint,
+			}
+
+			_, _, err := GetAwsConfig(context.Background(), config)
+			if testCase.ExpectedError {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+		})
+	}
+}
+
+func TestAssumeRoleDefaultSessionName(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	var mu sync.Mutex
+	var sessionNames []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		sessionNames = append(sessionNames, r.Form.Get("RoleSessionName"))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintln(w, servicemocks.MockStsAssumeRoleValidResponseBody)
+	}))
+	defer ts.Close()
+
+	for i := 0; i < 2; i++ {
+		config := &Config{
+			AccessKey: servicemocks.MockStaticAccessKey,
+			AssumeRole: &AssumeRole{
+				RoleARN: servicemocks.MockStsAssumeRoleArn,
+			},
+			Region:              "us-east-1",
+			SecretKey:           servicemocks.MockStaticSecretKey,
+			SkipCredsValidation: true,
+			StsEndpoint:         ts.URL,
+		}
+
+		if _, _, err := GetAwsConfig(context.Background(), config); err != nil {
+			t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+		}
+	}
+
+	if len(sessionNames) == 0 {
+		t.Fatal("expected AssumeRole requests, got none")
+	}
+	valid := regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+	for _, v := range sessionNames {
+		if !valid.MatchString(v) {
+			t.Errorf("invalid session name %q", v)
+		}
+		if v != sessionNames[0] {
+			t.Errorf("expected the same session name in every run, got %q and %q", sessionNames[0], v)
+		}
+	}
+}
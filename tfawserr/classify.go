@@ -0,0 +1,316 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfawserr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/aws/smithy-go"
+)
+
+// The classification functions in this file accept errors returned by either the AWS SDK for Go v2 or v1.
+// Errors from the AWS SDK for Go v1 are matched by their methods, so that this package does not depend on it.
+
+// IsThrottling returns true if the error indicates that the request was throttled.
+func IsThrottling(err error) bool {
+	if statusCode, ok := errorStatusCode(err); ok && statusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return throttlingCodes.matches(err)
+}
+
+// IsAccessDenied returns true if the error indicates that the caller is not authorized to perform the operation.
+func IsAccessDenied(err error) bool {
+	return accessDeniedCodes.matches(err)
+}
+
+// IsNotFound returns true if the error indicates that the requested resource does not exist.
+func IsNotFound(err error) bool {
+	return notFoundCodes.matches(err)
+}
+
+// IsExpiredCredentials returns true if the error indicates that the credentials used to sign the request have expired.
+func IsExpiredCredentials(err error) bool {
+	return expiredCredentialsCodes.matches(err)
+}
+
+// IsServiceUnavailable returns true if the error indicates that the service was temporarily unable to handle the request.
+// Throttling errors are not included, even though some services respond to throttled requests with a 503 status code.
+func IsServiceUnavailable(err error) bool {
+	if IsThrottling(err) {
+		return false
+	}
+	if statusCode, ok := errorStatusCode(err); ok {
+		switch statusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return serviceUnavailableCodes.matches(err)
+}
+
+// IsValidationError returns true if the error indicates that the request parameters were rejected by the service.
+func IsValidationError(err error) bool {
+	return validationCodes.matches(err)
+}
+
+// IsTransientNetwork returns true if the error indicates that the connection to the endpoint failed
+// after it was established, such as a connection reset or a timeout.
+// Cancellation or expiry of the caller's context is not considered transient.
+func IsTransientNetwork(err error) bool {
+	if err == nil || is(err, context.Canceled) || is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if as(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var opErr *net.OpError
+	if as(err, &opErr) {
+		msg := opErr.Error()
+		return strings.Contains(msg, "connection reset") || strings.Contains(msg, "broken pipe")
+	}
+
+	return false
+}
+
+// IsEndpointUnreachable returns true if the error indicates that a connection to the endpoint could not be made,
+// because the host name could not be resolved or the connection was refused.
+// Such errors are commonly caused by a misconfigured endpoint and are unlikely to be resolved by retrying indefinitely.
+func IsEndpointUnreachable(err error) bool {
+	var opErr *net.OpError
+	if as(err, &opErr) {
+		// It's disappointing that we have to do string matching here, rather than being able to using `errors.Is()` or even strings exported by the Go `net` package
+		msg := opErr.Error()
+		return strings.Contains(msg, "no such host") || strings.Contains(msg, "connection refused")
+	}
+	return false
+}
+
+// codeTable is a set of error codes common to all services, and error codes specific to individual services.
+// Services are keyed by service ID, e.g. "EC2" or "CloudWatch Logs".
+type codeTable struct {
+	common   []string
+	services map[string][]string
+
+	// suffixes match error codes ending with any of the values, e.g. the EC2 ".NotFound" codes.
+	suffixes map[string][]string
+}
+
+// matches returns true if the error code is in the table.
+// When the service is known, as it is for AWS SDK for Go v2 operation errors, only its codes are considered.
+// Otherwise, the codes for all services are considered.
+func (t codeTable) matches(err error) bool {
+	code, ok := errorCode(err)
+	if !ok {
+		return false
+	}
+
+	for _, c := range t.common {
+		if code == c {
+			return true
+		}
+	}
+
+	service, known := errorServiceID(err)
+	for s, codes := range t.services {
+		if known && s != service {
+			continue
+		}
+		for _, c := range codes {
+			if code == c {
+				return true
+			}
+		}
+	}
+	for s, suffixes := range t.suffixes {
+		if known && s != service {
+			continue
+		}
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(code, suffix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+var throttlingCodes = codeTable{
+	common: []string{
+		"BandwidthLimitExceeded",
+		"RequestThrottled",
+		"RequestThrottledException",
+		"ThrottledException",
+		"Throttling",
+		"ThrottlingException",
+		"TooManyRequestsException",
+	},
+	services: map[string][]string{
+		"DynamoDB": {"ProvisionedThroughputExceededException", "RequestLimitExceeded", "TransactionInProgressException"},
+		"EC2":      {"EC2ThrottledException", "RequestLimitExceeded"},
+		"Kinesis":  {"LimitExceededException", "ProvisionedThroughputExceededException"},
+		"Route 53": {"PriorRequestNotComplete"},
+		"S3":       {"SlowDown"},
+	},
+}
+
+var accessDeniedCodes = codeTable{
+	common: []string{
+		"AccessDenied",
+		"AccessDeniedException",
+	},
+	services: map[string][]string{
+		"EC2": {"UnauthorizedOperation"},
+		"SNS": {"AuthorizationError"},
+	},
+}
+
+var notFoundCodes = codeTable{
+	common: []string{
+		"NotFoundException",
+		"ResourceNotFoundException",
+	},
+	services: map[string][]string{
+		"CloudFormation": {"StackNotFoundException"},
+		"ECS":            {"ClusterNotFoundException", "ServiceNotFoundException"},
+		"IAM":            {"NoSuchEntity"},
+		"RDS":            {"DBClusterNotFoundFault", "DBInstanceNotFound", "DBSubnetGroupNotFoundFault"},
+		"Route 53":       {"NoSuchHealthCheck", "NoSuchHostedZone"},
+		"S3":             {"NoSuchBucket", "NoSuchKey", "NoSuchUpload", "NotFound"},
+		"SNS":            {"NotFound"},
+		"SQS":            {"AWS.SimpleQueueService.NonExistentQueue", "QueueDoesNotExist"},
+	},
+	suffixes: map[string][]string{
+		"EC2": {".NotFound"},
+	},
+}
+
+var expiredCredentialsCodes = codeTable{
+	common: []string{
+		"ExpiredToken",
+		"ExpiredTokenException",
+		"RequestExpired",
+	},
+}
+
+var serviceUnavailableCodes = codeTable{
+	common: []string{
+		"InternalError",
+		"InternalFailure",
+		"InternalServerError",
+		"InternalServiceError",
+		"ServiceUnavailable",
+		"ServiceUnavailableException",
+	},
+	services: map[string][]string{
+		"EC2":    {"Unavailable"},
+		"Lambda": {"ServiceException"},
+	},
+}
+
+var validationCodes = codeTable{
+	common: []string{
+		"InvalidParameter",
+		"InvalidParameterCombination",
+		"InvalidParameterException",
+		"InvalidParameterValue",
+		"InvalidParameterValueException",
+		"MissingParameter",
+		"SerializationException",
+		"ValidationError",
+		"ValidationException",
+	},
+	services: map[string][]string{
+		"IAM": {"InvalidInput", "MalformedPolicyDocument"},
+		"S3":  {"InvalidArgument", "InvalidRequest"},
+	},
+}
+
+// errorCode returns the API error code of an AWS SDK for Go v2 or v1 error.
+func errorCode(err error) (string, bool) {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode(), true
+	}
+
+	var v1Err interface {
+		Code() string
+		Message() string
+	}
+	if errors.As(err, &v1Err) {
+		return v1Err.Code(), true
+	}
+
+	return "", false
+}
+
+// errorStatusCode returns the HTTP status code of an AWS SDK for Go v2 or v1 error.
+func errorStatusCode(err error) (int, bool) {
+	var v2Err interface{ HTTPStatusCode() int }
+	if errors.As(err, &v2Err) {
+		return v2Err.HTTPStatusCode(), true
+	}
+
+	var v1Err interface{ StatusCode() int }
+	if errors.As(err, &v1Err) {
+		return v1Err.StatusCode(), true
+	}
+
+	return 0, false
+}
+
+// errorServiceID returns the service ID of an AWS SDK for Go v2 operation error.
+// AWS SDK for Go v1 errors do not include the service.
+func errorServiceID(err error) (string, bool) {
+	var opErr *smithy.OperationError
+	if errors.As(err, &opErr) {
+		return opErr.ServiceID, true
+	}
+	return "", false
+}
+
+// as is errors.As, also following the OrigErr() chain of AWS SDK for Go v1 errors.
+func as(err error, target any) bool {
+	if errors.As(err, target) {
+		return true
+	}
+	if orig := origErr(err); orig != nil {
+		return as(orig, target)
+	}
+	return false
+}
+
+// is is errors.Is, also following the OrigErr() chain of AWS SDK for Go v1 errors.
+func is(err, target error) bool {
+	if errors.Is(err, target) {
+		return true
+	}
+	if orig := origErr(err); orig != nil {
+		return is(orig, target)
+	}
+	return false
+}
+
+func origErr(err error) error {
+	var v1Err interface{ OrigErr() error }
+	if errors.As(err, &v1Err) {
+		if orig := v1Err.OrigErr(); orig != err {
+			return orig
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfawserr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+// v1Error has the methods of the AWS SDK for Go v1 awserr.RequestFailure
+type v1Error struct {
+	code       string
+	message    string
+	origErr    error
+	statusCode int
+}
+
+func (e v1Error) Error() string   { return fmt.Sprintf("%s: %s", e.code, e.message) }
+func (e v1Error) Code() string    { return e.code }
+func (e v1Error) Message() string { return e.message }
+func (e v1Error) OrigErr() error  { return e.origErr }
+func (e v1Error) StatusCode() int { return e.statusCode }
+func (e v1Error) RequestID() string {
+	return ""
+}
+
+func serviceOperationError(service string, err error) error {
+	return &smithy.OperationError{
+		ServiceID:     service,
+		OperationName: "TestOperation",
+		Err:           err,
+	}
+}
+
+func TestClassify(t *testing.T) {
+	type classifier struct {
+		Name string
+		Fn   func(error) bool
+	}
+	var (
+		throttling         = classifier{"IsThrottling", IsThrottling}
+		accessDenied       = classifier{"IsAccessDenied", IsAccessDenied}
+		notFound           = classifier{"IsNotFound", IsNotFound}
+		expiredCredentials = classifier{"IsExpiredCredentials", IsExpiredCredentials}
+		transientNetwork   = classifier{"IsTransientNetwork", IsTransientNetwork}
+		serviceUnavailable = classifier{"IsServiceUnavailable", IsServiceUnavailable}
+		validation         = classifier{"IsValidationError", IsValidationError}
+		unreachable        = classifier{"IsEndpointUnreachable", IsEndpointUnreachable}
+	)
+	classifiers := []classifier{throttling, accessDenied, notFound, expiredCredentials, transientNetwork, serviceUnavailable, validation, unreachable}
+
+	testCases := map[string]struct {
+		Err      error
+		Expected *classifier
+	}{
+		"nil error": {},
+		"other error": {
+			Err: errors.New("test"),
+		},
+		"v2 throttling": {
+			Err:      operationError(responseError(apiError("ThrottlingException", "Rate exceeded"), 400)),
+			Expected: &throttling,
+		},
+		"v2 too many requests status": {
+			Err:      operationError(responseError(apiError("Unknown", "test"), 429)),
+			Expected: &throttling,
+		},
+		"v1 throttling": {
+			Err:      v1Error{code: "Throttling", message: "Rate exceeded", statusCode: 400},
+			Expected: &throttling,
+		},
+		"v2 EC2 request limit exceeded": {
+			Err:      serviceOperationError("EC2", responseError(apiError("RequestLimitExceeded", "test"), 503)),
+			Expected: &throttling,
+		},
+		"v2 other service request limit exceeded": {
+			Err: serviceOperationError("IAM", responseError(apiError("RequestLimitExceeded", "test"), 400)),
+		},
+		"v2 Kinesis limit exceeded": {
+			Err:      serviceOperationError("Kinesis", responseError(apiError("LimitExceededException", "Rate exceeded for stream"), 400)),
+			Expected: &throttling,
+		},
+		"v2 IAM limit exceeded": {
+			Err: serviceOperationError("IAM", responseError(apiError("LimitExceeded", "Cannot exceed quota for PoliciesPerRole"), 409)),
+		},
+		"v2 IAM limit exceeded exception": {
+			Err: serviceOperationError("IAM", responseError(apiError("LimitExceededException", "Cannot exceed quota"), 409)),
+		},
+		"v2 Lambda limit exceeded exception": {
+			Err: serviceOperationError("Lambda", responseError(apiError("LimitExceededException", "Cannot exceed quota"), 400)),
+		},
+		"v1 request limit exceeded": {
+			Err:      v1Error{code: "RequestLimitExceeded", message: "test", statusCode: 400},
+			Expected: &throttling,
+		},
+		"v2 access denied": {
+			Err:      operationError(responseError(apiError("AccessDenied", "test"), 403)),
+			Expected: &accessDenied,
+		},
+		"v2 EC2 unauthorized operation": {
+			Err:      serviceOperationError("EC2", responseError(apiError("UnauthorizedOperation", "test"), 403)),
+			Expected: &accessDenied,
+		},
+		"v1 access denied": {
+			Err:      v1Error{code: "AccessDeniedException", message: "test", statusCode: 400},
+			Expected: &accessDenied,
+		},
+		"v2 resource not found": {
+			Err:      operationError(responseError(apiError("ResourceNotFoundException", "test"), 400)),
+			Expected: &notFound,
+		},
+		"v2 IAM no such entity": {
+			Err:      serviceOperationError("IAM", responseError(apiError("NoSuchEntity", "test"), 404)),
+			Expected: &notFound,
+		},
+		"v2 other service no such entity": {
+			Err: serviceOperationError("S3", responseError(apiError("NoSuchEntity", "test"), 404)),
+		},
+		"v2 EC2 not found suffix": {
+			Err:      serviceOperationError("EC2", responseError(apiError("InvalidVpcID.NotFound", "test"), 400)),
+			Expected: &notFound,
+		},
+		"v1 EC2 not found suffix": {
+			Err:      v1Error{code: "InvalidInstanceID.NotFound", message: "test", statusCode: 400},
+			Expected: &notFound,
+		},
+		"v2 expired token": {
+			Err:      operationError(responseError(apiError("ExpiredToken", "test"), 400)),
+			Expected: &expiredCredentials,
+		},
+		"v1 expired token": {
+			Err:      v1Error{code: "ExpiredTokenException", message: "test", statusCode: 400},
+			Expected: &expiredCredentials,
+		},
+		"v2 service unavailable status": {
+			Err:      operationError(responseError(apiError("Unknown", "test"), 503)),
+			Expected: &serviceUnavailable,
+		},
+		"v1 internal error": {
+			Err:      v1Error{code: "InternalError", message: "test", statusCode: 500},
+			Expected: &serviceUnavailable,
+		},
+		"v2 validation": {
+			Err:      operationError(responseError(apiError("ValidationException", "test"), 400)),
+			Expected: &validation,
+		},
+		"v1 IAM malformed policy": {
+			Err:      v1Error{code: "MalformedPolicyDocument", message: "test", statusCode: 400},
+			Expected: &validation,
+		},
+		"connection reset": {
+			Err:      operationError(&net.OpError{Op: "read", Err: errors.New("connection reset by peer")}),
+			Expected: &transientNetwork,
+		},
+		"v1 connection reset": {
+			Err:      v1Error{code: "RequestError", message: "send request failed", origErr: &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}},
+			Expected: &transientNetwork,
+		},
+		"unexpected EOF": {
+			Err:      fmt.Errorf("test: %w", io.ErrUnexpectedEOF),
+			Expected: &transientNetwork,
+		},
+		"context deadline exceeded": {
+			Err: operationError(context.DeadlineExceeded),
+		},
+		"v1 request canceled deadline exceeded": {
+			Err: v1Error{code: "RequestCanceled", message: "request context canceled", origErr: context.DeadlineExceeded},
+		},
+		"v1 request canceled": {
+			Err: v1Error{code: "RequestCanceled", message: "request context canceled", origErr: context.Canceled},
+		},
+		"no such host": {
+			Err:      operationError(&net.OpError{Op: "dial", Err: errors.New("no such host")}),
+			Expected: &unreachable,
+		},
+		"v1 connection refused": {
+			Err:      v1Error{code: "RequestError", message: "send request failed", origErr: &net.OpError{Op: "dial", Err: errors.New("connection refused")}},
+			Expected: &unreachable,
+		},
+		"v1 other network error": {
+			Err: v1Error{code: "RequestError", message: "send request failed", origErr: &net.OpError{Op: "dial", Err: errors.New("other error")}},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			for _, c := range classifiers {
+				expected := testCase.Expected != nil && testCase.Expected.Name == c.Name
+				if got := c.Fn(testCase.Err); got != expected {
+					t.Errorf("%s: got %t, expected %t", c.Name, got, expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package tfawserr provides helpers for matching errors returned by the AWS SDK for Go v2.
+// The matching semantics are the same as those of the awsv1shim/tfawserr package for the AWS SDK for Go v1.
+// There is no equivalent of ErrMessageAndOrigErrContain, as smithy.APIError does not wrap an original error.
+// The error classification functions, such as IsThrottling, accept errors from either SDK.
+package tfawserr
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// ErrCodeEquals returns true if the error matches all these conditions:
+//   - err is of type smithy.APIError
+//   - APIError.ErrorCode() equals one of the passed codes
+func ErrCodeEquals(err error, codes ...string) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		for _, code := range codes {
+			if apiErr.ErrorCode() == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ErrCodeContains returns true if the error matches all these conditions:
+//   - err is of type smithy.APIError
+//   - APIError.ErrorCode() contains code
+func ErrCodeContains(err error, code string) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return strings.Contains(apiErr.ErrorCode(), code)
+	}
+	return false
+}
+
+// ErrMessageContains returns true if the error matches all these conditions:
+//   - err is of type smithy.APIError
+//   - APIError.ErrorCode() equals code
+//   - APIError.ErrorMessage() contains message
+func ErrMessageContains(err error, code string, message string) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == code && strings.Contains(apiErr.ErrorMessage(), message)
+	}
+	return false
+}
+
+// ErrStatusCodeEquals returns true if the error matches all these conditions:
+//   - err is of type smithyhttp.ResponseError
+//   - ResponseError.HTTPStatusCode() equals statusCode
+//
+// It is always preferable to use ErrMessageContains() except in older APIs (e.g. S3)
+// that sometimes only respond with status codes.
+func ErrStatusCodeEquals(err error, statusCode int) bool {
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.HTTPStatusCode() == statusCode
+	}
+	return false
+}
@@ -0,0 +1,344 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfawserr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+func TestErrCodeEquals(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Err      error
+		Codes    []string
+		Expected bool
+	}{
+		{
+			Name: "nil error",
+			Err:  nil,
+		},
+		{
+			Name:  "nil error code",
+			Err:   nil,
+			Codes: []string{"test"},
+		},
+		{
+			Name: "other error",
+			Err:  errors.New("test"),
+		},
+		{
+			Name:  "other error code",
+			Err:   errors.New("test"),
+			Codes: []string{"test"},
+		},
+		{
+			Name:     "smithy error matching first code",
+			Err:      apiError("TestCode", "TestMessage"),
+			Codes:    []string{"TestCode"},
+			Expected: true,
+		},
+		{
+			Name:     "smithy error matching last code",
+			Err:      apiError("TestCode", "TestMessage"),
+			Codes:    []string{"NotMatching", "TestCode"},
+			Expected: true,
+		},
+		{
+			Name:  "smithy error no code",
+			Err:   apiError("TestCode", "TestMessage"),
+			Codes: []string{},
+		},
+		{
+			Name:  "smithy error non-matching codes",
+			Err:   apiError("TestCode", "TestMessage"),
+			Codes: []string{"NotMatching", "AlsoNotMatching"},
+		},
+		{
+			Name:     "operation error matching code",
+			Err:      operationError(responseError(apiError("TestCode", "TestMessage"), 400)),
+			Codes:    []string{"TestCode"},
+			Expected: true,
+		},
+		{
+			Name:     "wrapped smithy error matching code",
+			Err:      fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Codes:    []string{"TestCode"},
+			Expected: true,
+		},
+		{
+			Name:  "wrapped smithy error non-matching codes",
+			Err:   fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Codes: []string{"NotMatching", "AlsoNotMatching"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.Name, func(t *testing.T) {
+			got := ErrCodeEquals(testCase.Err, testCase.Codes...)
+
+			if got != testCase.Expected {
+				t.Errorf("got %t, expected %t", got, testCase.Expected)
+			}
+		})
+	}
+}
+
+func TestErrCodeContains(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Err      error
+		Code     string
+		Expected bool
+	}{
+		{
+			Name: "nil error",
+			Err:  nil,
+		},
+		{
+			Name: "nil error code",
+			Err:  nil,
+			Code: "test",
+		},
+		{
+			Name: "other error",
+			Err:  errors.New("test"),
+		},
+		{
+			Name: "other error code",
+			Err:  errors.New("test"),
+			Code: "test",
+		},
+		{
+			Name:     "smithy error matching code",
+			Err:      apiError("TestCode", "TestMessage"),
+			Code:     "Code",
+			Expected: true,
+		},
+		{
+			Name: "smithy error non-matching code",
+			Err:  apiError("TestCode", "TestMessage"),
+			Code: "NotMatching",
+		},
+		{
+			Name:     "operation error matching code",
+			Err:      operationError(responseError(apiError("TestCode", "TestMessage"), 400)),
+			Code:     "Test",
+			Expected: true,
+		},
+		{
+			Name:     "wrapped smithy error matching code",
+			Err:      fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Code:     "Code",
+			Expected: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.Name, func(t *testing.T) {
+			got := ErrCodeContains(testCase.Err, testCase.Code)
+
+			if got != testCase.Expected {
+				t.Errorf("got %t, expected %t", got, testCase.Expected)
+			}
+		})
+	}
+}
+
+func TestErrMessageContains(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Err      error
+		Code     string
+		Message  string
+		Expected bool
+	}{
+		{
+			Name: "nil error",
+			Err:  nil,
+		},
+		{
+			Name:    "nil error code and message",
+			Err:     nil,
+			Code:    "test",
+			Message: "test",
+		},
+		{
+			Name: "other error",
+			Err:  errors.New("test"),
+		},
+		{
+			Name:    "other error code and message",
+			Err:     errors.New("test"),
+			Code:    "test",
+			Message: "test",
+		},
+		{
+			Name:     "smithy error matching code and no message",
+			Err:      apiError("TestCode", "TestMessage"),
+			Code:     "TestCode",
+			Expected: true,
+		},
+		{
+			Name:     "smithy error matching code and matching message exact",
+			Err:      apiError("TestCode", "TestMessage"),
+			Code:     "TestCode",
+			Message:  "TestMessage",
+			Expected: true,
+		},
+		{
+			Name:     "smithy error matching code and matching message contains",
+			Err:      apiError("TestCode", "TestMessage"),
+			Code:     "TestCode",
+			Message:  "Message",
+			Expected: true,
+		},
+		{
+			Name:    "smithy error matching code and non-matching message",
+			Err:     apiError("TestCode", "TestMessage"),
+			Code:    "TestCode",
+			Message: "NotMatching",
+		},
+		{
+			Name:    "smithy error non-matching code and matching message",
+			Err:     apiError("TestCode", "TestMessage"),
+			Code:    "NotMatching",
+			Message: "TestMessage",
+		},
+		{
+			Name:     "operation error matching code and matching message",
+			Err:      operationError(responseError(apiError("TestCode", "TestMessage"), 400)),
+			Code:     "TestCode",
+			Message:  "Message",
+			Expected: true,
+		},
+		{
+			Name:     "wrapped smithy error matching code and matching message",
+			Err:      fmt.Errorf("test: %w", apiError("TestCode", "TestMessage")),
+			Code:     "TestCode",
+			Message:  "TestMessage",
+			Expected: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.Name, func(t *testing.T) {
+			got := ErrMessageContains(testCase.Err, testCase.Code, testCase.Message)
+
+			if got != testCase.Expected {
+				t.Errorf("got %t, expected %t", got, testCase.Expected)
+			}
+		})
+	}
+}
+
+func TestErrStatusCodeEquals(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		Err        error
+		StatusCode int
+		Expected   bool
+	}{
+		{
+			Name: "nil error",
+			Err:  nil,
+		},
+		{
+			Name:       "nil error status code",
+			Err:        nil,
+			StatusCode: 42,
+		},
+		{
+			Name: "other error",
+			Err:  errors.New("test"),
+		},
+		{
+			Name:       "other error status code",
+			Err:        errors.New("test"),
+			StatusCode: 42,
+		},
+		{
+			Name:       "smithy error without response",
+			Err:        apiError("TestCode", "TestMessage"),
+			StatusCode: 42,
+		},
+		{
+			Name:       "response error matching status code",
+			Err:        responseError(apiError("TestCode", "TestMessage"), 42),
+			StatusCode: 42,
+			Expected:   true,
+		},
+		{
+			Name:       "response error non-matching status code",
+			Err:        responseError(apiError("TestCode", "TestMessage"), 404),
+			StatusCode: 42,
+		},
+		{
+			Name:       "operation error matching status code",
+			Err:        operationError(responseError(apiError("TestCode", "TestMessage"), 42)),
+			StatusCode: 42,
+			Expected:   true,
+		},
+		{
+			Name:       "wrapped response error matching status code",
+			Err:        fmt.Errorf("test: %w", responseError(apiError("TestCode", "TestMessage"), 42)),
+			StatusCode: 42,
+			Expected:   true,
+		},
+		{
+			Name:       "wrapped response error non-matching status code",
+			Err:        fmt.Errorf("test: %w", responseError(apiError("TestCode", "TestMessage"), 404)),
+			StatusCode: 42,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.Name, func(t *testing.T) {
+			got := ErrStatusCodeEquals(testCase.Err, testCase.StatusCode)
+
+			if got != testCase.Expected {
+				t.Errorf("got %t, expected %t", got, testCase.Expected)
+			}
+		})
+	}
+}
+
+func apiError(code, message string) error {
+	return &smithy.GenericAPIError{
+		Code:    code,
+		Message: message,
+	}
+}
+
+func responseError(err error, statusCode int) error {
+	return &smithyhttp.ResponseError{
+		Response: &smithyhttp.Response{
+			Response: &http.Response{
+				StatusCode: statusCode,
+			},
+		},
+		Err: err,
+	}
+}
+
+func operationError(err error) error {
+	return &smithy.OperationError{
+		ServiceID:     "Test",
+		OperationName: "TestOperation",
+		Err:           err,
+	}
+}
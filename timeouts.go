@@ -0,0 +1,99 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// withPhaseTimeout calls f with a context limited by the timeout for the phase in c.Timeouts.
+// If the timeout is exceeded, the error is returned as a PhaseTimeoutError.
+func withPhaseTimeout(ctx context.Context, c *Config, phase Phase, f func(ctx context.Context) error) error {
+	timeout := c.Timeouts.For(phase)
+	if timeout <= 0 {
+		return f(ctx)
+	}
+
+	recorder := &endpointRecorder{}
+	phaseCtx, cancel := context.WithTimeout(context.WithValue(ctx, endpointRecorderKey{}, recorder), timeout)
+	defer cancel()
+
+	err := f(phaseCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(phaseCtx.Err(), context.DeadlineExceeded) {
+		endpoint := recorder.get()
+		if endpoint == "" {
+			endpoint = requestEndpoint(err)
+		}
+		return PhaseTimeoutError{
+			Phase:    phase,
+			Endpoint: endpoint,
+			Timeout:  timeout,
+			Err:      err,
+		}
+	}
+	return err
+}
+
+type endpointRecorderKey struct{}
+
+// endpointRecorder holds the endpoint most recently contacted during a phase.
+type endpointRecorder struct {
+	mu       sync.Mutex
+	endpoint string
+}
+
+func (r *endpointRecorder) set(endpoint string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.endpoint = endpoint
+}
+
+func (r *endpointRecorder) get() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.endpoint
+}
+
+// endpointRecorderMiddleware records the endpoint of each request attempt made during a phase limited by withPhaseTimeout.
+// The AWS SDK for Go v2 replaces the transport error with the context error on timeout, so the endpoint is not otherwise available.
+type endpointRecorderMiddleware struct{}
+
+// ID is the middleware identifier.
+func (m *endpointRecorderMiddleware) ID() string {
+	return "TF_AWS_EndpointRecorder"
+}
+
+func (m *endpointRecorderMiddleware) HandleFinalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler,
+) (
+	out middleware.FinalizeOutput, metadata middleware.Metadata, err error,
+) {
+	if recorder, ok := ctx.Value(endpointRecorderKey{}).(*endpointRecorder); ok {
+		if req, ok := in.Request.(*smithyhttp.Request); ok {
+			recorder.set(fmt.Sprintf("%s://%s", req.URL.Scheme, req.URL.Host))
+		}
+	}
+	return next.HandleFinalize(ctx, in)
+}
+
+// requestEndpoint returns the scheme and host of the request that failed with err, if any.
+func requestEndpoint(err error) string {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return ""
+	}
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil || u.Host == "" {
+		return urlErr.URL
+	}
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,139 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestPhaseTimeouts(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	testCases := map[string]struct {
+		Config        *Config
+		SlowIMDS      bool
+		AccountID     bool
+		ExpectedPhase Phase
+	}{
+		"credentials retrieval": {
+			Config: &Config{
+				SkipCredsValidation: true,
+				Timeouts: &Timeouts{
+					CredentialsRetrieval: timeout,
+				},
+			},
+			SlowIMDS:      true,
+			ExpectedPhase: PhaseCredentialsRetrieval,
+		},
+
+		"assume role": {
+			Config: &Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				AssumeRole: &AssumeRole{
+					RoleARN:     servicemocks.MockStsAssumeRoleArn,
+					SessionName: servicemocks.MockStsAssumeRoleSessionName,
+				},
+				SecretKey: servicemocks.MockStaticSecretKey,
+				Timeouts: &Timeouts{
+					AssumeRole: timeout,
+				},
+			},
+			ExpectedPhase: PhaseAssumeRole,
+		},
+
+		"credentials validation": {
+			Config: &Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				SecretKey: servicemocks.MockStaticSecretKey,
+				Timeouts: &Timeouts{
+					CredentialsValidation: timeout,
+				},
+			},
+			ExpectedPhase: PhaseCredentialsValidation,
+		},
+
+		"account ID lookup": {
+			Config: &Config{
+				AccessKey:           servicemocks.MockStaticAccessKey,
+				SecretKey:           servicemocks.MockStaticSecretKey,
+				SkipCredsValidation: true,
+				Timeouts: &Timeouts{
+					AccountIDLookup: timeout,
+				},
+			},
+			AccountID:     true,
+			ExpectedPhase: PhaseAccountIDLookup,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			ts := slowServer()
+			defer ts.Close()
+
+			expectedEndpoint := ts.URL
+			if testCase.SlowIMDS {
+				os.Setenv("AWS_EC2_METADATA_SERVICE_ENDPOINT", ts.URL)
+			} else {
+				testCase.Config.StsEndpoint = ts.URL
+				testCase.Config.IamEndpoint = ts.URL
+			}
+			testCase.Config.Region = "us-east-1"
+
+			ctx, awsConfig, err := GetAwsConfig(context.Background(), testCase.Config)
+			if testCase.AccountID {
+				if err != nil {
+					t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+				}
+				testCase.Config.SkipCredsValidation = false
+				_, _, err = GetAwsAccountIDAndPartition(ctx, awsConfig, testCase.Config)
+			}
+
+			if err == nil {
+				t.Fatal("expected error, got none")
+			}
+
+			var timeoutErr PhaseTimeoutError
+			if !errors.As(err, &timeoutErr) {
+				t.Fatalf("expected PhaseTimeoutError, got '%[1]T': %[1]s", err)
+			}
+
+			if a, e := timeoutErr.Phase, testCase.ExpectedPhase; a != e {
+				t.Errorf("expected phase %q, got %q", e, a)
+			}
+			if a, e := timeoutErr.Endpoint, expectedEndpoint; a != e {
+				t.Errorf("expected endpoint %q, got %q", e, a)
+			}
+			if a, e := timeoutErr.Timeout, timeout; a != e {
+				t.Errorf("expected timeout %s, got %s", e, a)
+			}
+		})
+	}
+}
+
+// slowServer returns a server that does not respond until the request is canceled.
+func slowServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Cancellation is only detected once the request body has been read
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+}
@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import (
+	"errors"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	awsbase "github.com/hashicorp/aws-sdk-go-base/v2"
+)
+
+// authorizationFailureError is an awsbase.AuthorizationFailureError which also implements awserr.RequestFailure,
+// so that it is handled by the AWS SDK for Go v1 and by code asserting the awserr interfaces in the same way as the original error.
+type authorizationFailureError struct {
+	awserr.RequestFailure
+	decodedMessage string
+}
+
+func (e authorizationFailureError) Error() string {
+	return e.Unwrap().Error()
+}
+
+// Unwrap returns the awsbase.AuthorizationFailureError, which in turn wraps the original error.
+func (e authorizationFailureError) Unwrap() error {
+	return awsbase.AuthorizationFailureError{
+		Err:            e.RequestFailure,
+		DecodedMessage: e.decodedMessage,
+	}
+}
+
+// authorizationMessageDecoderHandler decodes encoded authorization failure messages in request errors.
+// It is an AfterRetry handler following the SDK's, which clears the error of attempts that will be retried,
+// so that the message is decoded once, after the last attempt, and after the error has been unmarshaled by the service client's handlers.
+func authorizationMessageDecoderHandler(awsConfig awsv2.Config, c *awsbase.Config) request.NamedHandler {
+	return request.NamedHandler{
+		Name: "TF_AWS_AuthorizationMessageDecoder",
+		Fn: func(r *request.Request) {
+			var reqErr awserr.RequestFailure
+			if !errors.As(r.Error, &reqErr) {
+				return
+			}
+
+			var authErr awsbase.AuthorizationFailureError
+			if err := awsbase.DecodeAuthorizationFailureMessage(r.Context(), awsConfig, c, reqErr); errors.As(err, &authErr) {
+				r.Error = authorizationFailureError{
+					RequestFailure: reqErr,
+					decodedMessage: authErr.DecodedMessage,
+				}
+			}
+		},
+	}
+}
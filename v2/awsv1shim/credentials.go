@@ -11,6 +11,7 @@ import ( // nosemgrep: no-sdkv2-imports-in-awsv1shim
 
 	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/invalidate"
 )
 
 type v2CredentialsProvider struct {
@@ -31,19 +32,33 @@ type v2CredentialsProvider struct {
 // Since the SDK v1 `credentials.Credentials` handles expiry, it has an `Expire` function to explicitly expire credentials. This is
 // used, for example, in the SDK v1 default retry handler to catch an expired credentials error. Because of this, the result of
 // `RetrieveWithContext` cannot be cached in `v2CredentialsProvider`.
-// NOTE: Since the `Expire()` call is not passed up the chain, the (v2)`aws.CredentialsCache` will not have its cache cleared. This
-// may cause problems if a credential is revoked early. If this becomes a problem, every call to `RetrieveWithContext` may need to
-// call `Invalidate()` on the (v2)`aws.CredentialsCache`. In practice, `RetrieveWithContext` is rarely called, so this is not likely
-// to have a significant impact.
+// `RetrieveWithContext` is only called before the previously retrieved credentials expire when `Expire()` has been called. In that
+// case, if the (v2)`aws.CredentialsCache` still holds the same credentials, it is invalidated, along with any caches it wraps, so that
+// SDK v1 and v2 clients sharing the cache both use newly retrieved credentials. If the cache has already been refreshed, for example by an SDK v2 client that
+// received an expired credentials error, the refreshed credentials are used as-is.
 //
 // The expiry information is cached in `v2CredentialsProvider` because the SDK v1 model handles expiry separately from the credential
 // information, and otherwise calling `IsExpired()` and `ExpiresAt()` would potentially call the actual credential provider on each call.
+//
+// `ExpiresAt()` reports the expiry as adjusted by the (v2)`aws.CredentialsCache`, which is brought forward by its `ExpiryWindow`
+// and jitter. This keeps SDK v1 clients refreshing credentials at the same time as SDK v2 clients sharing the same cache.
 
 func (p *v2CredentialsProvider) RetrieveWithContext(ctx credentials.Context) (credentials.Value, error) {
 	v2creds, err := p.provider.Retrieve(ctx)
 	if err != nil {
 		return credentials.Value{}, err
 	}
+
+	if expired := p.credentials(); expired != nil && sameCredentials(v2creds, *expired) {
+		if _, ok := p.provider.(invalidate.Invalidator); ok {
+			invalidate.Credentials(p.provider)
+
+			v2creds, err = p.provider.Retrieve(ctx)
+			if err != nil {
+				return credentials.Value{}, err
+			}
+		}
+	}
 	p.v2creds.Store(&v2creds)
 
 	return credentials.Value{
@@ -88,6 +103,10 @@ func (p *v2CredentialsProvider) credentials() *awsv2.Credentials {
 	return nil
 }
 
+func sameCredentials(a, b awsv2.Credentials) bool {
+	return a.AccessKeyID == b.AccessKeyID && a.SessionToken == b.SessionToken
+}
+
 func newV2Credentials(v2provider awsv2.CredentialsProvider) *credentials.Credentials {
 	return credentials.NewCredentials(&v2CredentialsProvider{
 		provider: v2provider,
@@ -6,6 +6,7 @@ package awsv1shim
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -14,7 +15,10 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/logfilter"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/metrics"
 	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"go.opentelemetry.io/otel/attribute"
@@ -54,40 +58,101 @@ type durationKeyT string
 
 const durationKey durationKeyT = "request-duration"
 
+type trafficEntryKeyT string
+
+const trafficEntryKey trafficEntryKeyT = "traffic-entry"
+
+type logDecisionKeyT string
+
+const (
+	logDecisionKey logDecisionKeyT = "log-decision"
+	requestBodyKey logDecisionKeyT = "request-body"
+)
+
+const (
+	requestBodyField  = "http.request.body"
+	responseBodyField = "http.response.body"
+	attemptField      = "aws.attempt"
+)
+
+// requestResponseLogger logs HTTP requests and responses, records API call metrics, and writes the HTTP traffic log.
+// Request and response logging can be suppressed independently of metrics and the HTTP traffic log.
+type requestResponseLogger struct {
+	suppressLog bool
+	filter      *logfilter.Filter
+	metrics     *metrics.Recorder
+	trafficLog  *logging.TrafficLog
+}
+
 // Replaces the built-in logging middleware from https://github.com/aws/aws-sdk-go/blob/main/aws/client/logger.go
 // We want access to the request struct, and cannot get it from the built-in.
 // The typical route of adding logging to the http.RoundTripper doesn't work for the AWS SDK for Go v1 without forcing us to manually implement
 // configuration that the SDK handles for us.
-var requestLogger = request.NamedHandler{
-	Name: "TF_AWS_RequestLogger",
-	Fn:   logRequest,
+func (l requestResponseLogger) requestHandler() request.NamedHandler {
+	return request.NamedHandler{
+		Name: "TF_AWS_RequestLogger",
+		Fn:   l.logRequest,
+	}
 }
 
-func logRequest(r *request.Request) {
+func (l requestResponseLogger) logRequest(r *request.Request) {
 	ctx := r.Context()
 
 	ctx = setAWSFields(ctx, r)
 
-	bodySeekable := aws.IsReaderSeekable(r.Body)
-
-	requestFields, err := logging.DecomposeHTTPRequest(r.HTTPRequest)
-	if err != nil {
-		tflog.Error(ctx, fmt.Sprintf("decomposing request: %s", err))
-		return
+	// The request context is kept between attempts, so the filter is evaluated once per API call
+	decision, ok := ctx.Value(logDecisionKey).(logfilter.Decision)
+	if !ok {
+		decision = l.filter.Evaluate(r.ClientInfo.ServiceID, r.Operation.Name)
+		ctx = context.WithValue(ctx, logDecisionKey, decision)
 	}
+	logEnabled := !l.suppressLog && decision.Log
+
+	if logEnabled || l.trafficLog != nil {
+		bodySeekable := aws.IsReaderSeekable(r.Body)
+
+		var requestFields map[string]any
+		if logEnabled {
+			var err error
+			requestFields, err = logging.DecomposeHTTPRequest(r.HTTPRequest)
+			if err != nil {
+				tflog.Error(ctx, fmt.Sprintf("decomposing request: %s", err))
+				return
+			}
+			if decision.BodiesOnErrorOnly {
+				// Deferred until the response status is known
+				ctx = context.WithValue(ctx, requestBodyKey, requestFields[requestBodyField])
+				delete(requestFields, requestBodyField)
+			}
+		}
 
-	if !bodySeekable {
-		r.SetReaderBody(aws.ReadSeekCloser(r.HTTPRequest.Body))
-	}
-	// Reset the request body because dumpRequest will re-wrap the
-	// r.HTTPRequest's Body as a NoOpCloser and will not be reset after
-	// read by the HTTP client reader.
-	if err := r.Error; err != nil {
-		tflog.Error(ctx, fmt.Sprintf("decomposing request: %s", err))
-		return
-	}
+		if l.trafficLog != nil {
+			entry, err := logging.NewTrafficEntry(r.HTTPRequest, time.Now())
+			if err != nil {
+				tflog.Error(ctx, fmt.Sprintf("recording request: %s", err))
+				return
+			}
+			entry.AWSService = r.ClientInfo.ServiceID
+			entry.AWSOperation = r.Operation.Name
+			entry.AWSRegion = aws.StringValue(r.Config.Region)
+			ctx = context.WithValue(ctx, trafficEntryKey, entry)
+		}
+
+		if !bodySeekable {
+			r.SetReaderBody(aws.ReadSeekCloser(r.HTTPRequest.Body))
+		}
+		// Reset the request body because dumpRequest will re-wrap the
+		// r.HTTPRequest's Body as a NoOpCloser and will not be reset after
+		// read by the HTTP client reader.
+		if err := r.Error; err != nil {
+			tflog.Error(ctx, fmt.Sprintf("decomposing request: %s", err))
+			return
+		}
 
-	tflog.Debug(ctx, "HTTP Request Sent", requestFields)
+		if logEnabled {
+			tflog.Debug(ctx, "HTTP Request Sent", requestFields)
+		}
+	}
 
 	ctx = context.WithValue(ctx, durationKey, time.Now())
 
@@ -98,12 +163,14 @@ func logRequest(r *request.Request) {
 // We want access to the response struct, and cannot get it from the built-in.
 // The typical route of adding logging to the http.RoundTripper doesn't work for the AWS SDK for Go v1 without forcing us to manually implement
 // configuration that the SDK handles for us.
-var responseLogger = request.NamedHandler{
-	Name: "TF_AWS_ResponseLogger",
-	Fn:   logResponse,
+func (l requestResponseLogger) responseHandler() request.NamedHandler {
+	return request.NamedHandler{
+		Name: "TF_AWS_ResponseLogger",
+		Fn:   l.logResponse,
+	}
 }
 
-func logResponse(r *request.Request) {
+func (l requestResponseLogger) logResponse(r *request.Request) {
 	ctx := r.Context()
 
 	ctx = setAWSFields(ctx, r)
@@ -113,28 +180,82 @@ func logResponse(r *request.Request) {
 		return
 	}
 
+	trafficEntry, _ := ctx.Value(trafficEntryKey).(*logging.TrafficEntry)
+	decision, _ := ctx.Value(logDecisionKey).(logfilter.Decision)
+	logEnabled := !l.suppressLog && decision.Log
+
+	// The Unmarshal handlers are not run when the request could not be sent
+	if r.Error != nil {
+		elapsed := attemptDuration(r)
+		l.metrics.RecordAttempt(ctx, attemptMetrics(r, elapsed))
+		if trafficEntry != nil {
+			trafficEntry.SetError(r.Error, elapsed)
+			l.writeTrafficEntry(ctx, trafficEntry)
+		}
+		if logEnabled {
+			fields := map[string]any{
+				"http.duration":     elapsed.Milliseconds(),
+				attemptField:        r.RetryCount + 1,
+				"aws.error_message": logging.MaskAWSAccessKey(r.Error.Error()),
+			}
+			if decision.BodiesOnErrorOnly {
+				// The request body was withheld from the request log
+				if requestBody := ctx.Value(requestBodyKey); requestBody != nil {
+					fields[requestBodyField] = requestBody
+				}
+			}
+			tflog.Debug(ctx, "HTTP Request Failed", fields)
+		}
+		return
+	}
+
 	bodyBuffer := bytes.NewBuffer(nil)
 
-	r.HTTPResponse.Body = &teeReaderCloser{
-		Reader: io.TeeReader(r.HTTPResponse.Body, bodyBuffer),
-		Source: r.HTTPResponse.Body,
+	if logEnabled || trafficEntry != nil {
+		r.HTTPResponse.Body = &teeReaderCloser{
+			Reader: io.TeeReader(r.HTTPResponse.Body, bodyBuffer),
+			Source: r.HTTPResponse.Body,
+		}
 	}
 
 	handlerFn := func(req *request.Request) {
 		ctx := r.Context()
 
-		var elapsed time.Duration
-		if start, ok := ctx.Value(durationKey).(time.Time); ok {
-			elapsed = time.Since(start)
-		}
+		elapsed := attemptDuration(r)
 
 		ctx = setAWSFields(ctx, r)
 
-		responseFields, err := decomposeHTTPResponse(r.HTTPResponse, bodyBuffer, elapsed)
+		l.metrics.RecordAttempt(ctx, attemptMetrics(r, elapsed))
+
+		body := bodyBuffer.Bytes()
+
+		if trafficEntry != nil {
+			trafficEntry.SetResponse(r.HTTPResponse, body, elapsed)
+			l.writeTrafficEntry(ctx, trafficEntry)
+		}
+
+		if !logEnabled {
+			return
+		}
+
+		responseFields, err := decomposeHTTPResponse(r.HTTPResponse, bytes.NewReader(body), elapsed)
 		if err != nil {
 			tflog.Error(ctx, fmt.Sprintf("decomposing response: %s", err))
 			return
 		}
+		if decision.BodiesOnErrorOnly {
+			filterBodies(responseFields, r.HTTPResponse.StatusCode, ctx.Value(requestBodyKey))
+		}
+		responseFields[attemptField] = r.RetryCount + 1
+		if r.Error != nil {
+			var awsErr awserr.Error
+			if errors.As(r.Error, &awsErr) {
+				responseFields["aws.error_code"] = awsErr.Code()
+				responseFields["aws.error_message"] = logging.MaskAWSAccessKey(awsErr.Message())
+			} else {
+				responseFields["aws.error_message"] = logging.MaskAWSAccessKey(r.Error.Error())
+			}
+		}
 		tflog.Debug(ctx, "HTTP Response Received", responseFields)
 	}
 
@@ -148,6 +269,60 @@ func logResponse(r *request.Request) {
 	})
 }
 
+func (l requestResponseLogger) writeTrafficEntry(ctx context.Context, entry *logging.TrafficEntry) {
+	if err := l.trafficLog.Write(entry); err != nil {
+		tflog.Warn(ctx, "Unable to write HTTP traffic log entry", map[string]any{
+			"error": err,
+		})
+	}
+}
+
+// filterBodies removes the response body from the response log fields unless the response is an error.
+// For error responses, the request body that was withheld from the request log is added instead.
+func filterBodies(responseFields map[string]any, statusCode int, requestBody any) {
+	if statusCode < http.StatusBadRequest {
+		delete(responseFields, responseBodyField)
+		return
+	}
+	if requestBody != nil {
+		responseFields[requestBodyField] = requestBody
+	}
+}
+
+func attemptDuration(r *request.Request) time.Duration {
+	if start, ok := r.Context().Value(durationKey).(time.Time); ok {
+		return time.Since(start)
+	}
+	return 0
+}
+
+func attemptMetrics(r *request.Request, elapsed time.Duration) metrics.Attempt {
+	attempt := metrics.Attempt{
+		Service:   r.ClientInfo.ServiceID,
+		Operation: r.Operation.Name,
+		Region:    aws.StringValue(r.Config.Region),
+		Duration:  elapsed,
+	}
+
+	if r.HTTPResponse != nil {
+		attempt.StatusCode = r.HTTPResponse.StatusCode
+	}
+
+	if r.Error != nil {
+		attempt.Failed = true
+
+		// Only API errors have a response status code. Errors sending the request are recorded with metrics.UnknownErrorCode.
+		var reqErr awserr.RequestFailure
+		if errors.As(r.Error, &reqErr) {
+			attempt.ErrorCode = reqErr.Code()
+		}
+
+		attempt.Throttled = request.IsErrorThrottle(r.Error)
+	}
+
+	return attempt
+}
+
 type teeReaderCloser struct {
 	// io.Reader will be a tee reader that is used during logging.
 	// This structure will read from a body and write the contents to a logger.
@@ -169,6 +344,8 @@ func decomposeHTTPResponse(resp *http.Response, body io.Reader, elapsed time.Dur
 
 	attributes = append(attributes, logging.DecomposeResponseHeaders(resp)...)
 
+	attributes = append(attributes, logging.DecomposeResponseRequestIDs(resp)...)
+
 	bodyAttribute, err := decomposeResponseBody(body)
 	if err != nil {
 		return nil, err
@@ -191,5 +368,5 @@ func decomposeResponseBody(bodyReader io.Reader) (attribute.KeyValue, error) {
 
 	body := logging.MaskAWSAccessKey(string(respBytes))
 
-	return attribute.String("http.response.body", body), nil
+	return attribute.String(responseBodyField, body), nil
 }
@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/metrics"
+)
+
+type callStartKeyT string
+
+const callStartKey callStartKeyT = "call-start"
+
+// callMetricsHandlers returns the handlers recording the duration of each API call, including all attempts.
+// The Validate handlers are run once per call, before the first attempt, and the Complete handlers after the last attempt.
+func callMetricsHandlers(recorder *metrics.Recorder) (start, complete request.NamedHandler) {
+	start = request.NamedHandler{
+		Name: "TF_AWS_CallMetricsStart",
+		Fn: func(r *request.Request) {
+			r.SetContext(context.WithValue(r.Context(), callStartKey, time.Now()))
+		},
+	}
+	complete = request.NamedHandler{
+		Name: "TF_AWS_CallMetricsRecorder",
+		Fn: func(r *request.Request) {
+			start, ok := r.Context().Value(callStartKey).(time.Time)
+			if !ok {
+				return
+			}
+			recorder.RecordCall(r.Context(), metrics.Call{
+				Service:   r.ClientInfo.ServiceID,
+				Operation: r.Operation.Name,
+				Region:    aws.StringValue(r.Config.Region),
+				Duration:  time.Since(start),
+			})
+		},
+	}
+	return start, complete
+}
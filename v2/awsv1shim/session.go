@@ -14,10 +14,12 @@ import ( // nosemgrep: no-sdkv2-imports-in-awsv1shim
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	awsbase "github.com/hashicorp/aws-sdk-go-base/v2"
-	"github.com/hashicorp/aws-sdk-go-base/v2/awsv1shim/v2/tfawserr"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/awsconfig"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/constants"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/logfilter"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/metrics"
 	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
+	"github.com/hashicorp/aws-sdk-go-base/v2/tfawserr"
 )
 
 // getSessionOptions attempts to return valid AWS Go SDK session authentication
@@ -83,6 +85,12 @@ func GetSession(ctx context.Context, awsC *awsv2.Config, c *awsbase.Config) (*se
 	ctx, logger := logging.New(ctx, loggerName)
 	ctx = logging.RegisterLogger(ctx, logger)
 
+	// The session uses the credentials of awsC, so it does not resolve credentials itself,
+	// but awsC may not have been returned by GetAwsConfig for c in offline mode.
+	if err := c.CheckOffline(); err != nil {
+		return nil, err
+	}
+
 	options, err := getSessionOptions(ctx, awsC, c)
 	if err != nil {
 		return nil, err
@@ -105,9 +113,40 @@ func GetSession(ctx context.Context, awsC *awsv2.Config, c *awsbase.Config) (*se
 
 	sess.Handlers.Build.PushBack(userAgentFromContextHandler)
 
-	if !c.SuppressDebugLog {
-		sess.Handlers.Send.PushFrontNamed(requestLogger)
-		sess.Handlers.Send.PushBackNamed(responseLogger)
+	recorder, err := metrics.New(c.MeterProvider)
+	if err != nil {
+		return nil, fmt.Errorf("configuring metrics: %w", err)
+	}
+
+	trafficLog, err := c.HTTPTrafficLog()
+	if err != nil {
+		return nil, err
+	}
+
+	logFilter, err := logfilter.New(c.DebugLogFilter)
+	if err != nil {
+		return nil, fmt.Errorf("configuring debug log filter: %w", err)
+	}
+
+	if !c.SuppressDebugLog || recorder != nil || trafficLog != nil {
+		l := requestResponseLogger{
+			suppressLog: c.SuppressDebugLog,
+			filter:      logFilter,
+			metrics:     recorder,
+			trafficLog:  trafficLog,
+		}
+		sess.Handlers.Send.PushFrontNamed(l.requestHandler())
+		sess.Handlers.Send.PushBackNamed(l.responseHandler())
+	}
+
+	if recorder != nil {
+		start, complete := callMetricsHandlers(recorder)
+		sess.Handlers.Validate.PushFrontNamed(start)
+		sess.Handlers.Complete.PushBackNamed(complete)
+	}
+
+	if c.DecodeAuthorizationMessages {
+		sess.Handlers.AfterRetry.PushBackNamed(authorizationMessageDecoderHandler(*awsC, c))
 	}
 
 	// Add custom input from ENV to the User-Agent request header
@@ -129,11 +168,21 @@ func GetSession(ctx context.Context, awsC *awsv2.Config, c *awsbase.Config) (*se
 	sess.Handlers.Retry.PushBack(func(r *request.Request) {
 		logger := logging.RetrieveLogger(r.Context())
 
-		if r.IsErrorExpired() {
-			logger.Warn(ctx, "Disabling retries after next request due to expired credentials", map[string]any{
-				"error": r.Error,
-			})
-			r.Retryable = aws.Bool(false)
+		if tfawserr.IsExpiredCredentials(r.Error) {
+			// Retry once with refreshed credentials. The credentials are expired by the SDK's AfterRetry handler,
+			// which also invalidates the credentials cache shared with AWS SDK for Go v2 clients.
+			if r.Context().Value(expiredCredentialsRetriedKey{}) == nil {
+				logger.Info(ctx, "Retrying request with refreshed credentials due to expired credentials", map[string]any{
+					"error": r.Error,
+				})
+				r.SetContext(context.WithValue(r.Context(), expiredCredentialsRetriedKey{}, true))
+				r.Retryable = aws.Bool(true)
+			} else {
+				logger.Warn(ctx, "Disabling retries after next request due to expired credentials", map[string]any{
+					"error": r.Error,
+				})
+				r.Retryable = aws.Bool(false)
+			}
 		}
 
 		if r.RetryCount < constants.MaxNetworkRetryCount {
@@ -142,15 +191,9 @@ func GetSession(ctx context.Context, awsC *awsv2.Config, c *awsbase.Config) (*se
 
 		// RequestError: send request failed
 		// caused by: Post https://FQDN/: dial tcp: lookup FQDN: no such host
-		if tfawserr.ErrMessageAndOrigErrContain(r.Error, request.ErrCodeRequestError, "send request failed", "no such host") {
-			logger.Warn(ctx, "Disabling retries after next request due to networking error", map[string]any{
-				"error": r.Error,
-			})
-			r.Retryable = aws.Bool(false)
-		}
-		// RequestError: send request failed
+		// or
 		// caused by: Post https://FQDN/: dial tcp IPADDRESS:443: connect: connection refused
-		if tfawserr.ErrMessageAndOrigErrContain(r.Error, request.ErrCodeRequestError, "send request failed", "connection refused") {
+		if tfawserr.IsEndpointUnreachable(r.Error) {
 			logger.Warn(ctx, "Disabling retries after next request due to networking error", map[string]any{
 				"error": r.Error,
 			})
@@ -161,6 +204,9 @@ func GetSession(ctx context.Context, awsC *awsv2.Config, c *awsbase.Config) (*se
 	return sess, nil
 }
 
+// expiredCredentialsRetriedKey marks the context of a request that has been retried due to expired credentials.
+type expiredCredentialsRetriedKey struct{}
+
 func convertFIPSEndpointState(value awsv2.FIPSEndpointState) endpoints.FIPSEndpointState {
 	switch value {
 	case awsv2.FIPSEndpointStateEnabled:
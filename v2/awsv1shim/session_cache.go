@@ -0,0 +1,70 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import ( // nosemgrep: no-sdkv2-imports-in-awsv1shim
+	"context"
+	"fmt"
+	"time"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awsbase "github.com/hashicorp/aws-sdk-go-base/v2"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/cache"
+)
+
+// SessionCache memoizes the results of GetSession, keyed by the fingerprint of the Config.
+// The aws.Config passed to GetSession must be the one returned by GetAwsConfig for the Config,
+// for example by an awsbase.ConfigCache.
+// Concurrent calls for the same Config share a single call of GetSession.
+// Cached sessions are evicted after the TTL or when their credentials expire, whichever is first.
+// A SessionCache is safe for concurrent use.
+type SessionCache struct {
+	cache *cache.Cache[*session.Session]
+	ttl   time.Duration
+}
+
+// NewSessionCache returns a SessionCache. A zero ttl caches sessions until their credentials expire.
+func NewSessionCache(ttl time.Duration) *SessionCache {
+	return &SessionCache{
+		cache: cache.New[*session.Session](),
+		ttl:   ttl,
+	}
+}
+
+// GetSession returns a copy of the cached result of GetSession for c, calling GetSession if there is none.
+func (sc *SessionCache) GetSession(ctx context.Context, awsC *awsv2.Config, c *awsbase.Config) (*session.Session, error) {
+	key, err := c.Fingerprint()
+	if err != nil {
+		return nil, fmt.Errorf("fingerprinting configuration: %w", err)
+	}
+
+	sess, err := sc.cache.Get(ctx, key, func(ctx context.Context) (*session.Session, time.Time, error) {
+		sess, err := GetSession(ctx, awsC, c)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		return sess, awsbase.CacheExpiry(ctx, awsC.Credentials, sc.ttl), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sess.Copy(), nil
+}
+
+// Invalidate removes the cached session for c.
+func (sc *SessionCache) Invalidate(c *awsbase.Config) error {
+	key, err := c.Fingerprint()
+	if err != nil {
+		return fmt.Errorf("fingerprinting configuration: %w", err)
+	}
+	sc.cache.Delete(key)
+	return nil
+}
+
+// Purge removes all cached sessions.
+func (sc *SessionCache) Purge() {
+	sc.cache.Purge()
+}
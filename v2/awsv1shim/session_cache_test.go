@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsv1shim
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	awsbase "github.com/hashicorp/aws-sdk-go-base/v2"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+func TestSessionCache(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	config := &awsbase.Config{
+		AccessKey:           servicemocks.MockStaticAccessKey,
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+	}
+
+	ctx, awsConfig, err := awsbase.GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	cache := NewSessionCache(time.Hour)
+
+	const callers = 5
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.GetSession(ctx, &awsConfig, config); err != nil {
+				t.Errorf("error in GetSession() '%[1]T': %[1]s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	first, err := cache.GetSession(ctx, &awsConfig, config)
+	if err != nil {
+		t.Fatalf("error in GetSession() '%[1]T': %[1]s", err)
+	}
+	second, err := cache.GetSession(ctx, &awsConfig, config)
+	if err != nil {
+		t.Fatalf("error in GetSession() '%[1]T': %[1]s", err)
+	}
+
+	if first == second {
+		t.Error("expected cached sessions to be copies")
+	}
+	if first.Config.Credentials != second.Config.Credentials {
+		t.Error("expected cached sessions to share credentials")
+	}
+
+	if err := cache.Invalidate(config); err != nil {
+		t.Fatalf("unexpected Invalidate() error: %s", err)
+	}
+
+	third, err := cache.GetSession(ctx, &awsConfig, config)
+	if err != nil {
+		t.Fatalf("error in GetSession() '%[1]T': %[1]s", err)
+	}
+	if first.Config.Credentials == third.Config.Credentials {
+		t.Error("expected new session after invalidation")
+	}
+}
@@ -6,20 +6,24 @@ package awsv1shim
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	retryv2 "github.com/aws/aws-sdk-go-v2/aws/retry"
 	configv2 "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	stsv2 "github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/client"
@@ -28,12 +32,16 @@ import (
 	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	awsbase "github.com/hashicorp/aws-sdk-go-base/v2"
 	"github.com/hashicorp/aws-sdk-go-base/v2/awsv1shim/v2/mockdata"
+	"github.com/hashicorp/aws-sdk-go-base/v2/awsv1shim/v2/tfawserr"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/constants"
+	"github.com/hashicorp/aws-sdk-go-base/v2/internal/metrics"
 	"github.com/hashicorp/aws-sdk-go-base/v2/internal/test"
+	"github.com/hashicorp/aws-sdk-go-base/v2/logging"
 	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
 	"github.com/hashicorp/aws-sdk-go-base/v2/useragent"
 	"github.com/hashicorp/terraform-plugin-log/tflogtest"
@@ -2212,7 +2220,7 @@ web_identity_token_file = no-such-file
 			},
 			ExpectedCredentialsValue: mockdata.MockStsAssumeRoleWithWebIdentityCredentials,
 			ExpectedError: func(err error) bool {
-				return strings.Contains(err.Error(), "one of WebIdentityToken, WebIdentityTokenFile must be set")
+				return strings.Contains(err.Error(), "one of WebIdentityToken, WebIdentityTokenFile, WebIdentityTokenRetriever must be set")
 			},
 		},
 	}
@@ -2349,11 +2357,12 @@ func TestSessionRetryHandlers(t *testing.T) {
 	const maxRetries = 25
 
 	testcases := []struct {
-		Description              string
-		RetryCount               int
-		Error                    error
-		ExpectedRetryableValue   bool
-		ExpectRetryToBeAttempted bool
+		Description               string
+		RetryCount                int
+		ExpiredCredentialsRetried bool
+		Error                     error
+		ExpectedRetryableValue    bool
+		ExpectRetryToBeAttempted  bool
 	}{
 		{
 			Description:              "other error under maxRetries",
@@ -2370,25 +2379,35 @@ func TestSessionRetryHandlers(t *testing.T) {
 			ExpectRetryToBeAttempted: false, // Does not actually get retried, because over max retry limit
 		},
 		{
-			Description:              "ExpiredToken error no retries",
-			RetryCount:               maxRetries,
+			Description:              "ExpiredToken error retried once",
+			RetryCount:               0,
 			Error:                    awserr.New("ExpiredToken", "The security token included in the request is expired", nil),
-			ExpectedRetryableValue:   false,
-			ExpectRetryToBeAttempted: false,
+			ExpectedRetryableValue:   true,
+			ExpectRetryToBeAttempted: true,
 		},
 		{
-			Description:              "ExpiredTokenException error no retries",
-			RetryCount:               maxRetries,
-			Error:                    awserr.New("ExpiredTokenException", "The security token included in the request is expired", nil),
-			ExpectedRetryableValue:   false,
-			ExpectRetryToBeAttempted: false,
+			Description:               "ExpiredToken error no retries",
+			RetryCount:                maxRetries,
+			ExpiredCredentialsRetried: true,
+			Error:                     awserr.New("ExpiredToken", "The security token included in the request is expired", nil),
+			ExpectedRetryableValue:    false,
+			ExpectRetryToBeAttempted:  false,
 		},
 		{
-			Description:              "RequestExpired error no retries",
-			RetryCount:               maxRetries,
-			Error:                    awserr.New("RequestExpired", "The security token included in the request is expired", nil),
-			ExpectedRetryableValue:   false,
-			ExpectRetryToBeAttempted: false,
+			Description:               "ExpiredTokenException error no retries",
+			RetryCount:                maxRetries,
+			ExpiredCredentialsRetried: true,
+			Error:                     awserr.New("ExpiredTokenException", "The security token included in the request is expired", nil),
+			ExpectedRetryableValue:    false,
+			ExpectRetryToBeAttempted:  false,
+		},
+		{
+			Description:               "RequestExpired error no retries",
+			RetryCount:                maxRetries,
+			ExpiredCredentialsRetried: true,
+			Error:                     awserr.New("RequestExpired", "The security token included in the request is expired", nil),
+			ExpectedRetryableValue:    false,
+			ExpectRetryToBeAttempted:  false,
 		},
 		{
 			Description:              "send request no such host failed under MaxNetworkRetryCount",
@@ -2461,6 +2480,9 @@ func TestSessionRetryHandlers(t *testing.T) {
 
 			request, _ := iamconn.GetUserRequest(&iam.GetUserInput{})
 			request.RetryCount = testcase.RetryCount
+			if testcase.ExpiredCredentialsRetried {
+				request.SetContext(context.WithValue(request.Context(), expiredCredentialsRetriedKey{}, true))
+			}
 			request.Error = testcase.Error
 
 			// Prevent the retryer from using the default retry delay
@@ -2540,3 +2562,853 @@ func TestLogger(t *testing.T) {
 		}
 	}
 }
+
+func TestSessionMetrics(t *testing.T) {
+	testCases := map[string]struct {
+		SuppressDebugLog   bool
+		MockIamEndpoints   []*servicemocks.MockEndpoint
+		ExpectError        bool
+		ExpectedErrorAttrs []map[string]any
+	}{
+		"success": {
+			MockIamEndpoints: []*servicemocks.MockEndpoint{
+				{
+					Request:  &servicemocks.MockRequest{Method: http.MethodPost, Uri: "/", Body: "Action=GetUser&Version=2010-05-08"},
+					Response: &servicemocks.MockResponse{StatusCode: http.StatusOK, Body: servicemocks.IamResponse_GetUser_valid, ContentType: "text/xml"},
+				},
+			},
+		},
+		"success with debug log suppressed": {
+			SuppressDebugLog: true,
+			MockIamEndpoints: []*servicemocks.MockEndpoint{
+				{
+					Request:  &servicemocks.MockRequest{Method: http.MethodPost, Uri: "/", Body: "Action=GetUser&Version=2010-05-08"},
+					Response: &servicemocks.MockResponse{StatusCode: http.StatusOK, Body: servicemocks.IamResponse_GetUser_valid, ContentType: "text/xml"},
+				},
+			},
+		},
+		"access denied": {
+			MockIamEndpoints: []*servicemocks.MockEndpoint{
+				{
+					Request:  &servicemocks.MockRequest{Method: http.MethodPost, Uri: "/", Body: "Action=GetUser&Version=2010-05-08"},
+					Response: &servicemocks.MockResponse{StatusCode: http.StatusForbidden, Body: servicemocks.IamResponse_GetUser_unauthorized, ContentType: "text/xml"},
+				},
+			},
+			ExpectError: true,
+			ExpectedErrorAttrs: []map[string]any{
+				{
+					"aws.service":      "IAM",
+					"aws.operation":    "GetUser",
+					"aws.region":       "us-east-1",
+					"aws.error_code":   "AccessDenied",
+					"http.status_code": int64(403),
+				},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			ts := servicemocks.MockAwsApiServer("IAM", testCase.MockIamEndpoints)
+			defer ts.Close()
+
+			provider := &test.MeterProvider{}
+
+			config := &awsbase.Config{
+				AccessKey:           servicemocks.MockStaticAccessKey,
+				MeterProvider:       provider,
+				Region:              "us-east-1",
+				SecretKey:           servicemocks.MockStaticSecretKey,
+				SkipCredsValidation: true,
+				SuppressDebugLog:    testCase.SuppressDebugLog,
+			}
+
+			ctx, awsConfig, err := awsbase.GetAwsConfig(context.Background(), config)
+			if err != nil {
+				t.Fatalf("GetAwsConfig: unexpected '%[1]T': %[1]s", err)
+			}
+
+			sess, err := GetSession(ctx, &awsConfig, config)
+			if err != nil {
+				t.Fatalf("GetSession: unexpected '%[1]T': %[1]s", err)
+			}
+
+			conn := iam.New(sess, &aws.Config{Endpoint: aws.String(ts.URL)})
+			_, err = conn.GetUserWithContext(ctx, &iam.GetUserInput{})
+			if err != nil && !testCase.ExpectError {
+				t.Fatalf("expected no error, got '%[1]T' error: %[1]s", err)
+			}
+			if err == nil && testCase.ExpectError {
+				t.Fatal("expected error, got none")
+			}
+
+			attempts := provider.Measurements(metrics.AttemptsName)
+			if a, e := len(attempts), 1; a != e {
+				t.Fatalf("expected %d attempts, got %d", e, a)
+			}
+			if a, e := attempts[0].Attributes["aws.operation"], "GetUser"; a != e {
+				t.Errorf("expected operation %q, got %q", e, a)
+			}
+
+			if a, e := len(provider.Measurements(metrics.AttemptDurationName)), 1; a != e {
+				t.Errorf("expected %d duration measurements, got %d", e, a)
+			}
+
+			if a, e := len(provider.Measurements(metrics.CallDurationName)), 1; a != e {
+				t.Errorf("expected %d call duration measurements, got %d", e, a)
+			}
+
+			var errorAttrs []map[string]any
+			for _, m := range provider.Measurements(metrics.ErrorsName) {
+				errorAttrs = append(errorAttrs, m.Attributes)
+			}
+			if diff := cmp.Diff(errorAttrs, testCase.ExpectedErrorAttrs); diff != "" {
+				t.Errorf("unexpected error measurements: (- got, + expected)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSessionHTTPTrafficLog(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	ts := servicemocks.MockAwsApiServer("IAM", []*servicemocks.MockEndpoint{
+		{
+			Request:  &servicemocks.MockRequest{Method: http.MethodPost, Uri: "/", Body: "Action=GetUser&Version=2010-05-08"},
+			Response: &servicemocks.MockResponse{StatusCode: http.StatusOK, Body: servicemocks.IamResponse_GetUser_valid, ContentType: "text/xml"},
+		},
+	})
+	defer ts.Close()
+
+	filename := filepath.Join(t.TempDir(), "traffic.jsonl")
+	defer logging.CloseTrafficLogs()
+
+	config := &awsbase.Config{
+		AccessKey:           servicemocks.MockStaticAccessKey,
+		HTTPTrafficLogFile:  filename,
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+		SuppressDebugLog:    true,
+	}
+
+	ctx, awsConfig, err := awsbase.GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GetAwsConfig: unexpected '%[1]T': %[1]s", err)
+	}
+
+	sess, err := GetSession(ctx, &awsConfig, config)
+	if err != nil {
+		t.Fatalf("GetSession: unexpected '%[1]T': %[1]s", err)
+	}
+
+	conn := iam.New(sess, &aws.Config{Endpoint: aws.String(ts.URL)})
+	if _, err := conn.GetUserWithContext(ctx, &iam.GetUserInput{}); err != nil {
+		t.Fatalf("GetUser: unexpected '%[1]T': %[1]s", err)
+	}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("reading HTTP traffic log: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if a, e := len(lines), 1; a != e {
+		t.Fatalf("expected %d entries, got %d", e, a)
+	}
+
+	var entry struct {
+		AWSOperation string `json:"_awsOperation"`
+		Response     struct {
+			Status  int `json:"status"`
+			Content struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("decoding JSON line: %s", err)
+	}
+
+	if a, e := entry.AWSOperation, "GetUser"; a != e {
+		t.Errorf("expected operation %q, got %q", e, a)
+	}
+	if a, e := entry.Response.Status, http.StatusOK; a != e {
+		t.Errorf("expected status %d, got %d", e, a)
+	}
+	if a, e := entry.Response.Content.Text, "AIDA*************MPLE"; !strings.Contains(a, e) {
+		t.Errorf("expected response body to contain masked user ID %q, got %q", e, a)
+	}
+}
+
+func TestSessionDebugLogFilter(t *testing.T) {
+	testCases := map[string]struct {
+		Filter               *awsbase.DebugLogFilter
+		ExpectedRequestLogs  int
+		ExpectRequestBody    bool
+		ExpectedResponseLogs int
+		ExpectResponseBody   bool
+	}{
+		"no filter": {
+			ExpectedRequestLogs:  3,
+			ExpectedResponseLogs: 3,
+			ExpectRequestBody:    true,
+			ExpectResponseBody:   true,
+		},
+		"sampled": {
+			Filter: &awsbase.DebugLogFilter{
+				Rules: []awsbase.DebugLogRule{
+					{Service: "IAM", Operation: "GetUser", SampleRate: 2},
+				},
+			},
+			ExpectedRequestLogs:  2,
+			ExpectedResponseLogs: 2,
+			ExpectRequestBody:    true,
+			ExpectResponseBody:   true,
+		},
+		"excluded": {
+			Filter: &awsbase.DebugLogFilter{
+				Rules: []awsbase.DebugLogRule{
+					{Service: "IAM", Exclude: true},
+				},
+			},
+		},
+		"bodies on error only": {
+			Filter: &awsbase.DebugLogFilter{
+				BodiesOnErrorOnly: true,
+			},
+			ExpectedRequestLogs:  3,
+			ExpectedResponseLogs: 3,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			endpoint := &servicemocks.MockEndpoint{
+				Request:  &servicemocks.MockRequest{Method: http.MethodPost, Uri: "/", Body: "Action=GetUser&Version=2010-05-08"},
+				Response: &servicemocks.MockResponse{StatusCode: http.StatusOK, Body: servicemocks.IamResponse_GetUser_valid, ContentType: "text/xml"},
+			}
+			ts := servicemocks.MockAwsApiServer("IAM", []*servicemocks.MockEndpoint{endpoint, endpoint, endpoint})
+			defer ts.Close()
+
+			config := &awsbase.Config{
+				AccessKey:           servicemocks.MockStaticAccessKey,
+				DebugLogFilter:      testCase.Filter,
+				Region:              "us-east-1",
+				SecretKey:           servicemocks.MockStaticSecretKey,
+				SkipCredsValidation: true,
+			}
+
+			var buf bytes.Buffer
+			ctx := tflogtest.RootLogger(context.Background(), &buf)
+
+			ctx, awsConfig, err := awsbase.GetAwsConfig(ctx, config)
+			if err != nil {
+				t.Fatalf("GetAwsConfig: unexpected '%[1]T': %[1]s", err)
+			}
+
+			sess, err := GetSession(ctx, &awsConfig, config)
+			if err != nil {
+				t.Fatalf("GetSession: unexpected '%[1]T': %[1]s", err)
+			}
+
+			buf.Reset()
+
+			conn := iam.New(sess, &aws.Config{Endpoint: aws.String(ts.URL)})
+			for i := 0; i < 3; i++ {
+				if _, err := conn.GetUserWithContext(ctx, &iam.GetUserInput{}); err != nil {
+					t.Fatalf("GetUser: unexpected '%[1]T': %[1]s", err)
+				}
+			}
+
+			lines, err := tflogtest.MultilineJSONDecode(&buf)
+			if err != nil {
+				t.Fatalf("decoding log lines: %s", err)
+			}
+
+			var requestLogs, responseLogs int
+			for _, line := range lines {
+				switch line["@message"] {
+				case "HTTP Request Sent":
+					requestLogs++
+					if _, ok := line["http.request.body"]; ok != testCase.ExpectRequestBody {
+						t.Errorf("expected request body presence %t, got %t", testCase.ExpectRequestBody, ok)
+					}
+				case "HTTP Response Received":
+					responseLogs++
+					if _, ok := line["http.response.body"]; ok != testCase.ExpectResponseBody {
+						t.Errorf("expected response body presence %t, got %t", testCase.ExpectResponseBody, ok)
+					}
+				}
+			}
+
+			if a, e := requestLogs, testCase.ExpectedRequestLogs; a != e {
+				t.Errorf("expected %d request logs, got %d", e, a)
+			}
+			if a, e := responseLogs, testCase.ExpectedResponseLogs; a != e {
+				t.Errorf("expected %d response logs, got %d", e, a)
+			}
+		})
+	}
+}
+
+// TestSessionDebugLogFilterPerCall checks that the filter is evaluated once per API call, so that retries of a sampled call
+// are logged, and that the request body withheld by BodiesOnErrorOnly is logged when a request fails.
+func TestSessionDebugLogFilterPerCall(t *testing.T) {
+	testCases := map[string]struct {
+		Filter              *awsbase.DebugLogFilter
+		CloseServer         bool
+		ExpectedRequestLogs int
+		ExpectFailedReqBody bool
+	}{
+		"sampled call with retry": {
+			Filter: &awsbase.DebugLogFilter{
+				Rules: []awsbase.DebugLogRule{
+					{Service: "IAM", Operation: "GetUser", SampleRate: 2},
+				},
+			},
+			ExpectedRequestLogs: 2,
+		},
+		"bodies on error only request failed": {
+			Filter: &awsbase.DebugLogFilter{
+				BodiesOnErrorOnly: true,
+			},
+			CloseServer:         true,
+			ExpectFailedReqBody: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			var requests int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) == 1 {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Content-Type", "text/xml")
+				fmt.Fprintln(w, servicemocks.IamResponse_GetUser_valid)
+			}))
+			defer ts.Close()
+			if testCase.CloseServer {
+				ts.Close()
+			}
+
+			config := &awsbase.Config{
+				AccessKey:           servicemocks.MockStaticAccessKey,
+				DebugLogFilter:      testCase.Filter,
+				MaxRetries:          2,
+				Region:              "us-east-1",
+				SecretKey:           servicemocks.MockStaticSecretKey,
+				SkipCredsValidation: true,
+			}
+
+			var buf bytes.Buffer
+			ctx := tflogtest.RootLogger(context.Background(), &buf)
+
+			ctx, awsConfig, err := awsbase.GetAwsConfig(ctx, config)
+			if err != nil {
+				t.Fatalf("GetAwsConfig: unexpected '%[1]T': %[1]s", err)
+			}
+
+			sess, err := GetSession(ctx, &awsConfig, config)
+			if err != nil {
+				t.Fatalf("GetSession: unexpected '%[1]T': %[1]s", err)
+			}
+
+			buf.Reset()
+
+			conn := iam.New(sess, &aws.Config{Endpoint: aws.String(ts.URL)})
+			_, err = conn.GetUserWithContext(ctx, &iam.GetUserInput{})
+			if err != nil && !testCase.CloseServer {
+				t.Fatalf("GetUser: unexpected '%[1]T': %[1]s", err)
+			}
+
+			lines, err := tflogtest.MultilineJSONDecode(&buf)
+			if err != nil {
+				t.Fatalf("decoding log lines: %s", err)
+			}
+
+			var requestLogs int
+			var failedLine map[string]any
+			for _, line := range lines {
+				switch line["@message"] {
+				case "HTTP Request Sent":
+					requestLogs++
+				case "HTTP Request Failed":
+					failedLine = line
+				}
+			}
+
+			if testCase.ExpectedRequestLogs > 0 {
+				if a, e := requestLogs, testCase.ExpectedRequestLogs; a != e {
+					t.Errorf("expected %d request logs, got %d", e, a)
+				}
+			}
+			if testCase.ExpectFailedReqBody {
+				if failedLine == nil {
+					t.Fatal("expected failed request log, got none")
+				}
+				if _, ok := failedLine["http.request.body"]; !ok {
+					t.Error("expected request body in failed request log")
+				}
+			}
+		})
+	}
+}
+
+func TestSessionResponseLogEnrichment(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	ts := servicemocks.MockAwsApiServer("IAM", []*servicemocks.MockEndpoint{
+		{
+			Request:  &servicemocks.MockRequest{Method: http.MethodPost, Uri: "/", Body: "Action=GetUser&Version=2010-05-08"},
+			Response: &servicemocks.MockResponse{StatusCode: http.StatusForbidden, Body: servicemocks.IamResponse_GetUser_unauthorized, ContentType: "text/xml"},
+		},
+	})
+	defer ts.Close()
+
+	config := &awsbase.Config{
+		AccessKey:           servicemocks.MockStaticAccessKey,
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+	}
+
+	var buf bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &buf)
+
+	ctx, awsConfig, err := awsbase.GetAwsConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("GetAwsConfig: unexpected '%[1]T': %[1]s", err)
+	}
+
+	sess, err := GetSession(ctx, &awsConfig, config)
+	if err != nil {
+		t.Fatalf("GetSession: unexpected '%[1]T': %[1]s", err)
+	}
+
+	buf.Reset()
+
+	conn := iam.New(sess, &aws.Config{Endpoint: aws.String(ts.URL)})
+	if _, err := conn.GetUserWithContext(ctx, &iam.GetUserInput{}); err == nil {
+		t.Fatal("GetUser: expected error, got none")
+	}
+
+	lines, err := tflogtest.MultilineJSONDecode(&buf)
+	if err != nil {
+		t.Fatalf("decoding log lines: %s", err)
+	}
+
+	var line map[string]any
+	for _, l := range lines {
+		if l["@message"] == "HTTP Response Received" {
+			line = l
+			break
+		}
+	}
+	if line == nil {
+		t.Fatal("expected response log, got none")
+	}
+
+	expected := map[string]any{
+		"aws.request_id":    servicemocks.MockRequestID,
+		"aws.attempt":       float64(1),
+		"aws.error_code":    "AccessDenied",
+		"aws.error_message": "User: arn:aws:iam::123456789012:user/Bob is not authorized to perform: iam:GetUser on resource: arn:aws:iam::123456789012:user/Bob",
+	}
+	for k, e := range expected {
+		if a := line[k]; a != e {
+			t.Errorf("expected field %q to be %v, got %v", k, e, a)
+		}
+	}
+}
+
+func TestSessionDecodeAuthorizationMessages(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	iamServer := servicemocks.MockAwsApiServer("IAM", []*servicemocks.MockEndpoint{
+		{
+			Request: &servicemocks.MockRequest{Method: http.MethodPost, Uri: "/", Body: "Action=GetUser&Version=2010-05-08"},
+			Response: &servicemocks.MockResponse{
+				StatusCode: http.StatusForbidden,
+				Body: `<ErrorResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/">
+  <Error>
+    <Type>Sender</Type>
+    <Code>AccessDenied</Code>
+    <Message>User: arn:aws:iam::123456789012:user/Bob is not authorized to perform: iam:GetUser. Encoded authorization failure message: ` + servicemocks.MockStsEncodedAuthorizationMessage + `</Message>
+  </Error>
+  <RequestId>7a62c49f-347e-4fc4-9331-6e8eEXAMPLE</RequestId>
+</ErrorResponse>`,
+				ContentType: "text/xml",
+			},
+		},
+	})
+	defer iamServer.Close()
+
+	stsServer := servicemocks.MockAwsApiServer("STS", []*servicemocks.MockEndpoint{
+		servicemocks.MockStsDecodeAuthorizationMessageValidEndpoint,
+	})
+	defer stsServer.Close()
+
+	config := &awsbase.Config{
+		AccessKey:                   servicemocks.MockStaticAccessKey,
+		DecodeAuthorizationMessages: true,
+		Region:                      "us-east-1",
+		SecretKey:                   servicemocks.MockStaticSecretKey,
+		SkipCredsValidation:         true,
+		StsEndpoint:                 stsServer.URL,
+	}
+
+	ctx, awsConfig, err := awsbase.GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GetAwsConfig: unexpected '%[1]T': %[1]s", err)
+	}
+
+	sess, err := GetSession(ctx, &awsConfig, config)
+	if err != nil {
+		t.Fatalf("GetSession: unexpected '%[1]T': %[1]s", err)
+	}
+
+	conn := iam.New(sess, &aws.Config{Endpoint: aws.String(iamServer.URL)})
+	_, err = conn.GetUserWithContext(ctx, &iam.GetUserInput{})
+	if err == nil {
+		t.Fatal("GetUser: expected error, got none")
+	}
+
+	var authErr awsbase.AuthorizationFailureError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("expected AuthorizationFailureError, got '%[1]T': %[1]s", err)
+	}
+	if a, e := authErr.DecodedMessage, servicemocks.MockStsDecodedAuthorizationMessage; a != e {
+		t.Errorf("expected decoded message %q, got %q", e, a)
+	}
+	if !tfawserr.ErrCodeEquals(err, "AccessDenied") {
+		t.Errorf("expected wrapped AccessDenied error, got %s", err)
+	}
+	if !tfawserr.ErrMessageAndOrigErrContain(err, "AccessDenied", "not authorized", "") {
+		t.Errorf("expected AccessDenied error, got %s", err)
+	}
+
+	reqErr, ok := err.(awserr.RequestFailure)
+	if !ok {
+		t.Fatalf("expected awserr.RequestFailure, got '%[1]T': %[1]s", err)
+	}
+	if a, e := reqErr.StatusCode(), http.StatusForbidden; a != e {
+		t.Errorf("expected status code %d, got %d", e, a)
+	}
+	if a, e := reqErr.RequestID(), "7a62c49f-347e-4fc4-9331-6e8eEXAMPLE"; a != e {
+		t.Errorf("expected request ID %q, got %q", e, a)
+	}
+}
+
+// TestSessionDecodeAuthorizationMessagesRetries checks that the message is decoded once, after the last attempt.
+func TestSessionDecodeAuthorizationMessagesRetries(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	var attempts int32
+	iamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, `<ErrorResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/">
+  <Error>
+    <Type>Receiver</Type>
+    <Code>ServiceUnavailable</Code>
+    <Message>Encoded authorization failure message: `+servicemocks.MockStsEncodedAuthorizationMessage+`</Message>
+  </Error>
+  <RequestId>7a62c49f-347e-4fc4-9331-6e8eEXAMPLE</RequestId>
+</ErrorResponse>`)
+	}))
+	defer iamServer.Close()
+
+	var decodes int32
+	stsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&decodes, 1)
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintln(w, servicemocks.MockStsDecodeAuthorizationMessageValidResponseBody)
+	}))
+	defer stsServer.Close()
+
+	config := &awsbase.Config{
+		AccessKey:                   servicemocks.MockStaticAccessKey,
+		DecodeAuthorizationMessages: true,
+		MaxRetries:                  3,
+		Region:                      "us-east-1",
+		SecretKey:                   servicemocks.MockStaticSecretKey,
+		SkipCredsValidation:         true,
+		StsEndpoint:                 stsServer.URL,
+	}
+
+	ctx, awsConfig, err := awsbase.GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("GetAwsConfig: unexpected '%[1]T': %[1]s", err)
+	}
+
+	sess, err := GetSession(ctx, &awsConfig, config)
+	if err != nil {
+		t.Fatalf("GetSession: unexpected '%[1]T': %[1]s", err)
+	}
+
+	conn := iam.New(sess, &aws.Config{
+		Endpoint:   aws.String(iamServer.URL),
+		SleepDelay: func(time.Duration) {},
+	})
+	_, err = conn.GetUserWithContext(ctx, &iam.GetUserInput{})
+	if !awsbase.IsAuthorizationFailureError(err) {
+		t.Fatalf("expected AuthorizationFailureError, got '%[1]T': %[1]s", err)
+	}
+
+	if a := atomic.LoadInt32(&attempts); a < 2 {
+		t.Errorf("expected the request to be retried, got %d attempts", a)
+	}
+	if a, e := atomic.LoadInt32(&decodes), int32(1); a != e {
+		t.Errorf("expected %d DecodeAuthorizationMessage requests, got %d", e, a)
+	}
+}
+
+func TestSessionOffline(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	os.Setenv("AWS_EC2_METADATA_SERVICE_ENDPOINT", ts.URL)
+
+	config := &awsbase.Config{
+		AccessKey:   servicemocks.MockStaticAccessKey,
+		IamEndpoint: ts.URL,
+		Offline:     true,
+		Region:      "us-east-1",
+		SecretKey:   servicemocks.MockStaticSecretKey,
+		StsEndpoint: ts.URL,
+	}
+
+	ctx, awsConfig, err := awsbase.GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	sess, err := GetSession(ctx, &awsConfig, config)
+	if err != nil {
+		t.Fatalf("error in GetSession() '%[1]T': %[1]s", err)
+	}
+
+	if _, err := sess.Config.Credentials.GetWithContext(ctx); err != nil {
+		t.Fatalf("unexpected credentials error: %s", err)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("expected no network requests, got %d", n)
+	}
+}
+
+func TestSessionOfflineAssumeRole(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	ctx, awsConfig, err := awsbase.GetAwsConfig(context.Background(), &awsbase.Config{
+		AccessKey:           servicemocks.MockStaticAccessKey,
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+	})
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	config := &awsbase.Config{
+		AccessKey: servicemocks.MockStaticAccessKey,
+		AssumeRole: &awsbase.AssumeRole{
+			RoleARN:     servicemocks.MockStsAssumeRoleArn,
+			SessionName: servicemocks.MockStsAssumeRoleSessionName,
+		},
+		Offline:   true,
+		Region:    "us-east-1",
+		SecretKey: servicemocks.MockStaticSecretKey,
+	}
+
+	_, err = GetSession(ctx, &awsConfig, config)
+	if !awsbase.IsOfflineError(err) {
+		t.Fatalf("expected OfflineError, got '%[1]T': %[1]v", err)
+	}
+}
+
+func TestSessionCredentialsRefresh(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	closeSts, mockStsSession, err := mockdata.GetMockedAwsApiSession("STS", []*servicemocks.MockEndpoint{
+		servicemocks.MockStsAssumeRoleValidEndpoint,
+	})
+	defer closeSts()
+
+	if err != nil {
+		t.Fatalf("unexpected error creating mock STS server: %s", err)
+	}
+
+	config := &awsbase.Config{
+		AccessKey: servicemocks.MockStaticAccessKey,
+		AssumeRole: &awsbase.AssumeRole{
+			RoleARN:     servicemocks.MockStsAssumeRoleArn,
+			SessionName: servicemocks.MockStsAssumeRoleSessionName,
+		},
+		CredentialsRefresh: &awsbase.CredentialsRefresh{
+			ExpiryWindow: 15 * time.Minute,
+		},
+		Region:              "us-east-1",
+		SecretKey:           servicemocks.MockStaticSecretKey,
+		SkipCredsValidation: true,
+		StsEndpoint:         aws.StringValue(mockStsSession.Config.Endpoint),
+	}
+
+	ctx, awsConfig, err := awsbase.GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	sess, err := GetSession(ctx, &awsConfig, config)
+	if err != nil {
+		t.Fatalf("error in GetSession() '%[1]T': %[1]s", err)
+	}
+
+	if _, err := sess.Config.Credentials.GetWithContext(ctx); err != nil {
+		t.Fatalf("unexpected credentials error: %s", err)
+	}
+
+	expiry, err := sess.Config.Credentials.ExpiresAt()
+	if err != nil {
+		t.Fatalf("unexpected error getting expiry: %s", err)
+	}
+
+	// The mock STS responses expire at 2099-12-31T23:59:59Z
+	if e := time.Date(2099, time.December, 31, 23, 44, 59, 0, time.UTC); !expiry.Equal(e) {
+		t.Errorf("expected expiry %s, got %s", e, expiry)
+	}
+}
+
+func TestSessionExpiredCredentials(t *testing.T) {
+	testCases := map[string]struct {
+		V1First              bool
+		WatchCredentialFiles *awsbase.WatchCredentialFiles
+	}{
+		"v2 client first": {},
+		"v1 client first": {
+			V1First: true,
+		},
+		"v1 client first watch credential files": {
+			V1First:              true,
+			WatchCredentialFiles: &awsbase.WatchCredentialFiles{},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			ts, revoke, assumeRoleCount := servicemocks.MockStsRevokedCredentialsServer()
+			defer ts.Close()
+
+			config := &awsbase.Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				AssumeRole: &awsbase.AssumeRole{
+					RoleARN:     servicemocks.MockStsAssumeRoleArn,
+					SessionName: servicemocks.MockStsAssumeRoleSessionName,
+				},
+				Region:               "us-east-1",
+				SecretKey:            servicemocks.MockStaticSecretKey,
+				SkipCredsValidation:  true,
+				StsEndpoint:          ts.URL,
+				WatchCredentialFiles: testCase.WatchCredentialFiles,
+			}
+
+			ctx, awsConfig, err := awsbase.GetAwsConfig(context.Background(), config)
+			if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+			defer awsbase.CloseAwsConfig(awsConfig)
+
+			sess, err := GetSession(ctx, &awsConfig, config)
+			if err != nil {
+				t.Fatalf("error in GetSession() '%[1]T': %[1]s", err)
+			}
+
+			// Both clients start with the revoked credentials
+			if _, err := sess.Config.Credentials.GetWithContext(ctx); err != nil {
+				t.Fatalf("unexpected credentials error: %s", err)
+			}
+			revoke()
+			initialCount := assumeRoleCount()
+
+			v1Client := sts.New(sess, aws.NewConfig().WithEndpoint(ts.URL))
+			v2Client := stsv2.NewFromConfig(awsConfig, func(o *stsv2.Options) {
+				o.EndpointResolver = stsv2.EndpointResolverFromURL(ts.URL)
+			})
+
+			callV1 := func() {
+				t.Helper()
+				if _, err := v1Client.GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+					t.Fatalf("unexpected v1 GetCallerIdentity() error: %s", err)
+				}
+			}
+			callV2 := func() {
+				t.Helper()
+				if _, err := v2Client.GetCallerIdentity(ctx, &stsv2.GetCallerIdentityInput{}); err != nil {
+					t.Fatalf("unexpected v2 GetCallerIdentity() error: %s", err)
+				}
+			}
+
+			if testCase.V1First {
+				callV1()
+				callV2()
+			} else {
+				callV2()
+				callV1()
+			}
+
+			// The role is assumed again only once after the credentials are revoked
+			if a, e := assumeRoleCount(), initialCount+1; a != e {
+				t.Errorf("expected %d AssumeRole calls, got %d", e, a)
+			}
+
+			v1Value, err := sess.Config.Credentials.GetWithContext(ctx)
+			if err != nil {
+				t.Fatalf("unexpected credentials error: %s", err)
+			}
+			v2Value, err := awsConfig.Credentials.Retrieve(ctx)
+			if err != nil {
+				t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+			}
+			if a, e := v1Value.SessionToken, v2Value.SessionToken; a != e {
+				t.Errorf("expected v1 and v2 session tokens to match, got %q and %q", a, e)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfawserr
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	tfawserrv2 "github.com/hashicorp/aws-sdk-go-base/v2/tfawserr"
+)
+
+// TestIsTransientNetwork checks the classification of AWS SDK for Go v1 errors by the AWS SDK for Go v2 package.
+func TestIsTransientNetwork(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Err      error
+		Expected bool
+	}{
+		{
+			Name: "request canceled deadline exceeded",
+			Err:  awserr.New(request.CanceledErrorCode, "request context canceled", context.DeadlineExceeded),
+		},
+		{
+			Name: "request canceled",
+			Err:  awserr.New(request.CanceledErrorCode, "request context canceled", context.Canceled),
+		},
+		{
+			Name:     "connection reset",
+			Err:      awserr.New(request.ErrCodeRequestError, "send request failed", &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}),
+			Expected: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.Name, func(t *testing.T) {
+			got := tfawserrv2.IsTransientNetwork(testCase.Err)
+
+			if got != testCase.Expected {
+				t.Errorf("got %t, expected %t", got, testCase.Expected)
+			}
+		})
+	}
+}
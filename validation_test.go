@@ -4,7 +4,20 @@
 package awsbase
 
 import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/aws-sdk-go-base/v2/mockdata"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+	multierror "github.com/hashicorp/go-multierror"
 )
 
 func TestValidateRegion(t *testing.T) {
@@ -48,3 +61,384 @@ func TestValidateRegion(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigValidate(t *testing.T) {
+	testCases := map[string]struct {
+		Config        Config
+		ExpectedPaths []string
+	}{
+		"empty": {},
+		"valid": {
+			Config: Config{
+				AccessKey: "AccessKey",
+				AssumeRole: &AssumeRole{
+					RoleARN:           "arn:aws:iam::555555555555:role/AssumeRole",
+					Duration:          1 * time.Hour,
+					ExternalID:        "ExternalID",
+					Policy:            `{"Version": "2012-10-17", "Statement": []}`,
+					PolicyARNs:        []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"},
+					SessionName:       "{{.User}}-{{.Timestamp}}",
+					SourceIdentity:    "SourceIdentity-{{.Timestamp}}",
+					Tags:              map[string]string{"key": "value"},
+					TransitiveTagKeys: []string{"key"},
+				},
+				EC2MetadataServiceEndpoint:     "http://169.254.169.254",
+				EC2MetadataServiceEndpointMode: "IPv4",
+				HTTPProxy:                      "http://proxy.example.com:3128",
+				HTTPTrafficLogFormat:           HTTPTrafficLogFormatHAR,
+				Region:                         "us-west-2",
+				SecretKey:                      "SecretKey",
+				StsEndpoint:                    "https://sts.example.com",
+			},
+		},
+		"credentials": {
+			Config: Config{
+				SecretKey: "SecretKey",
+				Token:     "Token",
+			},
+			ExpectedPaths: []string{"AccessKey", "Token"},
+		},
+		"assume role": {
+			Config: Config{
+				AssumeRole: &AssumeRole{
+					RoleARN:           "arn:aws:s3:::bucket",
+					Duration:          1 * time.Minute,
+					ExternalID:        "x",
+					Policy:            "{",
+					PolicyARNs:        []string{"invalid"},
+					SessionName:       "invalid session name",
+					Tags:              map[string]string{"key": strings.Repeat("v", 257)},
+					TransitiveTagKeys: []string{"other"},
+				},
+			},
+			ExpectedPaths: []string{
+				"AssumeRole.Duration",
+				"AssumeRole.ExternalID",
+				"AssumeRole.Policy",
+				"AssumeRole.PolicyARNs[0]",
+				"AssumeRole.RoleARN",
+				"AssumeRole.SessionName",
+				`AssumeRole.Tags["key"]`,
+				"AssumeRole.TransitiveTagKeys[0]",
+			},
+		},
+		"assume role tags": {
+			Config: Config{
+				AssumeRole: &AssumeRole{
+					RoleARN: "arn:aws:iam::555555555555:role/AssumeRole",
+					Tags: map[string]string{
+						"Team":  "a",
+						"team":  "b",
+						"a*b":   "c",
+						"valid": "d|e",
+					},
+				},
+			},
+			ExpectedPaths: []string{
+				`AssumeRole.Tags["a*b"]`,
+				`AssumeRole.Tags["team"]`,
+				`AssumeRole.Tags["valid"]`,
+			},
+		},
+		"assume role session name template": {
+			Config: Config{
+				AssumeRole: &AssumeRole{
+					RoleARN:        "arn:aws:iam::555555555555:role/AssumeRole",
+					SessionName:    `{{.User}} {{.Hostname}}`,
+					SourceIdentity: `{{.Unknown}}`,
+				},
+			},
+			ExpectedPaths: []string{"AssumeRole.SessionName", "AssumeRole.SourceIdentity"},
+		},
+		"assume role missing role ARN": {
+			Config: Config{
+				AssumeRole: &AssumeRole{},
+			},
+			ExpectedPaths: []string{"AssumeRole.RoleARN"},
+		},
+		"assume role with web identity conflicting token": {
+			Config: Config{
+				AssumeRoleWithWebIdentity: &AssumeRoleWithWebIdentity{
+					RoleARN:              "arn:aws:iam::666666666666:role/WebIdentityToken",
+					WebIdentityToken:     "token",
+					WebIdentityTokenFile: "token-file",
+				},
+			},
+			ExpectedPaths: []string{"AssumeRoleWithWebIdentity.WebIdentityTokenFile"},
+		},
+		"assume role with web identity missing token file": {
+			Config: Config{
+				AssumeRoleWithWebIdentity: &AssumeRoleWithWebIdentity{
+					RoleARN:              "arn:aws:iam::666666666666:role/WebIdentityToken",
+					WebIdentityTokenFile: filepath.Join(t.TempDir(), "missing"),
+				},
+			},
+			ExpectedPaths: []string{"AssumeRoleWithWebIdentity.WebIdentityTokenFile"},
+		},
+		"web identity token retriever": {
+			Config: Config{
+				AssumeRoleWithWebIdentity: &AssumeRoleWithWebIdentity{
+					RoleARN:                   "arn:aws:iam::666666666666:role/WebIdentityToken",
+					WebIdentityToken:          "WebIdentityToken",
+					WebIdentityTokenRetriever: GitLabIdentityTokenRetriever{},
+				},
+			},
+			ExpectedPaths: []string{"AssumeRoleWithWebIdentity.WebIdentityTokenRetriever"},
+		},
+		"get session token": {
+			Config: Config{
+				GetSessionToken: &GetSessionToken{
+					Duration:     48 * time.Hour,
+					SerialNumber: "mfa",
+					TokenCode:    "12345",
+				},
+				Token: "Token",
+			},
+			ExpectedPaths: []string{
+				"GetSessionToken",
+				"GetSessionToken.Duration",
+				"GetSessionToken.SerialNumber",
+				"GetSessionToken.TokenCode",
+				"Token",
+			},
+		},
+		"get session token missing token code": {
+			Config: Config{
+				GetSessionToken: &GetSessionToken{
+					SerialNumber: "arn:aws:iam::222222222222:mfa/MFADevice",
+				},
+			},
+			ExpectedPaths: []string{"GetSessionToken.TokenCode"},
+		},
+		"container credentials": {
+			Config: Config{
+				AccessKey: "AccessKey",
+				ContainerCredentials: &ContainerCredentials{
+					AuthorizationToken:     "Token\n",
+					AuthorizationTokenFile: filepath.Join(t.TempDir(), "missing"),
+					FullURI:                "http://example.com/creds",
+					RelativeURI:            "creds",
+					Timeout:                -1 * time.Second,
+				},
+			},
+			ExpectedPaths: []string{
+				"ContainerCredentials",
+				"ContainerCredentials.AuthorizationToken",
+				"ContainerCredentials.AuthorizationTokenFile",
+				"ContainerCredentials.AuthorizationTokenFile",
+				"ContainerCredentials.FullURI",
+				"ContainerCredentials.RelativeURI",
+				"ContainerCredentials.RelativeURI",
+				"ContainerCredentials.Timeout",
+				"SecretKey",
+			},
+		},
+		"container credentials allowed hosts": {
+			Config: Config{
+				ContainerCredentials: &ContainerCredentials{
+					AllowedHosts: []string{"credentials.example.com"},
+					FullURI:      "https://example.com/creds",
+				},
+			},
+			ExpectedPaths: []string{"ContainerCredentials.FullURI"},
+		},
+		"endpoints and transport": {
+			Config: Config{
+				CustomCABundle:                 filepath.Join(t.TempDir(), "missing"),
+				EC2MetadataServiceEndpoint:     "169.254.169.254",
+				EC2MetadataServiceEndpointMode: "IPv5",
+				HTTPClient:                     &http.Client{},
+				HTTPProxy:                      "http://%zz",
+				HTTPTrafficLogFormat:           "xml",
+				Insecure:                       true,
+				MaxRetries:                     -1,
+				Region:                         "us-invalid-1",
+				StsRegion:                      "invalid",
+				Timeouts: &Timeouts{
+					AssumeRole: -time.Second,
+				},
+				WatchCredentialFiles: &WatchCredentialFiles{
+					Interval: -time.Second,
+				},
+				CredentialsRefresh: &CredentialsRefresh{
+					ExpiryWindowJitterFrac: 1.5,
+				},
+			},
+			ExpectedPaths: []string{
+				"CredentialsRefresh.ExpiryWindowJitterFrac",
+				"CustomCABundle",
+				"EC2MetadataServiceEndpoint",
+				"EC2MetadataServiceEndpointMode",
+				"HTTPProxy",
+				"HTTPProxy",
+				"HTTPTrafficLogFormat",
+				"Insecure",
+				"MaxRetries",
+				"Region",
+				"StsRegion",
+				"Timeouts.AssumeRole",
+				"WatchCredentialFiles.Interval",
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			err := testCase.Config.Validate()
+
+			var paths []string
+			if err != nil {
+				var merr *multierror.Error
+				if !errors.As(err, &merr) {
+					t.Fatalf("expected multierror, got '%[1]T': %[1]s", err)
+				}
+				for _, e := range merr.Errors {
+					var verr ValidationError
+					if !errors.As(e, &verr) {
+						t.Fatalf("expected ValidationError, got '%[1]T': %[1]s", e)
+					}
+					paths = append(paths, verr.Path)
+				}
+			}
+			sort.Strings(paths)
+
+			if diff := cmp.Diff(paths, testCase.ExpectedPaths); diff != "" {
+				t.Errorf("unexpected error paths: (- got, + expected)\n%s\n%s", diff, err)
+			}
+		})
+	}
+}
+
+func TestAssumeRolePreflightValidation(t *testing.T) {
+	testCases := map[string]struct {
+		Config        *Config
+		ExpectedPaths []string
+	}{
+		"assume role": {
+			Config: &Config{
+				AccessKey: servicemocks.MockStaticAccessKey,
+				AssumeRole: &AssumeRole{
+					RoleARN:           servicemocks.MockStsAssumeRoleArn,
+					Duration:          13 * time.Hour,
+					Policy:            `{"Version": "2012-10-17",}`,
+					PolicyARNs:        []string{"ReadOnlyAccess"},
+					SessionName:       servicemocks.MockStsAssumeRoleSessionName,
+					TransitiveTagKeys: []string{"missing"},
+				},
+				SecretKey: servicemocks.MockStaticSecretKey,
+			},
+			ExpectedPaths: []string{
+				"AssumeRole.Duration",
+				"AssumeRole.Policy",
+				"AssumeRole.PolicyARNs[0]",
+				"AssumeRole.TransitiveTagKeys[0]",
+			},
+		},
+
+		"assume role with web identity": {
+			Config: &Config{
+				AssumeRoleWithWebIdentity: &AssumeRoleWithWebIdentity{
+					RoleARN:          "arn:aws:iam::666666666666:user/WebIdentityToken",
+					Duration:         time.Minute,
+					SessionName:      servicemocks.MockStsAssumeRoleWithWebIdentitySessionName,
+					WebIdentityToken: servicemocks.MockWebIdentityToken,
+				},
+			},
+			ExpectedPaths: []string{
+				"AssumeRoleWithWebIdentity.Duration",
+				"AssumeRoleWithWebIdentity.RoleARN",
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			// STS is never called
+			closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", nil)
+			defer closeSts()
+
+			testCase.Config.Region = "us-east-1"
+			testCase.Config.SkipCredsValidation = true
+			testCase.Config.StsEndpoint = stsEndpoint
+
+			_, _, err := GetAwsConfig(context.Background(), testCase.Config)
+			if err == nil {
+				t.Fatal("expected error, got none")
+			}
+			var merr *multierror.Error
+			if !errors.As(err, &merr) {
+				t.Fatalf("expected multierror, got '%[1]T': %[1]s", err)
+			}
+			var paths []string
+			for _, e := range merr.Errors {
+				var verr ValidationError
+				if !errors.As(e, &verr) {
+					t.Fatalf("expected ValidationError, got '%[1]T': %[1]s", e)
+				}
+				paths = append(paths, verr.Path)
+			}
+			sort.Strings(paths)
+
+			if diff := cmp.Diff(paths, testCase.ExpectedPaths); diff != "" {
+				t.Errorf("unexpected error paths: (- got, + expected)\n%s\n%s", diff, err)
+			}
+		})
+	}
+}
+
+func TestPackedPolicySizeEstimate(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randomString := func(n int) string {
+		const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = chars[r.Intn(len(chars))]
+		}
+		return string(b)
+	}
+
+	largeTags := make(map[string]string, 50)
+	for i := 0; i < 50; i++ {
+		largeTags[randomString(128)] = randomString(256)
+	}
+
+	testCases := map[string]struct {
+		AssumeRole  AssumeRole
+		ExpectedMax int
+		ExpectedMin int
+	}{
+		"empty": {},
+		"small policy": {
+			AssumeRole: AssumeRole{
+				Policy: servicemocks.MockStsAssumeRolePolicy,
+				Tags:   map[string]string{"key": "value"},
+			},
+			ExpectedMax: 100,
+		},
+		"large tags": {
+			AssumeRole: AssumeRole{
+				Tags: largeTags,
+			},
+			ExpectedMin: 101,
+			ExpectedMax: 10000,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			size := testCase.AssumeRole.PackedPolicySizeEstimate()
+			if size < testCase.ExpectedMin || size > testCase.ExpectedMax {
+				t.Errorf("expected estimate between %d and %d, got %d", testCase.ExpectedMin, testCase.ExpectedMax, size)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+)
+
+const (
+	// DefaultGitHubActionsAudience is the audience requested by GitHubActionsIdentityTokenRetriever when Audience is not set.
+	DefaultGitHubActionsAudience = "sts.amazonaws.com"
+
+	// DefaultGitLabIdentityTokenEnvVar is the environment variable read by GitLabIdentityTokenRetriever when EnvVar is not set.
+	DefaultGitLabIdentityTokenEnvVar = "CI_JOB_JWT_V2"
+
+	githubActionsIDTokenRequestURLEnvVar   = "ACTIONS_ID_TOKEN_REQUEST_URL"
+	githubActionsIDTokenRequestTokenEnvVar = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+
+	identityTokenRequestTimeout = 30 * time.Second
+	identityTokenMaxSize        = 64 * 1024
+)
+
+var (
+	_ stscreds.IdentityTokenRetriever = GitHubActionsIdentityTokenRetriever{}
+	_ stscreds.IdentityTokenRetriever = GitLabIdentityTokenRetriever{}
+	_ stscreds.IdentityTokenRetriever = HTTPIdentityTokenRetriever{}
+)
+
+// GitHubActionsIdentityTokenRetriever retrieves an OIDC token from the GitHub Actions ID token endpoint.
+// The job must have the `id-token: write` permission.
+// The endpoint is read from ACTIONS_ID_TOKEN_REQUEST_URL and ACTIONS_ID_TOKEN_REQUEST_TOKEN each time a token is retrieved.
+type GitHubActionsIdentityTokenRetriever struct {
+	// Audience is the audience of the token. Defaults to DefaultGitHubActionsAudience.
+	Audience string
+
+	// HTTPClient is used to request the token. Defaults to a client with a 30 second timeout.
+	HTTPClient *http.Client
+}
+
+// GetIdentityToken implements `stscreds.IdentityTokenRetriever`.
+func (r GitHubActionsIdentityTokenRetriever) GetIdentityToken() ([]byte, error) {
+	requestURL := os.Getenv(githubActionsIDTokenRequestURLEnvVar)
+	requestToken := os.Getenv(githubActionsIDTokenRequestTokenEnvVar)
+	if requestURL == "" || requestToken == "" {
+		return nil, fmt.Errorf("GitHub Actions ID token: %s and %s must be set, check that the job has the id-token: write permission",
+			githubActionsIDTokenRequestURLEnvVar, githubActionsIDTokenRequestTokenEnvVar)
+	}
+
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub Actions ID token: parsing %s: %w", githubActionsIDTokenRequestURLEnvVar, err)
+	}
+	audience := r.Audience
+	if audience == "" {
+		audience = DefaultGitHubActionsAudience
+	}
+	query := u.Query()
+	query.Set("audience", audience)
+	u.RawQuery = query.Encode()
+
+	b, err := requestIdentityToken(r.HTTPClient, u.String(), map[string]string{
+		"Accept":        "application/json",
+		"Authorization": "Bearer " + requestToken,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GitHub Actions ID token: %w", err)
+	}
+
+	token, err := identityTokenFromJSON(b, "value")
+	if err != nil {
+		return nil, fmt.Errorf("GitHub Actions ID token: %w", err)
+	}
+	return token, nil
+}
+
+// GitLabIdentityTokenRetriever retrieves an OIDC token from a GitLab CI/CD environment variable,
+// such as CI_JOB_JWT_V2 or a variable declared with `id_tokens`.
+// The variable is read each time a token is retrieved.
+type GitLabIdentityTokenRetriever struct {
+	// EnvVar is the name of the environment variable containing the token. Defaults to DefaultGitLabIdentityTokenEnvVar.
+	EnvVar string
+}
+
+// GetIdentityToken implements `stscreds.IdentityTokenRetriever`.
+func (r GitLabIdentityTokenRetriever) GetIdentityToken() ([]byte, error) {
+	name := r.EnvVar
+	if name == "" {
+		name = DefaultGitLabIdentityTokenEnvVar
+	}
+
+	token := strings.TrimSpace(os.Getenv(name))
+	if token == "" {
+		return nil, fmt.Errorf("GitLab ID token: environment variable %s is not set", name)
+	}
+	return []byte(token), nil
+}
+
+// HTTPIdentityTokenRetriever retrieves an OIDC token with an HTTP GET request each time a token is retrieved.
+type HTTPIdentityTokenRetriever struct {
+	// URL is the URL of the token endpoint.
+	URL string
+
+	// Headers are added to the request, e.g. an Authorization header.
+	Headers map[string]string
+
+	// ResponseField is the name of the field of a JSON response object containing the token.
+	// If it is not set, the whole response body is the token.
+	ResponseField string
+
+	// HTTPClient is used to request the token. Defaults to a client with a 30 second timeout.
+	HTTPClient *http.Client
+}
+
+// GetIdentityToken implements `stscreds.IdentityTokenRetriever`.
+func (r HTTPIdentityTokenRetriever) GetIdentityToken() ([]byte, error) {
+	if r.URL == "" {
+		return nil, errors.New("HTTP identity token: URL must be set")
+	}
+
+	b, err := requestIdentityToken(r.HTTPClient, r.URL, r.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP identity token: %w", err)
+	}
+
+	if r.ResponseField == "" {
+		token := strings.TrimSpace(string(b))
+		if token == "" {
+			return nil, errors.New("HTTP identity token: empty response")
+		}
+		return []byte(token), nil
+	}
+
+	token, err := identityTokenFromJSON(b, r.ResponseField)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP identity token: %w", err)
+	}
+	return token, nil
+}
+
+func requestIdentityToken(client *http.Client, url string, headers map[string]string) ([]byte, error) {
+	if client == nil {
+		client = &http.Client{Timeout: identityTokenRequestTimeout}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, identityTokenMaxSize))
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("requesting token: unexpected status %s", resp.Status)
+	}
+
+	return b, nil
+}
+
+func identityTokenFromJSON(b []byte, field string) ([]byte, error) {
+	var v map[string]any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	token, ok := v[field].(string)
+	if !ok || token == "" {
+		return nil, fmt.Errorf("response does not contain a %q string", field)
+	}
+	return []byte(token), nil
+}
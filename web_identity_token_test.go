@@ -0,0 +1,296 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package awsbase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/hashicorp/aws-sdk-go-base/v2/mockdata"
+	"github.com/hashicorp/aws-sdk-go-base/v2/servicemocks"
+)
+
+const mockGitHubActionsRequestToken = "GitHubActionsRequestToken"
+
+func mockGitHubActionsIDTokenServer(t *testing.T, audience string) *httptest.Server {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+mockGitHubActionsRequestToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("audience") != audience {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"value": servicemocks.MockWebIdentityToken,
+		})
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func TestIdentityTokenRetrievers(t *testing.T) {
+	testCases := map[string]struct {
+		Retriever            func(t *testing.T) stscreds.IdentityTokenRetriever
+		EnvironmentVariables func(t *testing.T) map[string]string
+		ExpectedError        bool
+	}{
+		"GitHub Actions default audience": {
+			Retriever: func(t *testing.T) stscreds.IdentityTokenRetriever {
+				return GitHubActionsIdentityTokenRetriever{}
+			},
+			EnvironmentVariables: func(t *testing.T) map[string]string {
+				ts := mockGitHubActionsIDTokenServer(t, DefaultGitHubActionsAudience)
+				return map[string]string{
+					"ACTIONS_ID_TOKEN_REQUEST_URL":   ts.URL + "/token?api-version=2.0",
+					"ACTIONS_ID_TOKEN_REQUEST_TOKEN": mockGitHubActionsRequestToken,
+				}
+			},
+		},
+
+		"GitHub Actions audience": {
+			Retriever: func(t *testing.T) stscreds.IdentityTokenRetriever {
+				return GitHubActionsIdentityTokenRetriever{Audience: "custom"}
+			},
+			EnvironmentVariables: func(t *testing.T) map[string]string {
+				ts := mockGitHubActionsIDTokenServer(t, "custom")
+				return map[string]string{
+					"ACTIONS_ID_TOKEN_REQUEST_URL":   ts.URL + "/token",
+					"ACTIONS_ID_TOKEN_REQUEST_TOKEN": mockGitHubActionsRequestToken,
+				}
+			},
+		},
+
+		"GitHub Actions invalid request token": {
+			Retriever: func(t *testing.T) stscreds.IdentityTokenRetriever {
+				return GitHubActionsIdentityTokenRetriever{}
+			},
+			EnvironmentVariables: func(t *testing.T) map[string]string {
+				ts := mockGitHubActionsIDTokenServer(t, DefaultGitHubActionsAudience)
+				return map[string]string{
+					"ACTIONS_ID_TOKEN_REQUEST_URL":   ts.URL + "/token",
+					"ACTIONS_ID_TOKEN_REQUEST_TOKEN": "invalid",
+				}
+			},
+			ExpectedError: true,
+		},
+
+		"GitHub Actions not configured": {
+			Retriever: func(t *testing.T) stscreds.IdentityTokenRetriever {
+				return GitHubActionsIdentityTokenRetriever{}
+			},
+			ExpectedError: true,
+		},
+
+		"GitLab default variable": {
+			Retriever: func(t *testing.T) stscreds.IdentityTokenRetriever {
+				return GitLabIdentityTokenRetriever{}
+			},
+			EnvironmentVariables: func(t *testing.T) map[string]string {
+				return map[string]string{
+					"CI_JOB_JWT_V2": servicemocks.MockWebIdentityToken,
+				}
+			},
+		},
+
+		"GitLab variable": {
+			Retriever: func(t *testing.T) stscreds.IdentityTokenRetriever {
+				return GitLabIdentityTokenRetriever{EnvVar: "GITLAB_OIDC_TOKEN"}
+			},
+			EnvironmentVariables: func(t *testing.T) map[string]string {
+				return map[string]string{
+					"GITLAB_OIDC_TOKEN": servicemocks.MockWebIdentityToken,
+				}
+			},
+		},
+
+		"GitLab variable not set": {
+			Retriever: func(t *testing.T) stscreds.IdentityTokenRetriever {
+				return GitLabIdentityTokenRetriever{}
+			},
+			ExpectedError: true,
+		},
+
+		"HTTP body": {
+			Retriever: func(t *testing.T) stscreds.IdentityTokenRetriever {
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.Header.Get("X-Token-Request") != "true" {
+						w.WriteHeader(http.StatusForbidden)
+						return
+					}
+					_, _ = w.Write([]byte(servicemocks.MockWebIdentityToken + "\n"))
+				}))
+				t.Cleanup(ts.Close)
+
+				return HTTPIdentityTokenRetriever{
+					URL: ts.URL,
+					Headers: map[string]string{
+						"X-Token-Request": "true",
+					},
+				}
+			},
+		},
+
+		"HTTP response field": {
+			Retriever: func(t *testing.T) stscreds.IdentityTokenRetriever {
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "application/json")
+					_ = json.NewEncoder(w).Encode(map[string]string{
+						"id_token": servicemocks.MockWebIdentityToken,
+					})
+				}))
+				t.Cleanup(ts.Close)
+
+				return HTTPIdentityTokenRetriever{
+					URL:           ts.URL,
+					ResponseField: "id_token",
+				}
+			},
+		},
+
+		"HTTP missing response field": {
+			Retriever: func(t *testing.T) stscreds.IdentityTokenRetriever {
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					_, _ = w.Write([]byte(`{"token": "WebIdentityToken"}`))
+				}))
+				t.Cleanup(ts.Close)
+
+				return HTTPIdentityTokenRetriever{
+					URL:           ts.URL,
+					ResponseField: "id_token",
+				}
+			},
+			ExpectedError: true,
+		},
+
+		"HTTP error status": {
+			Retriever: func(t *testing.T) stscreds.IdentityTokenRetriever {
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+				}))
+				t.Cleanup(ts.Close)
+
+				return HTTPIdentityTokenRetriever{
+					URL: ts.URL,
+				}
+			},
+			ExpectedError: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testName, func(t *testing.T) {
+			oldEnv := servicemocks.InitSessionTestEnv()
+			defer servicemocks.PopEnv(oldEnv)
+
+			if testCase.EnvironmentVariables != nil {
+				for k, v := range testCase.EnvironmentVariables(t) {
+					os.Setenv(k, v)
+				}
+			}
+
+			closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", []*servicemocks.MockEndpoint{
+				servicemocks.MockStsAssumeRoleWithWebIdentityValidEndpoint,
+			})
+			defer closeSts()
+
+			config := &Config{
+				AssumeRoleWithWebIdentity: &AssumeRoleWithWebIdentity{
+					RoleARN:                   servicemocks.MockStsAssumeRoleWithWebIdentityArn,
+					SessionName:               servicemocks.MockStsAssumeRoleWithWebIdentitySessionName,
+					WebIdentityTokenRetriever: testCase.Retriever(t),
+				},
+				Region:              "us-east-1",
+				SkipCredsValidation: true,
+				StsEndpoint:         stsEndpoint,
+			}
+
+			ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+			if testCase.ExpectedError {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+			}
+
+			credentialsValue, err := awsConfig.Credentials.Retrieve(ctx)
+			if err != nil {
+				t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+			}
+			if a, e := credentialsValue.AccessKeyID, servicemocks.MockStsAssumeRoleWithWebIdentityAccessKey; a != e {
+				t.Errorf("expected access key %q, got %q", e, a)
+			}
+		})
+	}
+}
+
+// TestIdentityTokenRetrieverRefresh checks that a new token is retrieved each time credentials are refreshed.
+func TestIdentityTokenRetrieverRefresh(t *testing.T) {
+	oldEnv := servicemocks.InitSessionTestEnv()
+	defer servicemocks.PopEnv(oldEnv)
+
+	var tokenRequests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tokenRequests, 1)
+		_, _ = w.Write([]byte(servicemocks.MockWebIdentityToken))
+	}))
+	defer ts.Close()
+
+	closeSts, _, stsEndpoint := mockdata.GetMockedAwsApiSession("STS", []*servicemocks.MockEndpoint{
+		servicemocks.MockStsAssumeRoleWithWebIdentityValidEndpoint,
+		servicemocks.MockStsAssumeRoleWithWebIdentityValidEndpoint,
+	})
+	defer closeSts()
+
+	config := &Config{
+		AssumeRoleWithWebIdentity: &AssumeRoleWithWebIdentity{
+			RoleARN:                   servicemocks.MockStsAssumeRoleWithWebIdentityArn,
+			SessionName:               servicemocks.MockStsAssumeRoleWithWebIdentitySessionName,
+			WebIdentityTokenRetriever: HTTPIdentityTokenRetriever{URL: ts.URL},
+		},
+		Region:              "us-east-1",
+		SkipCredsValidation: true,
+		StsEndpoint:         stsEndpoint,
+	}
+
+	ctx, awsConfig, err := GetAwsConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("error in GetAwsConfig() '%[1]T': %[1]s", err)
+	}
+
+	cache, ok := awsConfig.Credentials.(*aws.CredentialsCache)
+	if !ok {
+		t.Fatalf("expected *aws.CredentialsCache, got %T", awsConfig.Credentials)
+	}
+	initialRequests := atomic.LoadInt32(&tokenRequests)
+	if initialRequests == 0 {
+		t.Fatal("expected token requests, got none")
+	}
+
+	cache.Invalidate()
+	if _, err := awsConfig.Credentials.Retrieve(ctx); err != nil {
+		t.Fatalf("unexpected credentials Retrieve() error: %s", err)
+	}
+
+	if a, e := atomic.LoadInt32(&tokenRequests), initialRequests+1; a != e {
+		t.Errorf("expected %d token requests, got %d", e, a)
+	}
+}